@@ -53,6 +53,30 @@ func main() {
 		os.Exit(1)
 	}
 
+	if err := (&controller.NetworkSplitReconciler{}).
+		SetupWithManager(mgr); err != nil {
+		log.Printf("unable to create controller: %v", err)
+		os.Exit(1)
+	}
+
+	if err := (&controller.LeasePriorityClassReconciler{}).
+		SetupWithManager(mgr); err != nil {
+		log.Printf("unable to create controller: %v", err)
+		os.Exit(1)
+	}
+
+	if err := (&controller.LeaseQuotaReconciler{}).
+		SetupWithManager(mgr); err != nil {
+		log.Printf("unable to create controller: %v", err)
+		os.Exit(1)
+	}
+
+	if err := (&controller.ReservationReconciler{}).
+		SetupWithManager(mgr); err != nil {
+		log.Printf("unable to create controller: %v", err)
+		os.Exit(1)
+	}
+
 	if err := (&controller.NamespaceReconciler{}).
 		SetupWithManager(mgr); err != nil {
 		log.Printf("unable to create controller: %v", err)
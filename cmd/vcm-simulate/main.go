@@ -0,0 +1,202 @@
+// vcm-simulate shows what the capacity manager would do with a set of Pools, Networks and Leases
+// without talking to an API server. Feed it an `oc get -o yaml` dump, edited to try out a change
+// such as cordoning a pool, and it reports which pool and networks each waiting lease would get,
+// the resulting pool utilization and which leases would stay pending and why.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/yaml"
+
+	v1 "github.com/openshift-splat-team/vsphere-capacity-manager/pkg/apis/vspherecapacitymanager.splat.io/v1"
+	"github.com/openshift-splat-team/vsphere-capacity-manager/pkg/controller"
+)
+
+type fileList []string
+
+func (f *fileList) String() string { return strings.Join(*f, ",") }
+
+func (f *fileList) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+func main() {
+	var files fileList
+	flag.Var(&files, "f", "YAML or JSON file with Pools, Networks, Leases, LeasePriorityClasses, LeaseQuotas and Reservations; may be repeated, - reads stdin")
+	output := flag.String("o", "text", "output format: text or yaml")
+	verbose := flag.Bool("v", false, "print the reconciler log")
+	allowMultiToUseSingle := flag.Bool("allow-multi-to-use-single", controller.ALLOW_MULTI_TO_USE_SINGLE, "let multi-tenant leases use single-tenant networks")
+	flag.Parse()
+
+	if len(files) == 0 {
+		fmt.Fprintln(os.Stderr, "at least one -f file is required")
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	input := &controller.SimulationInput{AllowMultiToUseSingle: *allowMultiToUseSingle}
+	for _, file := range files {
+		if err := loadFile(file, input); err != nil {
+			fmt.Fprintf(os.Stderr, "error loading %s: %v\n", file, err)
+			os.Exit(1)
+		}
+	}
+
+	if !*verbose {
+		log.SetOutput(io.Discard)
+	}
+
+	result, err := controller.Simulate(context.Background(), input)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "simulation failed: %v\n", err)
+		os.Exit(1)
+	}
+
+	switch *output {
+	case "yaml":
+		out, err := yaml.Marshal(result)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error formatting result: %v\n", err)
+			os.Exit(1)
+		}
+		os.Stdout.Write(out)
+	case "text":
+		printResult(os.Stdout, result)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown output format %q\n", *output)
+		os.Exit(2)
+	}
+}
+
+// loadFile adds the objects in a file, which may hold several documents and List objects, to the input.
+func loadFile(file string, input *controller.SimulationInput) error {
+	var reader io.Reader = os.Stdin
+	if file != "-" {
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		reader = f
+	}
+
+	decoder := utilyaml.NewYAMLOrJSONDecoder(reader, 4096)
+	for {
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		if err := addObject(raw, input); err != nil {
+			return err
+		}
+	}
+}
+
+func addObject(raw json.RawMessage, input *controller.SimulationInput) error {
+	var header struct {
+		Kind  string            `json:"kind"`
+		Items []json.RawMessage `json:"items"`
+	}
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil
+	}
+	if err := json.Unmarshal(raw, &header); err != nil {
+		return err
+	}
+
+	switch {
+	case strings.HasSuffix(header.Kind, "List"):
+		for _, item := range header.Items {
+			if err := addObject(item, input); err != nil {
+				return err
+			}
+		}
+	case header.Kind == v1.PoolKind:
+		pool := &v1.Pool{}
+		if err := json.Unmarshal(raw, pool); err != nil {
+			return err
+		}
+		input.Pools = append(input.Pools, pool)
+	case header.Kind == v1.NetworkKind:
+		network := &v1.Network{}
+		if err := json.Unmarshal(raw, network); err != nil {
+			return err
+		}
+		input.Networks = append(input.Networks, network)
+	case header.Kind == v1.LeaseKind:
+		lease := &v1.Lease{}
+		if err := json.Unmarshal(raw, lease); err != nil {
+			return err
+		}
+		input.Leases = append(input.Leases, lease)
+	case header.Kind == v1.LeasePriorityClassKind:
+		class := &v1.LeasePriorityClass{}
+		if err := json.Unmarshal(raw, class); err != nil {
+			return err
+		}
+		input.PriorityClasses = append(input.PriorityClasses, class)
+	case header.Kind == v1.LeaseQuotaKind:
+		quota := &v1.LeaseQuota{}
+		if err := json.Unmarshal(raw, quota); err != nil {
+			return err
+		}
+		input.Quotas = append(input.Quotas, quota)
+	case header.Kind == v1.ReservationKind:
+		reservation := &v1.Reservation{}
+		if err := json.Unmarshal(raw, reservation); err != nil {
+			return err
+		}
+		input.Reservations = append(input.Reservations, reservation)
+	default:
+		log.Printf("ignoring object of kind %q", header.Kind)
+	}
+	return nil
+}
+
+func printResult(out io.Writer, result *controller.SimulationResult) {
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+
+	fmt.Fprintln(w, "ASSIGNED LEASE\tPOOLS\tNETWORKS")
+	for _, assignment := range result.Assignments {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", assignment.Lease, strings.Join(assignment.Pools, ","), strings.Join(assignment.Networks, ","))
+	}
+
+	fmt.Fprintln(w, "\nPENDING LEASE\tPHASE\tREASON\tDETAILS")
+	for _, pending := range result.Pending {
+		details := pending.Message
+		if reasons := pending.UnschedulableReasons; reasons != nil && len(reasons.Summary) > 0 {
+			var counts []string
+			for _, count := range reasons.Summary {
+				counts = append(counts, fmt.Sprintf("%s on %d pools", count.Reason, count.Count))
+			}
+			details = strings.Join(counts, ", ")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", pending.Lease, pending.Phase, pending.Reason, details)
+	}
+
+	fmt.Fprintln(w, "\nPOOL\tVCPUS FREE\tMEMORY(GB) FREE\tSTORAGE FREE\tNETWORKS FREE\tLEASES")
+	for _, pool := range result.Utilization {
+		fmt.Fprintf(w, "%s\t%d/%d\t%d/%d\t%d/%d\t%d/%d\t%d\n", pool.Pool,
+			pool.VCpusAvailable, pool.VCpus,
+			pool.MemoryAvailable, pool.Memory,
+			pool.StorageAvailable, pool.Storage,
+			pool.NetworksAvailable, pool.Networks,
+			pool.Leases)
+	}
+	w.Flush()
+}
@@ -0,0 +1,51 @@
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	LeasePriorityClassKind = "LeasePriorityClass"
+)
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// LeasePriorityClass defines a named priority that leases can reference through
+// spec.priorityClassName. This works like Kubernetes PriorityClass.
+// +k8s:openapi-gen=true
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="Value",type=integer,JSONPath=`.spec.value`
+// +kubebuilder:printcolumn:name="Global Default",type=boolean,JSONPath=`.spec.globalDefault`
+type LeasePriorityClass struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec LeasePriorityClassSpec `json:"spec"`
+}
+
+// LeasePriorityClassSpec defines the specification for a lease priority class
+type LeasePriorityClassSpec struct {
+	// Value is the priority of leases referencing this class. When leases contend for the
+	// same pools, leases with a higher value are scheduled first.
+	Value int32 `json:"value"`
+	// GlobalDefault when true, this class is used for leases that do not set
+	// spec.priorityClassName. If several classes set it, the highest value is used.
+	// +optional
+	GlobalDefault bool `json:"globalDefault,omitempty"`
+	// Description is an arbitrary string describing when this class should be used.
+	// +optional
+	Description string `json:"description,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// LeasePriorityClassList is a list of lease priority classes
+type LeasePriorityClassList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []LeasePriorityClass `json:"items"`
+}
@@ -0,0 +1,98 @@
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	LeaseQuotaKind = "LeaseQuota"
+)
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// LeaseQuota caps the resources held by the leases in its namespace. Leases that would exceed
+// the quota stay Pending until enough capacity is released. This works like Kubernetes
+// ResourceQuota.
+// +k8s:openapi-gen=true
+// +kubebuilder:object:root=true
+// +kubebuilder:scope=Namespaced
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Leases",type=integer,JSONPath=`.status.used.leases`
+// +kubebuilder:printcolumn:name="vCPUs",type=integer,JSONPath=`.status.used.vcpus`
+// +kubebuilder:printcolumn:name="Memory(GB)",type=integer,JSONPath=`.status.used.memory`
+type LeaseQuota struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec LeaseQuotaSpec `json:"spec"`
+	// +optional
+	Status LeaseQuotaStatus `json:"status"`
+}
+
+// LeaseQuotaSpec defines the specification for a lease quota
+type LeaseQuotaSpec struct {
+	// Selector restricts the quota to leases whose labels match. When unset, every lease in
+	// the namespace is counted.
+	// +optional
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+
+	// JobNamePrefix restricts the quota to leases whose job-name label starts with the prefix.
+	// It is ANDed with Selector.
+	// +optional
+	JobNamePrefix string `json:"jobNamePrefix,omitempty"`
+
+	// Hard is the set of limits enforced for the leases counted by this quota.
+	Hard LeaseQuotaLimits `json:"hard"`
+}
+
+// LeaseQuotaLimits are the limits of a lease quota. Limits that are not set are not enforced.
+type LeaseQuotaLimits struct {
+	// VCpus is the maximum number of vCPUs held by the leases. A lease holds its vcpus
+	// in every pool assigned to it.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	VCpus *int `json:"vcpus,omitempty"`
+	// Memory is the maximum amount of memory in GB held by the leases. A lease holds its
+	// memory in every pool assigned to it.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Memory *int `json:"memory,omitempty"`
+	// Leases is the maximum number of leases holding pools at the same time.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Leases *int `json:"leases,omitempty"`
+	// Networks is the maximum number of networks held by the leases, keyed by network type.
+	// +optional
+	Networks map[string]int `json:"networks,omitempty"`
+}
+
+// LeaseQuotaUsage is the amount of resources held by the leases counted by a quota.
+type LeaseQuotaUsage struct {
+	// VCpus is the number of vCPUs held.
+	VCpus int `json:"vcpus"`
+	// Memory is the amount of memory in GB held.
+	Memory int `json:"memory"`
+	// Leases is the number of leases holding pools.
+	Leases int `json:"leases"`
+	// Networks is the number of networks held, keyed by network type.
+	// +optional
+	Networks map[string]int `json:"networks,omitempty"`
+}
+
+// LeaseQuotaStatus defines the status for a lease quota
+type LeaseQuotaStatus struct {
+	// Used is the current usage of the leases counted by this quota.
+	// +optional
+	Used LeaseQuotaUsage `json:"used"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// LeaseQuotaList is a list of lease quotas
+type LeaseQuotaList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []LeaseQuota `json:"items"`
+}
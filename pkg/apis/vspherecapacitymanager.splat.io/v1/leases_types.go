@@ -14,6 +14,31 @@ const (
 	NetworkTypeDisconnected = NetworkType("disconnected")
 	NetworkTypeSingleTenant = NetworkType("single-tenant")
 	NetworkTypeMultiTenant  = NetworkType("multi-tenant")
+
+	// LeaseRenewTimeAnnotation is set to the current RFC 3339 time by the holder of a lease
+	// with a TTL to renew it.
+	LeaseRenewTimeAnnotation = "vsphere-capacity-manager.splat-team.io/renew-time"
+)
+
+// IPFamily is an IP family a lease needs its networks to carry.
+// +kubebuilder:validation:Enum=IPv4;IPv6
+type IPFamily string
+
+const (
+	// IPFamilyIPv4 networks have an IPv4 gateway and subnet.
+	IPFamilyIPv4 IPFamily = "IPv4"
+	// IPFamilyIPv6 networks have an IPv6 gateway and prefix.
+	IPFamilyIPv6 IPFamily = "IPv6"
+)
+
+// LeaseExpirationPolicy is what happens to a lease when its TTL passes.
+type LeaseExpirationPolicy string
+
+const (
+	// LeaseExpirationPolicyDelete deletes the expired lease.
+	LeaseExpirationPolicyDelete LeaseExpirationPolicy = "Delete"
+	// LeaseExpirationPolicyRelease fails the expired lease and releases its pools and networks.
+	LeaseExpirationPolicyRelease LeaseExpirationPolicy = "Release"
 )
 
 // TolerationOperator is the operator for a toleration.
@@ -49,6 +74,122 @@ type Toleration struct {
 	Effect string `json:"effect,omitempty"`
 }
 
+// PreferredPoolTerm is a weighted pool label preference. Pools matching the preference
+// are ranked higher, but pools that don't match remain candidates.
+type PreferredPoolTerm struct {
+	// Weight is added to the LabelAffinity score of pools matching Preference.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	Weight int32 `json:"weight"`
+	// Preference is a label selector over pool labels.
+	Preference metav1.LabelSelector `json:"preference"`
+}
+
+// LeaseTopologyKey names the pool attribute that defines a topology domain for lease affinity.
+type LeaseTopologyKey string
+
+const (
+	// LeaseTopologyKeyPool places leases relative to each other by pool.
+	LeaseTopologyKeyPool LeaseTopologyKey = "pool"
+	// LeaseTopologyKeyServer places leases relative to each other by vCenter.
+	LeaseTopologyKeyServer LeaseTopologyKey = "server"
+	// LeaseTopologyKeyRegion places leases relative to each other by failure domain region.
+	LeaseTopologyKeyRegion LeaseTopologyKey = "region"
+	// LeaseTopologyKeyZone places leases relative to each other by failure domain zone.
+	LeaseTopologyKeyZone LeaseTopologyKey = "zone"
+)
+
+// LeaseAffinityMode is how strictly a lease affinity term is enforced.
+type LeaseAffinityMode string
+
+const (
+	// LeaseAffinityModeRequired terms must be met for a pool to be selected.
+	LeaseAffinityModeRequired LeaseAffinityMode = "Required"
+	// LeaseAffinityModePreferred terms rank pools that meet them higher.
+	LeaseAffinityModePreferred LeaseAffinityMode = "Preferred"
+)
+
+// LeaseAffinityTerm places a lease relative to other leases in the same namespace, such as
+// the other leases of a CI job sharing the boskos-lease-id label. Only leases that have been
+// assigned a pool are considered.
+type LeaseAffinityTerm struct {
+	// LabelSelector selects the leases this term applies to.
+	LabelSelector metav1.LabelSelector `json:"labelSelector"`
+	// TopologyKey is the pool attribute compared between leases: pool, server, region or zone.
+	// +kubebuilder:validation:Enum=pool;server;region;zone
+	TopologyKey LeaseTopologyKey `json:"topologyKey"`
+	// Mode is Required or Preferred. Defaults to Required.
+	// +kubebuilder:validation:Enum=Required;Preferred
+	// +kubebuilder:default=Required
+	// +optional
+	Mode LeaseAffinityMode `json:"mode,omitempty"`
+	// Weight is added to the LeaseAffinity score of pools meeting a Preferred term.
+	// It is ignored for Required terms.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	// +optional
+	Weight int32 `json:"weight,omitempty"`
+}
+
+// TopologySpreadConstraint spreads the pools of a multi-pool lease across topology domains.
+type TopologySpreadConstraint struct {
+	// TopologyKey is the pool attribute that defines a domain: server, region, zone or the key of
+	// a pool label. Pools with no value for the key are not used for the lease.
+	// +kubebuilder:validation:MinLength=1
+	TopologyKey string `json:"topologyKey"`
+	// MaxSkew is the maximum difference between the number of the lease's pools in any two
+	// domains that have pools able to host the lease. When unset, pools are not balanced.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxSkew int32 `json:"maxSkew,omitempty"`
+	// MinDomains is the minimum number of distinct domains the lease's pools must span.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MinDomains int32 `json:"minDomains,omitempty"`
+}
+
+// LeaseIPAddressRequest is the set of addresses a lease needs on each of its networks.
+type LeaseIPAddressRequest struct {
+	// APIVIP when true allocates an address for the API VIP, or the load balancer of a UPI install.
+	// +optional
+	APIVIP bool `json:"apiVIP,omitempty"`
+	// IngressVIP when true allocates an address for the ingress VIP.
+	// +optional
+	IngressVIP bool `json:"ingressVIP,omitempty"`
+	// Bootstrap when true allocates an address for the bootstrap node.
+	// +optional
+	Bootstrap bool `json:"bootstrap,omitempty"`
+	// Nodes is the number of addresses to allocate for control plane and compute nodes.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Nodes int `json:"nodes,omitempty"`
+	// Contiguous when true allocates all of the addresses as one range of consecutive addresses.
+	// +optional
+	Contiguous bool `json:"contiguous,omitempty"`
+}
+
+// LeaseIPAllocation is the set of addresses allocated to a lease on one of its networks.
+type LeaseIPAllocation struct {
+	// Network is the name of the Network the addresses were allocated from.
+	Network string `json:"network"`
+	// Addresses is the window of addresses held by the lease on networks which give each tenant
+	// one. The other addresses of the allocation are taken from the start of the window.
+	// +optional
+	Addresses []string `json:"addresses,omitempty"`
+	// APIVIP is the address allocated for the API VIP.
+	// +optional
+	APIVIP string `json:"apiVIP,omitempty"`
+	// IngressVIP is the address allocated for the ingress VIP.
+	// +optional
+	IngressVIP string `json:"ingressVIP,omitempty"`
+	// Bootstrap is the address allocated for the bootstrap node.
+	// +optional
+	Bootstrap string `json:"bootstrap,omitempty"`
+	// Nodes are the addresses allocated for nodes.
+	// +optional
+	Nodes []string `json:"nodes,omitempty"`
+}
+
 // +genclient
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
@@ -60,6 +201,10 @@ type Toleration struct {
 // +kubebuilder:printcolumn:name="vCPUs",type=string,JSONPath=`.spec.vcpus`
 // +kubebuilder:printcolumn:name="Memory(GB)",type=string,JSONPath=`.spec.memory`
 // +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Priority",type=integer,JSONPath=`.status.priority`,priority=1
+// +kubebuilder:printcolumn:name="Expires",type=date,JSONPath=`.status.expireTime`,priority=1
+// +kubebuilder:printcolumn:name="Queue",type=integer,JSONPath=`.status.queuePosition`,priority=1
+// +kubebuilder:printcolumn:name="Wait(s)",type=integer,JSONPath=`.status.estimatedWaitSeconds`,priority=1
 type Lease struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
@@ -70,6 +215,8 @@ type Lease struct {
 }
 
 // LeaseSpec defines the specification for a lease
+// +kubebuilder:validation:XValidation:rule="!has(self.notBefore) || !has(self.notAfter) || self.notAfter > self.notBefore",message="notAfter must be after notBefore"
+// +kubebuilder:validation:XValidation:rule="!has(self.reservationName) || !has(self.pools) || self.pools <= 1",message="only single-pool leases can use a reservation"
 type LeaseSpec struct {
 	// VCpus is the number of virtual CPUs allocated for this lease
 	VCpus int `json:"vcpus,omitempty"`
@@ -93,6 +240,17 @@ type LeaseSpec struct {
 	Storage int `json:"storage,omitempty"`
 	// Networks is the number of networks requested
 	Networks int `json:"networks"`
+	// IPAddresses requests addresses from the ipAddresses of each network assigned to the lease.
+	// The addresses allocated on each network are recorded in status.ipAllocations. It can not be
+	// changed once set.
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="ipAddresses is immutable"
+	// +optional
+	IPAddresses *LeaseIPAddressRequest `json:"ipAddresses,omitempty"`
+	// ExtendedResources are the amounts of extended resources, such as vsphere.io/hosts or
+	// licenses/vgpu, requested from each pool of the lease. Only pools offering enough of every
+	// requested resource are used. They can be resized like vcpus and memory.
+	// +optional
+	ExtendedResources map[string]int `json:"extendedResources,omitempty"`
 	// RequiredPool when configured, this lease can only be fulfilled by a specific
 	// pool
 	// +optional
@@ -104,11 +262,83 @@ type LeaseSpec struct {
 	// +optional
 	PoolSelector map[string]string `json:"poolSelector,omitempty"`
 
+	// PoolSelectorExpressions is a list of set-based label selector requirements for pools.
+	// Requirements are ANDed with each other and with PoolSelector. Valid operators are
+	// In, NotIn, Exists and DoesNotExist. This works like Kubernetes node affinity
+	// matchExpressions.
+	// +optional
+	PoolSelectorExpressions []metav1.LabelSelectorRequirement `json:"poolSelectorExpressions,omitempty"`
+
 	// Tolerations are tolerations that allow this lease to be scheduled on pools with matching taints.
 	// This works like Kubernetes pod tolerations for scheduling on nodes with taints.
 	// +optional
 	Tolerations []Toleration `json:"tolerations,omitempty"`
 
+	// PreferredPools are weighted pool label preferences used by the LabelAffinity score
+	// plugin. This works like Kubernetes preferredDuringSchedulingIgnoredDuringExecution
+	// node affinity.
+	// +optional
+	PreferredPools []PreferredPoolTerm `json:"preferredPools,omitempty"`
+
+	// LeaseAffinity places this lease in the same topology domain as the leases matching each
+	// term. A Required term with no matching lease assigned yet is met by any pool, so the first
+	// lease of a group is placed freely.
+	// +optional
+	LeaseAffinity []LeaseAffinityTerm `json:"leaseAffinity,omitempty"`
+
+	// LeaseAntiAffinity places this lease in a different topology domain from the leases
+	// matching each term.
+	// +optional
+	LeaseAntiAffinity []LeaseAffinityTerm `json:"leaseAntiAffinity,omitempty"`
+
+	// TopologySpreadConstraints spread the pools of a multi-pool lease across regions, zones,
+	// vCenters or pool label values. Unlike VCenters, which caps the number of vCenters, a
+	// constraint can require a minimum number of distinct domains.
+	// +optional
+	TopologySpreadConstraints []TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+
+	// SchedulingProfile is the name of the scheduler profile used to filter and rank
+	// candidate pools. Built-in profiles are default (least allocated pools first),
+	// bin-packing (most allocated pools first) and random. When empty, default is used.
+	// +optional
+	SchedulingProfile string `json:"schedulingProfile,omitempty"`
+
+	// PriorityClassName is the name of the LeasePriorityClass of this lease. When leases
+	// contend for the same pools, higher priority leases are scheduled first and leases of
+	// equal priority are scheduled oldest first. When empty, the global default class is
+	// used, or priority 0 if there is none.
+	// +optional
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+
+	// ReservationName is the name of a Reservation in the lease's namespace. The lease is
+	// placed on the reservation's pool and consumes the capacity it holds before using the
+	// shared capacity of the pool. The lease waits while the reservation is not active.
+	// +optional
+	ReservationName string `json:"reservationName,omitempty"`
+
+	// NotBefore is the earliest time the lease may claim pools. Until then the lease stays in
+	// the Scheduled phase and does not delay other leases. Priority aging starts from it.
+	// +optional
+	NotBefore *metav1.Time `json:"notBefore,omitempty"`
+
+	// NotAfter is the deadline for fulfilling the lease. A lease that is not fulfilled by then
+	// fails with reason DeadlineExceeded and releases anything it holds. Must be after NotBefore.
+	// +optional
+	NotAfter *metav1.Time `json:"notAfter,omitempty"`
+
+	// TTL is how long a fulfilled lease is held without being renewed. The holder renews the
+	// lease by setting the vsphere-capacity-manager.splat-team.io/renew-time annotation, or
+	// status.renewTime, to the current time. When unset, the lease does not expire.
+	// +optional
+	TTL *metav1.Duration `json:"ttl,omitempty"`
+
+	// ExpirationPolicy is what happens to the lease when its TTL passes. Delete removes the
+	// lease. Release fails it with reason Expired and releases its pools and networks, keeping
+	// the lease for inspection. Defaults to Delete.
+	// +kubebuilder:validation:Enum=Delete;Release
+	// +optional
+	ExpirationPolicy LeaseExpirationPolicy `json:"expirationPolicy,omitempty"`
+
 	// NetworkType defines the type of network required by the lease.
 	// by default, all networks are treated as single-tenant. single-tenant networks
 	// are only used by one CI jobs.  multi-tenant networks reside on a
@@ -119,6 +349,16 @@ type LeaseSpec struct {
 	// +optional
 	NetworkType NetworkType `json:"network-type"`
 
+	// IPFamilies are the IP families every network of the lease must carry. Set both IPv4 and
+	// IPv6 for dual-stack installs, or only IPv6 for IPv6-only installs. When IPv6 is requested,
+	// the IPv6 gateway and prefix of the network are exported in the env vars. When not set, the
+	// networks are not filtered by IP family. It can not be changed once set.
+	// +kubebuilder:validation:MaxItems=2
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="ipFamilies is immutable"
+	// +listType=set
+	// +optional
+	IPFamilies []IPFamily `json:"ipFamilies,omitempty"`
+
 	// BoskosLeaseID is the ID of the lease in Boskos associated with this lease
 	// +optional
 	BoskosLeaseID string `json:"boskos-lease-id,omitempty"`
@@ -153,6 +393,45 @@ type LeaseStatus struct {
 	// +optional
 	Phase Phase `json:"phase,omitempty"`
 
+	// Priority is the priority resolved from the lease's priority class, before aging.
+	// +optional
+	Priority int32 `json:"priority,omitempty"`
+
+	// UnschedulableReasons describes why the most recent attempt to schedule the lease found
+	// no pool. It is updated on every attempt and cleared once the lease is fulfilled.
+	// +optional
+	UnschedulableReasons *UnschedulableReasons `json:"unschedulableReasons,omitempty"`
+
+	// QueuePosition is the position of a Pending lease among the leases contending for the same
+	// pools, starting at 1 for the lease scheduled next. It is cleared once the lease is fulfilled.
+	// +optional
+	QueuePosition int32 `json:"queuePosition,omitempty"`
+
+	// EstimatedWaitSeconds estimates how long a Pending lease waits for the leases ahead of it,
+	// from the hold times of recently released leases. It is not set until hold times are known.
+	// +optional
+	EstimatedWaitSeconds *int64 `json:"estimatedWaitSeconds,omitempty"`
+
+	// IPAllocations are the addresses allocated to the lease on each of its networks, when the
+	// lease requests addresses with spec.ipAddresses.
+	// +optional
+	IPAllocations []LeaseIPAllocation `json:"ipAllocations,omitempty"`
+
+	// Allocated is the vCPUs, memory, storage, networks and extended resources per pool held by
+	// the lease since it was fulfilled or last resized. Pools account for the lease using these amounts, so changes
+	// to the spec of a fulfilled lease only take effect once the resize is admitted.
+	// +optional
+	Allocated *LeaseResources `json:"allocated,omitempty"`
+
+	// RenewTime is the last time a lease with a TTL was renewed, or the time it was fulfilled
+	// if it has not been renewed since.
+	// +optional
+	RenewTime *metav1.Time `json:"renewTime,omitempty"`
+
+	// ExpireTime is the time a lease with a TTL expires unless it is renewed.
+	// +optional
+	ExpireTime *metav1.Time `json:"expireTime,omitempty"`
+
 	// conditions defines the current state of the Machine
 	// +listType=map
 	// +listMapKey=type
@@ -162,6 +441,74 @@ type LeaseStatus struct {
 	JobLink string `json:"job-link,omitempty"`
 }
 
+// LeaseResources are the resources a lease holds on each of its pools.
+type LeaseResources struct {
+	// VCpus is the number of virtual CPUs.
+	// +optional
+	VCpus int `json:"vcpus,omitempty"`
+	// Memory is the amount of memory in GB.
+	// +optional
+	Memory int `json:"memory,omitempty"`
+	// Storage is the amount of storage in GB.
+	// +optional
+	Storage int `json:"storage,omitempty"`
+	// Networks is the number of networks.
+	// +optional
+	Networks int `json:"networks,omitempty"`
+	// ExtendedResources are the amounts of extended resources.
+	// +optional
+	ExtendedResources map[string]int `json:"extendedResources,omitempty"`
+}
+
+// MaxUnschedulablePools is the maximum number of pools listed in UnschedulableReasons.Pools.
+const MaxUnschedulablePools = 20
+
+// UnschedulableReasons are the reasons the pools were rejected during a scheduling attempt.
+type UnschedulableReasons struct {
+	// LastAttemptTime is the time of the scheduling attempt.
+	LastAttemptTime metav1.Time `json:"lastAttemptTime"`
+
+	// Summary counts the rejected pools per reason, most frequent first.
+	// +optional
+	Summary []UnschedulableReasonCount `json:"summary,omitempty"`
+
+	// Pools lists the rejected pools ordered by name, at most MaxUnschedulablePools of them.
+	// +optional
+	Pools []PoolUnschedulableReason `json:"pools,omitempty"`
+
+	// OmittedPools is the number of rejected pools not listed in Pools.
+	// +optional
+	OmittedPools int `json:"omittedPools,omitempty"`
+}
+
+// UnschedulableReasonCount is the number of pools rejected for a reason.
+type UnschedulableReasonCount struct {
+	// Reason is a CamelCase code identifying why the pools were rejected.
+	Reason string `json:"reason"`
+	// Count is the number of pools rejected for the reason.
+	Count int `json:"count"`
+}
+
+// PoolUnschedulableReason is the reason a single pool was rejected.
+type PoolUnschedulableReason struct {
+	// Pool is the name of the rejected pool.
+	Pool string `json:"pool"`
+	// Reason is a CamelCase code identifying why the pool was rejected.
+	Reason string `json:"reason"`
+	// Message is a human readable description of the reason.
+	// +optional
+	Message string `json:"message,omitempty"`
+	// Resource is the resource the pool has too little of, for capacity reasons.
+	// +optional
+	Resource string `json:"resource,omitempty"`
+	// Required is the amount of Resource the lease requested.
+	// +optional
+	Required int `json:"required,omitempty"`
+	// Available is the amount of Resource the pool had free. It is always shown, since a pool
+	// with nothing free reports 0 and an overcommitted pool a negative amount.
+	Available int `json:"available"`
+}
+
 type Leases []*Lease
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -247,3 +247,24 @@ func TestLeaseVCentersOmitEmpty(t *testing.T) {
 		t.Errorf("Expected VCenters=0 after unmarshal, got %d", unmarshaled.Spec.VCenters)
 	}
 }
+
+// TestPoolUnschedulableReasonAvailableAlwaysSerialized tests that a pool with nothing, or less than
+// nothing, free reports its available amount
+func TestPoolUnschedulableReasonAvailableAlwaysSerialized(t *testing.T) {
+	for _, available := range []int{0, -8} {
+		reason := PoolUnschedulableReason{Pool: "pool-a", Reason: "InsufficientVCPU", Resource: "vcpus", Required: 16, Available: available}
+
+		data, err := json.Marshal(reason)
+		if err != nil {
+			t.Fatalf("Failed to marshal reason: %v", err)
+		}
+
+		var fields map[string]interface{}
+		if err := json.Unmarshal(data, &fields); err != nil {
+			t.Fatalf("Failed to unmarshal reason: %v", err)
+		}
+		if got, exists := fields["available"]; !exists || got != float64(available) {
+			t.Errorf("Expected available=%d in %s", available, data)
+		}
+	}
+}
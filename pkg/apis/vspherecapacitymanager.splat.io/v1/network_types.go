@@ -18,8 +18,16 @@ const (
 // +k8s:openapi-gen=true
 // +kubebuilder:object:root=true
 // +kubebuilder:scope=Namespaced
+// +kubebuilder:subresource:status
 // +kubebuilder:printcolumn:name="Port Group",type=string,JSONPath=`.spec.portGroupName`
 // +kubebuilder:printcolumn:name="Pod",type=string,JSONPath=`.spec.podName`
+// +kubebuilder:printcolumn:name="Tenants",type=integer,JSONPath=`.status.tenants`
+// +kubebuilder:printcolumn:name="Max Tenants",type=integer,JSONPath=`.spec.maxTenants`,priority=1
+// +kubebuilder:printcolumn:name="Leases",type=string,JSONPath=`.status.leases`
+// +kubebuilder:printcolumn:name="Free IPs",type=integer,JSONPath=`.status.ipAddressesFree`
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="Last Assigned",type=date,JSONPath=`.status.lastAssignedTime`,priority=1
+// +kubebuilder:printcolumn:name="Last Released",type=date,JSONPath=`.status.lastReleasedTime`,priority=1
 type Network struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
@@ -89,10 +97,60 @@ type NetworkSpec struct {
 	// Nameservers an array of the nameservers to use
 	// +optional
 	Nameservers []string `json:"nameservers"`
+
+	// MaxTenants is the number of leases which may hold the network at the same time. Leases only
+	// share a network with leases of the same network-type, and single-tenant leases never share.
+	// Defaults to 1.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxTenants int `json:"maxTenants,omitempty"`
+
+	// TenantIPAddresses when set gives each lease holding the network a window of this many
+	// addresses from ipAddresses. The window is recorded in the status.ipAllocations of the lease.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	TenantIPAddresses int `json:"tenantIPAddresses,omitempty"`
 }
 
-// NetworkStatus defines the status for a pool
+// NetworkStatus defines the status for a network
 type NetworkStatus struct {
+	// Pools are the names of the pools the network belongs to.
+	// +optional
+	Pools []string `json:"pools,omitempty"`
+
+	// Leases are the names of the leases which currently own the network.
+	// +optional
+	Leases []string `json:"leases,omitempty"`
+
+	// Tenants is the number of leases which currently own the network.
+	// +optional
+	Tenants int `json:"tenants"`
+
+	// IPAddressesAllocated is the number of addresses of ipAddresses allocated to leases.
+	// +optional
+	IPAddressesAllocated int `json:"ipAddressesAllocated"`
+
+	// IPAddressesFree is the number of addresses of ipAddresses which can still be allocated to
+	// leases. The network, gateway and broadcast addresses are not counted.
+	// +optional
+	IPAddressesFree int `json:"ipAddressesFree"`
+
+	// LastAssignedTime is when the network was last assigned to a lease.
+	// +optional
+	LastAssignedTime *metav1.Time `json:"lastAssignedTime,omitempty"`
+
+	// LastReleasedTime is when the network was last released by a lease.
+	// +optional
+	LastReleasedTime *metav1.Time `json:"lastReleasedTime,omitempty"`
+
+	// Conditions defines the current state of the network. Ready is true when the network can be
+	// assigned to leases, InUse is true when it is owned by a lease, Degraded is true when its
+	// spec or the addresses allocated from it are inconsistent and Bound is false when the network
+	// belongs to no pool.
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []Condition `json:"conditions,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
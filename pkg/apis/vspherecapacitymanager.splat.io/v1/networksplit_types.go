@@ -0,0 +1,111 @@
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	NetworkSplitFinalizer = "vsphere-capacity-manager.splat-team.io/network-split-finalizer"
+	NetworkSplitKind      = "NetworkSplit"
+	NetworkSplitLabel     = "vsphere-capacity-manager.splat-team.io/network-split"
+)
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// NetworkSplit generates networks which each own a range of the addresses of one VLAN. The
+// generated networks are named after the split, labeled with it and owned by it. They are
+// re-generated when the spec changes, once none of them is owned by a lease.
+// +k8s:openapi-gen=true
+// +kubebuilder:object:root=true
+// +kubebuilder:scope=Namespaced
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Port Group",type=string,JSONPath=`.spec.portGroupName`
+// +kubebuilder:printcolumn:name="CIDR",type=string,JSONPath=`.spec.machineNetworkCidr`
+// +kubebuilder:printcolumn:name="Shards",type=integer,JSONPath=`.status.shards`
+// +kubebuilder:printcolumn:name="Shard Size",type=integer,JSONPath=`.status.shardSize`
+// +kubebuilder:printcolumn:name="Message",type=string,JSONPath=`.status.message`,priority=1
+type NetworkSplit struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec NetworkSplitSpec `json:"spec"`
+	// +optional
+	Status NetworkSplitStatus `json:"status"`
+}
+
+// NetworkSplitSpec defines the specification for a network split
+type NetworkSplitSpec struct {
+	// PortGroupName is the non-pathed port group of the VLAN. Each generated network gets the
+	// port group with its index appended, e.g. ci-vlan-1234-1.
+	PortGroupName string `json:"portGroupName"`
+
+	VlanId string `json:"vlanId"`
+
+	// The PodName is the pod that this VLAN is associated with.
+	// +optional
+	PodName string `json:"podName,omitempty"`
+
+	// The DatacenterName is the datacenter that the firewall resides in.
+	// +optional
+	DatacenterName string `json:"datacenterName,omitempty"`
+
+	// MachineNetworkCidr is the IPv4 subnet of the VLAN, e.g. 192.168.10.0/24.
+	MachineNetworkCidr string `json:"machineNetworkCidr"`
+
+	// Gateway is the gateway address of the subnet.
+	Gateway string `json:"gateway"`
+
+	// PrimaryRouterHostname hostname of the primary router.
+	// +optional
+	PrimaryRouterHostname string `json:"primaryRouterHostname,omitempty"`
+
+	// Nameservers an array of the nameservers to use
+	// +optional
+	Nameservers []string `json:"nameservers,omitempty"`
+
+	// Shards is the number of networks to generate. When ShardSize is not set, the addresses of
+	// the subnet are divided evenly between them.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	Shards int `json:"shards,omitempty"`
+
+	// ShardSize is the number of addresses given to each network. When Shards is not set, as many
+	// networks as fit in the subnet are generated.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	ShardSize int `json:"shardSize,omitempty"`
+
+	// NetworkType is the network-type label of the generated networks. Defaults to multi-tenant.
+	// +optional
+	NetworkType NetworkType `json:"network-type,omitempty"`
+}
+
+// NetworkSplitStatus defines the status for a network split
+type NetworkSplitStatus struct {
+	// Networks are the names of the generated networks.
+	// +optional
+	Networks []string `json:"networks,omitempty"`
+
+	// Shards is the number of generated networks.
+	// +optional
+	Shards int `json:"shards"`
+
+	// ShardSize is the number of addresses of each generated network.
+	// +optional
+	ShardSize int `json:"shardSize"`
+
+	// Message explains why the generated networks do not match the spec.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// NetworkSplitList is a list of network splits
+type NetworkSplitList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []NetworkSplit `json:"items"`
+}
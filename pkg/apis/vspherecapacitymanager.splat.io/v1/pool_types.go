@@ -1,6 +1,7 @@
 package v1
 
 import (
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -18,7 +19,8 @@ const (
 	// unless they tolerate the taint.
 	TaintEffectNoSchedule TaintEffect = "NoSchedule"
 	// TaintEffectPreferNoSchedule means the scheduler tries to avoid scheduling leases
-	// onto pools with this taint, but it's not required.
+	// onto pools with this taint, but it's not required. Pools with untolerated
+	// PreferNoSchedule taints are only used when no other pool fits.
 	TaintEffectPreferNoSchedule TaintEffect = "PreferNoSchedule"
 )
 
@@ -35,6 +37,26 @@ type Taint struct {
 	Effect TaintEffect `json:"effect"`
 }
 
+// PoolOverCommit defines how far the capacity of a pool may be overcommitted. Each ratio
+// multiplies the matching capacity in the pool spec, and leases are scheduled against the
+// result. Ratios that are not set are 1.
+type PoolOverCommit struct {
+	// CPU is the overcommit ratio of the vCPUs of the pool, for example 2.5.
+	// +kubebuilder:validation:Pattern=`^([0-9]*[1-9][0-9]*(\.[0-9]+)?|[0-9]*\.[0-9]*[1-9][0-9]*)$`
+	// +optional
+	CPU *resource.Quantity `json:"cpu,omitempty"`
+	// Memory is the overcommit ratio of the memory of the pool, for example 1.2 on clusters
+	// with memory ballooning.
+	// +kubebuilder:validation:Pattern=`^([0-9]*[1-9][0-9]*(\.[0-9]+)?|[0-9]*\.[0-9]*[1-9][0-9]*)$`
+	// +optional
+	Memory *resource.Quantity `json:"memory,omitempty"`
+	// Storage is the overcommit ratio of the storage of the pool, for thin provisioned
+	// datastores.
+	// +kubebuilder:validation:Pattern=`^([0-9]*[1-9][0-9]*(\.[0-9]+)?|[0-9]*\.[0-9]*[1-9][0-9]*)$`
+	// +optional
+	Storage *resource.Quantity `json:"storage,omitempty"`
+}
+
 // +genclient
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
@@ -45,9 +67,13 @@ type Taint struct {
 // +kubebuilder:subresource:status
 // +kubebuilder:printcolumn:name="vCPUs",type=string,JSONPath=`.status.vcpus-available`
 // +kubebuilder:printcolumn:name="Memory(GB)",type=string,JSONPath=`.status.memory-available`
+// +kubebuilder:printcolumn:name="Storage(GB)",type=string,JSONPath=`.status.datastore-available`
 // +kubebuilder:printcolumn:name="Networks",type=string,JSONPath=`.status.network-available`
 // +kubebuilder:printcolumn:name="Disabled",type=string,JSONPath=`.spec.noSchedule`
 // +kubebuilder:printcolumn:name="Excluded",type=string,JSONPath=`.spec.exclude`
+// +kubebuilder:printcolumn:name="CPU Ratio",type=string,JSONPath=`.spec.overCommit.cpu`,priority=1
+// +kubebuilder:printcolumn:name="Memory Ratio",type=string,JSONPath=`.spec.overCommit.memory`,priority=1
+// +kubebuilder:printcolumn:name="Storage Ratio",type=string,JSONPath=`.spec.overCommit.storage`,priority=1
 type Pool struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
@@ -71,12 +97,29 @@ type PoolSpec struct {
 	IBMPoolSpec IBMPoolSpec `json:"ibmPoolSpec,omitempty"`
 	// VCpus is the number of virtual CPUs
 	VCpus int `json:"vcpus"`
-	// +kubebuilder:default="1.0"
-	OverCommitRatio string `json:"overCommitRatio"`
+	// OverCommitRatio is the overcommit ratio of the vCPUs of the pool.
+	// Deprecated: use overCommit.cpu, which takes precedence when set.
+	// +kubebuilder:validation:Pattern=`^([0-9]*[1-9][0-9]*(\.[0-9]+)?|[0-9]*\.[0-9]*[1-9][0-9]*)$`
+	// +optional
+	OverCommitRatio string `json:"overCommitRatio,omitempty"`
+	// OverCommit are the overcommit ratios of the vCPUs, memory and storage of the pool.
+	// +optional
+	OverCommit PoolOverCommit `json:"overCommit,omitempty"`
 	// Memory is the amount of memory in GB
 	Memory int `json:"memory"`
-	// Storage is the amount of storage in GB
+	// Storage is the amount of storage in GB. When zero, storage is not tracked for this
+	// pool and leases requesting storage are not restricted by it.
 	Storage int `json:"storage"`
+	// ExtendedResources are the amounts of extended resources the pool offers, keyed by resource
+	// name, for example vsphere.io/hosts, licenses/vgpu or nested-esxi-slots. Leases requesting
+	// an extended resource are only placed on pools with enough of it left.
+	// +optional
+	ExtendedResources map[string]int `json:"extendedResources,omitempty"`
+	// NetworkRefs are the names of the Networks, in the namespace of the pool, which belong to the
+	// pool. When empty, a Network belongs to the pool when its portGroupName is the last element
+	// of a topology.networks path and its podName is ibmPoolSpec.pod.
+	// +optional
+	NetworkRefs []string `json:"networkRefs,omitempty"`
 	// Exclude when true, this pool is excluded from the default pools.
 	// This is useful if a job must be scheduled to a specific pool and that
 	// pool only has limited capacity.
@@ -86,7 +129,8 @@ type PoolSpec struct {
 	// +optional
 	NoSchedule bool `json:"noSchedule"`
 	// Taints are taints applied to this pool. Leases will not be scheduled on this pool
-	// unless they have matching tolerations. This works like Kubernetes node taints.
+	// unless they tolerate its NoSchedule taints. Untolerated PreferNoSchedule taints only
+	// rank the pool below others. This works like Kubernetes node taints.
 	// +optional
 	Taints []Taint `json:"taints,omitempty"`
 }
@@ -105,12 +149,22 @@ type PoolStatus struct {
 	// network-available is the number of networks available in the pool
 	// +optional
 	NetworkAvailable int `json:"network-available"`
+	// extended-resources-available is the amount of each extended resource available in the pool
+	// +optional
+	ExtendedResourcesAvailable map[string]int `json:"extended-resources-available,omitempty"`
 	// lease-count is the number of leases assigned to the pool
 	LeaseCount int `json:"lease-count"`
 
 	// Initialized when true, the status fields have been initialized
 	// +optional
 	Initialized bool `json:"initialized"`
+
+	// Conditions defines the current state of the pool. NetworksResolved is false when
+	// topology.networks or networkRefs name networks that do not exist.
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []Condition `json:"conditions,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -30,6 +30,14 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 		&PoolList{},
 		&Network{},
 		&NetworkList{},
+		&NetworkSplit{},
+		&NetworkSplitList{},
+		&LeasePriorityClass{},
+		&LeasePriorityClassList{},
+		&LeaseQuota{},
+		&LeaseQuotaList{},
+		&Reservation{},
+		&ReservationList{},
 	)
 
 	metav1.AddToGroupVersion(scheme, GroupVersion)
@@ -0,0 +1,130 @@
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	ReservationKind = "Reservation"
+)
+
+// ReservationPhase is the phase of a reservation.
+type ReservationPhase string
+
+const (
+	// ReservationPhasePending reservations have not reached their start time yet.
+	ReservationPhasePending ReservationPhase = "Pending"
+	// ReservationPhaseActive reservations hold capacity on their pool.
+	ReservationPhaseActive ReservationPhase = "Active"
+	// ReservationPhaseExpired reservations have passed their end time and hold nothing.
+	ReservationPhaseExpired ReservationPhase = "Expired"
+)
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// Reservation holds vCPUs, memory and networks on a pool between its start and end time.
+// While active, the held capacity is unavailable to other leases. Leases in the same namespace
+// that set spec.reservationName to the reservation consume the held capacity instead.
+// +k8s:openapi-gen=true
+// +kubebuilder:object:root=true
+// +kubebuilder:scope=Namespaced
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Pool",type=string,JSONPath=`.status.pool`
+// +kubebuilder:printcolumn:name="Start",type=string,JSONPath=`.spec.start`
+// +kubebuilder:printcolumn:name="End",type=string,JSONPath=`.spec.end`
+// +kubebuilder:printcolumn:name="vCPUs",type=integer,JSONPath=`.spec.vcpus`
+// +kubebuilder:printcolumn:name="Used vCPUs",type=integer,JSONPath=`.status.used.vcpus`
+type Reservation struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ReservationSpec `json:"spec"`
+	// +optional
+	Status ReservationStatus `json:"status"`
+}
+
+// ReservationSpec defines the specification for a reservation
+// +kubebuilder:validation:XValidation:rule="self.end > self.start",message="end must be after start"
+type ReservationSpec struct {
+	// Pool is the name of the pool to hold capacity on.
+	// +optional
+	Pool string `json:"pool,omitempty"`
+
+	// PoolSelector picks the pool when Pool is not set. When the reservation becomes active,
+	// the matching pool with the most free vCPUs is chosen and recorded in status.pool.
+	// +optional
+	PoolSelector *metav1.LabelSelector `json:"poolSelector,omitempty"`
+
+	// VCpus is the number of vCPUs to hold.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	VCpus int `json:"vcpus,omitempty"`
+
+	// Memory is the amount of memory in GB to hold.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Memory int `json:"memory,omitempty"`
+
+	// Networks is the number of networks to hold.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Networks int `json:"networks,omitempty"`
+
+	// NetworkType is the type of the networks to hold. Defaults to single-tenant.
+	// +optional
+	NetworkType NetworkType `json:"network-type,omitempty"`
+
+	// Start is the time the capacity starts being held.
+	Start metav1.Time `json:"start"`
+
+	// End is the time the capacity is released.
+	End metav1.Time `json:"end"`
+}
+
+// ReservationUsage is the capacity of a reservation consumed by leases.
+type ReservationUsage struct {
+	// VCpus is the number of vCPUs consumed.
+	VCpus int `json:"vcpus"`
+	// Memory is the amount of memory in GB consumed.
+	Memory int `json:"memory"`
+	// Networks is the number of held networks owned by leases.
+	Networks int `json:"networks"`
+	// Leases is the number of leases consuming the reservation.
+	Leases int `json:"leases"`
+}
+
+// ReservationStatus defines the status for a reservation
+type ReservationStatus struct {
+	// Phase is the current phase of the reservation.
+	// +optional
+	Phase ReservationPhase `json:"phase,omitempty"`
+
+	// Pool is the pool the capacity is held on.
+	// +optional
+	Pool string `json:"pool,omitempty"`
+
+	// Networks are the names of the networks held by the reservation. Fewer than
+	// spec.networks are listed while other leases still own the networks needed.
+	// +optional
+	Networks []string `json:"networks,omitempty"`
+
+	// Used is the capacity consumed by the leases referencing the reservation.
+	// +optional
+	Used ReservationUsage `json:"used"`
+
+	// Message explains why the reservation is not holding everything it asks for.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ReservationList is a list of reservations
+type ReservationList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []Reservation `json:"items"`
+}
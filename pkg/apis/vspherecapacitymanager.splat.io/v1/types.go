@@ -60,10 +60,21 @@ const (
 type ConditionType string
 
 const (
-	LeaseConditionTypeDelayed   ConditionType = "Delayed"
-	LeaseConditionTypeFulfilled ConditionType = "Fulfilled"
-	LeaseConditionTypePartial   ConditionType = "Partial"
-	LeaseConditionTypePending   ConditionType = "Pending"
+	LeaseConditionTypeBackfilled    ConditionType = "Backfilled"
+	LeaseConditionTypeDelayed       ConditionType = "Delayed"
+	LeaseConditionTypeExpiring      ConditionType = "Expiring"
+	LeaseConditionTypeFulfilled     ConditionType = "Fulfilled"
+	LeaseConditionTypePartial       ConditionType = "Partial"
+	LeaseConditionTypePending       ConditionType = "Pending"
+	LeaseConditionTypeQuotaExceeded ConditionType = "QuotaExceeded"
+	LeaseConditionTypeResized       ConditionType = "Resized"
+
+	NetworkConditionTypeBound    ConditionType = "Bound"
+	NetworkConditionTypeDegraded ConditionType = "Degraded"
+	NetworkConditionTypeInUse    ConditionType = "InUse"
+	NetworkConditionTypeReady    ConditionType = "Ready"
+
+	PoolConditionTypeNetworksResolved ConditionType = "NetworksResolved"
 )
 
 type ConditionStatus string
@@ -76,8 +87,24 @@ const (
 
 // all the reasons for various updates
 const (
-	ReasonLeaseDelayed       string = "LeaseDelayed"
-	ReasonLeasePartial       string = "LeasePartial"
-	ReasonLeaseNoPool        string = "NoAvailablePool"
-	ReasonLeaseUnschedulable string = "Unschedulable"
+	ReasonLeaseBackfilled     string = "LeaseBackfilled"
+	ReasonLeaseDelayed        string = "LeaseDelayed"
+	ReasonLeasePartial        string = "LeasePartial"
+	ReasonLeaseNoPool         string = "NoAvailablePool"
+	ReasonLeaseUnschedulable  string = "Unschedulable"
+	ReasonUnknownProfile      string = "UnknownSchedulingProfile"
+	ReasonQuotaExceeded       string = "QuotaExceeded"
+	ReasonReservationNotReady string = "ReservationNotReady"
+	ReasonLeaseNotBefore      string = "NotBefore"
+	ReasonDeadlineExceeded    string = "DeadlineExceeded"
+	ReasonLeaseExpiring       string = "LeaseExpiring"
+	ReasonLeaseExpired        string = "Expired"
+	ReasonLeaseRenewed        string = "LeaseRenewed"
+	ReasonLeaseResized        string = "LeaseResized"
+	ReasonResizeRejected      string = "ResizeRejected"
+	ReasonInvalidOverCommit   string = "InvalidOverCommit"
+	ReasonNetworkInvalid      string = "InvalidNetwork"
+	ReasonIPAddressConflict   string = "IPAddressConflict"
+	ReasonDanglingNetworks    string = "DanglingNetworks"
+	ReasonNetworkNotInPool    string = "NotInPool"
 )
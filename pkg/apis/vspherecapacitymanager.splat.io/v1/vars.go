@@ -1,17 +1,15 @@
 package v1
 
 const (
-	RESOURCE_ALLOCATION_STRATEGY_RANDOM        = AllocationStrategy("random")
-	RESOURCE_ALLOCATION_STRATEGY_UNDERUTILIZED = AllocationStrategy("under-utilized")
-
 	PHASE_FULFILLED Phase = "Fulfilled"
 	PHASE_PARTIAL   Phase = "Partial"
 	PHASE_PENDING   Phase = "Pending"
 	PHASE_FAILED    Phase = "Failed"
+	// PHASE_SCHEDULED leases wait for spec.notBefore without claiming pools.
+	PHASE_SCHEDULED Phase = "Scheduled"
 )
 
 type (
-	Phase              string
-	AllocationStrategy string
-	State              string
+	Phase string
+	State string
 )
@@ -6,6 +6,7 @@
 package v1
 
 import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -83,6 +84,62 @@ func (in *Lease) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LeaseAffinityTerm) DeepCopyInto(out *LeaseAffinityTerm) {
+	*out = *in
+	in.LabelSelector.DeepCopyInto(&out.LabelSelector)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LeaseAffinityTerm.
+func (in *LeaseAffinityTerm) DeepCopy() *LeaseAffinityTerm {
+	if in == nil {
+		return nil
+	}
+	out := new(LeaseAffinityTerm)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LeaseIPAddressRequest) DeepCopyInto(out *LeaseIPAddressRequest) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LeaseIPAddressRequest.
+func (in *LeaseIPAddressRequest) DeepCopy() *LeaseIPAddressRequest {
+	if in == nil {
+		return nil
+	}
+	out := new(LeaseIPAddressRequest)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LeaseIPAllocation) DeepCopyInto(out *LeaseIPAllocation) {
+	*out = *in
+	if in.Addresses != nil {
+		in, out := &in.Addresses, &out.Addresses
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Nodes != nil {
+		in, out := &in.Nodes, &out.Nodes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LeaseIPAllocation.
+func (in *LeaseIPAllocation) DeepCopy() *LeaseIPAllocation {
+	if in == nil {
+		return nil
+	}
+	out := new(LeaseIPAllocation)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *LeaseList) DeepCopyInto(out *LeaseList) {
 	*out = *in
@@ -115,9 +172,271 @@ func (in *LeaseList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LeasePriorityClass) DeepCopyInto(out *LeasePriorityClass) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LeasePriorityClass.
+func (in *LeasePriorityClass) DeepCopy() *LeasePriorityClass {
+	if in == nil {
+		return nil
+	}
+	out := new(LeasePriorityClass)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *LeasePriorityClass) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LeasePriorityClassList) DeepCopyInto(out *LeasePriorityClassList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]LeasePriorityClass, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LeasePriorityClassList.
+func (in *LeasePriorityClassList) DeepCopy() *LeasePriorityClassList {
+	if in == nil {
+		return nil
+	}
+	out := new(LeasePriorityClassList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *LeasePriorityClassList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LeasePriorityClassSpec) DeepCopyInto(out *LeasePriorityClassSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LeasePriorityClassSpec.
+func (in *LeasePriorityClassSpec) DeepCopy() *LeasePriorityClassSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(LeasePriorityClassSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LeaseQuota) DeepCopyInto(out *LeaseQuota) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LeaseQuota.
+func (in *LeaseQuota) DeepCopy() *LeaseQuota {
+	if in == nil {
+		return nil
+	}
+	out := new(LeaseQuota)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *LeaseQuota) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LeaseQuotaLimits) DeepCopyInto(out *LeaseQuotaLimits) {
+	*out = *in
+	if in.VCpus != nil {
+		in, out := &in.VCpus, &out.VCpus
+		*out = new(int)
+		**out = **in
+	}
+	if in.Memory != nil {
+		in, out := &in.Memory, &out.Memory
+		*out = new(int)
+		**out = **in
+	}
+	if in.Leases != nil {
+		in, out := &in.Leases, &out.Leases
+		*out = new(int)
+		**out = **in
+	}
+	if in.Networks != nil {
+		in, out := &in.Networks, &out.Networks
+		*out = make(map[string]int, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LeaseQuotaLimits.
+func (in *LeaseQuotaLimits) DeepCopy() *LeaseQuotaLimits {
+	if in == nil {
+		return nil
+	}
+	out := new(LeaseQuotaLimits)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LeaseQuotaList) DeepCopyInto(out *LeaseQuotaList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]LeaseQuota, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LeaseQuotaList.
+func (in *LeaseQuotaList) DeepCopy() *LeaseQuotaList {
+	if in == nil {
+		return nil
+	}
+	out := new(LeaseQuotaList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *LeaseQuotaList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LeaseQuotaSpec) DeepCopyInto(out *LeaseQuotaSpec) {
+	*out = *in
+	if in.Selector != nil {
+		in, out := &in.Selector, &out.Selector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	in.Hard.DeepCopyInto(&out.Hard)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LeaseQuotaSpec.
+func (in *LeaseQuotaSpec) DeepCopy() *LeaseQuotaSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(LeaseQuotaSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LeaseQuotaStatus) DeepCopyInto(out *LeaseQuotaStatus) {
+	*out = *in
+	in.Used.DeepCopyInto(&out.Used)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LeaseQuotaStatus.
+func (in *LeaseQuotaStatus) DeepCopy() *LeaseQuotaStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(LeaseQuotaStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LeaseQuotaUsage) DeepCopyInto(out *LeaseQuotaUsage) {
+	*out = *in
+	if in.Networks != nil {
+		in, out := &in.Networks, &out.Networks
+		*out = make(map[string]int, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LeaseQuotaUsage.
+func (in *LeaseQuotaUsage) DeepCopy() *LeaseQuotaUsage {
+	if in == nil {
+		return nil
+	}
+	out := new(LeaseQuotaUsage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LeaseResources) DeepCopyInto(out *LeaseResources) {
+	*out = *in
+	if in.ExtendedResources != nil {
+		in, out := &in.ExtendedResources, &out.ExtendedResources
+		*out = make(map[string]int, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LeaseResources.
+func (in *LeaseResources) DeepCopy() *LeaseResources {
+	if in == nil {
+		return nil
+	}
+	out := new(LeaseResources)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *LeaseSpec) DeepCopyInto(out *LeaseSpec) {
 	*out = *in
+	if in.IPAddresses != nil {
+		in, out := &in.IPAddresses, &out.IPAddresses
+		*out = new(LeaseIPAddressRequest)
+		**out = **in
+	}
+	if in.ExtendedResources != nil {
+		in, out := &in.ExtendedResources, &out.ExtendedResources
+		*out = make(map[string]int, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	if in.PoolSelector != nil {
 		in, out := &in.PoolSelector, &out.PoolSelector
 		*out = make(map[string]string, len(*in))
@@ -125,11 +444,62 @@ func (in *LeaseSpec) DeepCopyInto(out *LeaseSpec) {
 			(*out)[key] = val
 		}
 	}
+	if in.PoolSelectorExpressions != nil {
+		in, out := &in.PoolSelectorExpressions, &out.PoolSelectorExpressions
+		*out = make([]metav1.LabelSelectorRequirement, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.Tolerations != nil {
 		in, out := &in.Tolerations, &out.Tolerations
 		*out = make([]Toleration, len(*in))
 		copy(*out, *in)
 	}
+	if in.PreferredPools != nil {
+		in, out := &in.PreferredPools, &out.PreferredPools
+		*out = make([]PreferredPoolTerm, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.LeaseAffinity != nil {
+		in, out := &in.LeaseAffinity, &out.LeaseAffinity
+		*out = make([]LeaseAffinityTerm, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.LeaseAntiAffinity != nil {
+		in, out := &in.LeaseAntiAffinity, &out.LeaseAntiAffinity
+		*out = make([]LeaseAffinityTerm, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.TopologySpreadConstraints != nil {
+		in, out := &in.TopologySpreadConstraints, &out.TopologySpreadConstraints
+		*out = make([]TopologySpreadConstraint, len(*in))
+		copy(*out, *in)
+	}
+	if in.NotBefore != nil {
+		in, out := &in.NotBefore, &out.NotBefore
+		*out = (*in).DeepCopy()
+	}
+	if in.NotAfter != nil {
+		in, out := &in.NotAfter, &out.NotAfter
+		*out = (*in).DeepCopy()
+	}
+	if in.TTL != nil {
+		in, out := &in.TTL, &out.TTL
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.IPFamilies != nil {
+		in, out := &in.IPFamilies, &out.IPFamilies
+		*out = make([]IPFamily, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LeaseSpec.
@@ -153,12 +523,325 @@ func (in *LeaseStatus) DeepCopyInto(out *LeaseStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
-	if in.EnvVarsMap != nil {
-		in, out := &in.EnvVarsMap, &out.EnvVarsMap
-		*out = make(map[string]string, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
-		}
+	if in.EnvVarsMap != nil {
+		in, out := &in.EnvVarsMap, &out.EnvVarsMap
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.UnschedulableReasons != nil {
+		in, out := &in.UnschedulableReasons, &out.UnschedulableReasons
+		*out = new(UnschedulableReasons)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.EstimatedWaitSeconds != nil {
+		in, out := &in.EstimatedWaitSeconds, &out.EstimatedWaitSeconds
+		*out = new(int64)
+		**out = **in
+	}
+	if in.IPAllocations != nil {
+		in, out := &in.IPAllocations, &out.IPAllocations
+		*out = make([]LeaseIPAllocation, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Allocated != nil {
+		in, out := &in.Allocated, &out.Allocated
+		*out = new(LeaseResources)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RenewTime != nil {
+		in, out := &in.RenewTime, &out.RenewTime
+		*out = (*in).DeepCopy()
+	}
+	if in.ExpireTime != nil {
+		in, out := &in.ExpireTime, &out.ExpireTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LeaseStatus.
+func (in *LeaseStatus) DeepCopy() *LeaseStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(LeaseStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in Leases) DeepCopyInto(out *Leases) {
+	{
+		in := &in
+		*out = make(Leases, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(Lease)
+				(*in).DeepCopyInto(*out)
+			}
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Leases.
+func (in Leases) DeepCopy() Leases {
+	if in == nil {
+		return nil
+	}
+	out := new(Leases)
+	in.DeepCopyInto(out)
+	return *out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Network) DeepCopyInto(out *Network) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Network.
+func (in *Network) DeepCopy() *Network {
+	if in == nil {
+		return nil
+	}
+	out := new(Network)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *Network) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkList) DeepCopyInto(out *NetworkList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]Network, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkList.
+func (in *NetworkList) DeepCopy() *NetworkList {
+	if in == nil {
+		return nil
+	}
+	out := new(NetworkList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *NetworkList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkSpec) DeepCopyInto(out *NetworkSpec) {
+	*out = *in
+	if in.PodName != nil {
+		in, out := &in.PodName, &out.PodName
+		*out = new(string)
+		**out = **in
+	}
+	if in.DatacenterName != nil {
+		in, out := &in.DatacenterName, &out.DatacenterName
+		*out = new(string)
+		**out = **in
+	}
+	if in.Cidr != nil {
+		in, out := &in.Cidr, &out.Cidr
+		*out = new(int)
+		**out = **in
+	}
+	if in.Gateway != nil {
+		in, out := &in.Gateway, &out.Gateway
+		*out = new(string)
+		**out = **in
+	}
+	if in.IpAddressCount != nil {
+		in, out := &in.IpAddressCount, &out.IpAddressCount
+		*out = new(uint)
+		**out = **in
+	}
+	if in.Netmask != nil {
+		in, out := &in.Netmask, &out.Netmask
+		*out = new(string)
+		**out = **in
+	}
+	if in.SubnetType != nil {
+		in, out := &in.SubnetType, &out.SubnetType
+		*out = new(string)
+		**out = **in
+	}
+	if in.IpAddresses != nil {
+		in, out := &in.IpAddresses, &out.IpAddresses
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Nameservers != nil {
+		in, out := &in.Nameservers, &out.Nameservers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkSpec.
+func (in *NetworkSpec) DeepCopy() *NetworkSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(NetworkSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkSplit) DeepCopyInto(out *NetworkSplit) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkSplit.
+func (in *NetworkSplit) DeepCopy() *NetworkSplit {
+	if in == nil {
+		return nil
+	}
+	out := new(NetworkSplit)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *NetworkSplit) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkSplitList) DeepCopyInto(out *NetworkSplitList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]NetworkSplit, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkSplitList.
+func (in *NetworkSplitList) DeepCopy() *NetworkSplitList {
+	if in == nil {
+		return nil
+	}
+	out := new(NetworkSplitList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *NetworkSplitList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkSplitSpec) DeepCopyInto(out *NetworkSplitSpec) {
+	*out = *in
+	if in.Nameservers != nil {
+		in, out := &in.Nameservers, &out.Nameservers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkSplitSpec.
+func (in *NetworkSplitSpec) DeepCopy() *NetworkSplitSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(NetworkSplitSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkSplitStatus) DeepCopyInto(out *NetworkSplitStatus) {
+	*out = *in
+	if in.Networks != nil {
+		in, out := &in.Networks, &out.Networks
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkSplitStatus.
+func (in *NetworkSplitStatus) DeepCopy() *NetworkSplitStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(NetworkSplitStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkStatus) DeepCopyInto(out *NetworkStatus) {
+	*out = *in
+	if in.Pools != nil {
+		in, out := &in.Pools, &out.Pools
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Leases != nil {
+		in, out := &in.Leases, &out.Leases
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.LastAssignedTime != nil {
+		in, out := &in.LastAssignedTime, &out.LastAssignedTime
+		*out = (*in).DeepCopy()
+	}
+	if in.LastReleasedTime != nil {
+		in, out := &in.LastReleasedTime, &out.LastReleasedTime
+		*out = (*in).DeepCopy()
 	}
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
@@ -169,62 +852,62 @@ func (in *LeaseStatus) DeepCopyInto(out *LeaseStatus) {
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LeaseStatus.
-func (in *LeaseStatus) DeepCopy() *LeaseStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkStatus.
+func (in *NetworkStatus) DeepCopy() *NetworkStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(LeaseStatus)
+	out := new(NetworkStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in Leases) DeepCopyInto(out *Leases) {
+func (in Networks) DeepCopyInto(out *Networks) {
 	{
 		in := &in
-		*out = make(Leases, len(*in))
+		*out = make(Networks, len(*in))
 		for i := range *in {
 			if (*in)[i] != nil {
 				in, out := &(*in)[i], &(*out)[i]
-				*out = new(Lease)
+				*out = new(Network)
 				(*in).DeepCopyInto(*out)
 			}
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Leases.
-func (in Leases) DeepCopy() Leases {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Networks.
+func (in Networks) DeepCopy() Networks {
 	if in == nil {
 		return nil
 	}
-	out := new(Leases)
+	out := new(Networks)
 	in.DeepCopyInto(out)
 	return *out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Network) DeepCopyInto(out *Network) {
+func (in *Pool) DeepCopyInto(out *Pool) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Network.
-func (in *Network) DeepCopy() *Network {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Pool.
+func (in *Pool) DeepCopy() *Pool {
 	if in == nil {
 		return nil
 	}
-	out := new(Network)
+	out := new(Pool)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *Network) DeepCopyObject() runtime.Object {
+func (in *Pool) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -232,31 +915,31 @@ func (in *Network) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *NetworkList) DeepCopyInto(out *NetworkList) {
+func (in *PoolList) DeepCopyInto(out *PoolList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]Network, len(*in))
+		*out = make([]Pool, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkList.
-func (in *NetworkList) DeepCopy() *NetworkList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PoolList.
+func (in *PoolList) DeepCopy() *PoolList {
 	if in == nil {
 		return nil
 	}
-	out := new(NetworkList)
+	out := new(PoolList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *NetworkList) DeepCopyObject() runtime.Object {
+func (in *PoolList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -264,126 +947,176 @@ func (in *NetworkList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *NetworkSpec) DeepCopyInto(out *NetworkSpec) {
+func (in *PoolOverCommit) DeepCopyInto(out *PoolOverCommit) {
 	*out = *in
-	if in.PodName != nil {
-		in, out := &in.PodName, &out.PodName
-		*out = new(string)
-		**out = **in
-	}
-	if in.DatacenterName != nil {
-		in, out := &in.DatacenterName, &out.DatacenterName
-		*out = new(string)
-		**out = **in
-	}
-	if in.Cidr != nil {
-		in, out := &in.Cidr, &out.Cidr
-		*out = new(int)
-		**out = **in
+	if in.CPU != nil {
+		in, out := &in.CPU, &out.CPU
+		x := (*in).DeepCopy()
+		*out = &x
 	}
-	if in.Gateway != nil {
-		in, out := &in.Gateway, &out.Gateway
-		*out = new(string)
-		**out = **in
+	if in.Memory != nil {
+		in, out := &in.Memory, &out.Memory
+		x := (*in).DeepCopy()
+		*out = &x
 	}
-	if in.IpAddressCount != nil {
-		in, out := &in.IpAddressCount, &out.IpAddressCount
-		*out = new(uint)
-		**out = **in
+	if in.Storage != nil {
+		in, out := &in.Storage, &out.Storage
+		x := (*in).DeepCopy()
+		*out = &x
 	}
-	if in.Netmask != nil {
-		in, out := &in.Netmask, &out.Netmask
-		*out = new(string)
-		**out = **in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PoolOverCommit.
+func (in *PoolOverCommit) DeepCopy() *PoolOverCommit {
+	if in == nil {
+		return nil
 	}
-	if in.SubnetType != nil {
-		in, out := &in.SubnetType, &out.SubnetType
-		*out = new(string)
-		**out = **in
+	out := new(PoolOverCommit)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PoolSpec) DeepCopyInto(out *PoolSpec) {
+	*out = *in
+	in.FailureDomainSpec.DeepCopyInto(&out.FailureDomainSpec)
+	out.IBMPoolSpec = in.IBMPoolSpec
+	in.OverCommit.DeepCopyInto(&out.OverCommit)
+	if in.ExtendedResources != nil {
+		in, out := &in.ExtendedResources, &out.ExtendedResources
+		*out = make(map[string]int, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
 	}
-	if in.IpAddresses != nil {
-		in, out := &in.IpAddresses, &out.IpAddresses
+	if in.NetworkRefs != nil {
+		in, out := &in.NetworkRefs, &out.NetworkRefs
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
-	if in.Nameservers != nil {
-		in, out := &in.Nameservers, &out.Nameservers
-		*out = make([]string, len(*in))
+	if in.Taints != nil {
+		in, out := &in.Taints, &out.Taints
+		*out = make([]Taint, len(*in))
 		copy(*out, *in)
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkSpec.
-func (in *NetworkSpec) DeepCopy() *NetworkSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PoolSpec.
+func (in *PoolSpec) DeepCopy() *PoolSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(NetworkSpec)
+	out := new(PoolSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *NetworkStatus) DeepCopyInto(out *NetworkStatus) {
+func (in *PoolStatus) DeepCopyInto(out *PoolStatus) {
+	*out = *in
+	if in.ExtendedResourcesAvailable != nil {
+		in, out := &in.ExtendedResourcesAvailable, &out.ExtendedResourcesAvailable
+		*out = make(map[string]int, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PoolStatus.
+func (in *PoolStatus) DeepCopy() *PoolStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PoolStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PoolUnschedulableReason) DeepCopyInto(out *PoolUnschedulableReason) {
 	*out = *in
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkStatus.
-func (in *NetworkStatus) DeepCopy() *NetworkStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PoolUnschedulableReason.
+func (in *PoolUnschedulableReason) DeepCopy() *PoolUnschedulableReason {
 	if in == nil {
 		return nil
 	}
-	out := new(NetworkStatus)
+	out := new(PoolUnschedulableReason)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in Networks) DeepCopyInto(out *Networks) {
+func (in Pools) DeepCopyInto(out *Pools) {
 	{
 		in := &in
-		*out = make(Networks, len(*in))
+		*out = make(Pools, len(*in))
 		for i := range *in {
 			if (*in)[i] != nil {
 				in, out := &(*in)[i], &(*out)[i]
-				*out = new(Network)
+				*out = new(Pool)
 				(*in).DeepCopyInto(*out)
 			}
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Networks.
-func (in Networks) DeepCopy() Networks {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Pools.
+func (in Pools) DeepCopy() Pools {
 	if in == nil {
 		return nil
 	}
-	out := new(Networks)
+	out := new(Pools)
 	in.DeepCopyInto(out)
 	return *out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Pool) DeepCopyInto(out *Pool) {
+func (in *PreferredPoolTerm) DeepCopyInto(out *PreferredPoolTerm) {
+	*out = *in
+	in.Preference.DeepCopyInto(&out.Preference)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PreferredPoolTerm.
+func (in *PreferredPoolTerm) DeepCopy() *PreferredPoolTerm {
+	if in == nil {
+		return nil
+	}
+	out := new(PreferredPoolTerm)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Reservation) DeepCopyInto(out *Reservation) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Pool.
-func (in *Pool) DeepCopy() *Pool {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Reservation.
+func (in *Reservation) DeepCopy() *Reservation {
 	if in == nil {
 		return nil
 	}
-	out := new(Pool)
+	out := new(Reservation)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *Pool) DeepCopyObject() runtime.Object {
+func (in *Reservation) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -391,31 +1124,31 @@ func (in *Pool) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *PoolList) DeepCopyInto(out *PoolList) {
+func (in *ReservationList) DeepCopyInto(out *ReservationList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]Pool, len(*in))
+		*out = make([]Reservation, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PoolList.
-func (in *PoolList) DeepCopy() *PoolList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReservationList.
+func (in *ReservationList) DeepCopy() *ReservationList {
 	if in == nil {
 		return nil
 	}
-	out := new(PoolList)
+	out := new(ReservationList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *PoolList) DeepCopyObject() runtime.Object {
+func (in *ReservationList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -423,65 +1156,61 @@ func (in *PoolList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *PoolSpec) DeepCopyInto(out *PoolSpec) {
+func (in *ReservationSpec) DeepCopyInto(out *ReservationSpec) {
 	*out = *in
-	in.FailureDomainSpec.DeepCopyInto(&out.FailureDomainSpec)
-	out.IBMPoolSpec = in.IBMPoolSpec
-	if in.Taints != nil {
-		in, out := &in.Taints, &out.Taints
-		*out = make([]Taint, len(*in))
-		copy(*out, *in)
+	if in.PoolSelector != nil {
+		in, out := &in.PoolSelector, &out.PoolSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
 	}
+	in.Start.DeepCopyInto(&out.Start)
+	in.End.DeepCopyInto(&out.End)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PoolSpec.
-func (in *PoolSpec) DeepCopy() *PoolSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReservationSpec.
+func (in *ReservationSpec) DeepCopy() *ReservationSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(PoolSpec)
+	out := new(ReservationSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *PoolStatus) DeepCopyInto(out *PoolStatus) {
+func (in *ReservationStatus) DeepCopyInto(out *ReservationStatus) {
 	*out = *in
+	if in.Networks != nil {
+		in, out := &in.Networks, &out.Networks
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	out.Used = in.Used
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PoolStatus.
-func (in *PoolStatus) DeepCopy() *PoolStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReservationStatus.
+func (in *ReservationStatus) DeepCopy() *ReservationStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(PoolStatus)
+	out := new(ReservationStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in Pools) DeepCopyInto(out *Pools) {
-	{
-		in := &in
-		*out = make(Pools, len(*in))
-		for i := range *in {
-			if (*in)[i] != nil {
-				in, out := &(*in)[i], &(*out)[i]
-				*out = new(Pool)
-				(*in).DeepCopyInto(*out)
-			}
-		}
-	}
+func (in *ReservationUsage) DeepCopyInto(out *ReservationUsage) {
+	*out = *in
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Pools.
-func (in Pools) DeepCopy() Pools {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReservationUsage.
+func (in *ReservationUsage) DeepCopy() *ReservationUsage {
 	if in == nil {
 		return nil
 	}
-	out := new(Pools)
+	out := new(ReservationUsage)
 	in.DeepCopyInto(out)
-	return *out
+	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
@@ -513,3 +1242,59 @@ func (in *Toleration) DeepCopy() *Toleration {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TopologySpreadConstraint) DeepCopyInto(out *TopologySpreadConstraint) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TopologySpreadConstraint.
+func (in *TopologySpreadConstraint) DeepCopy() *TopologySpreadConstraint {
+	if in == nil {
+		return nil
+	}
+	out := new(TopologySpreadConstraint)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UnschedulableReasonCount) DeepCopyInto(out *UnschedulableReasonCount) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UnschedulableReasonCount.
+func (in *UnschedulableReasonCount) DeepCopy() *UnschedulableReasonCount {
+	if in == nil {
+		return nil
+	}
+	out := new(UnschedulableReasonCount)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UnschedulableReasons) DeepCopyInto(out *UnschedulableReasons) {
+	*out = *in
+	in.LastAttemptTime.DeepCopyInto(&out.LastAttemptTime)
+	if in.Summary != nil {
+		in, out := &in.Summary, &out.Summary
+		*out = make([]UnschedulableReasonCount, len(*in))
+		copy(*out, *in)
+	}
+	if in.Pools != nil {
+		in, out := &in.Pools, &out.Pools
+		*out = make([]PoolUnschedulableReason, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UnschedulableReasons.
+func (in *UnschedulableReasons) DeepCopy() *UnschedulableReasons {
+	if in == nil {
+		return nil
+	}
+	out := new(UnschedulableReasons)
+	in.DeepCopyInto(out)
+	return out
+}
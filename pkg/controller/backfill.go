@@ -0,0 +1,72 @@
+package controller
+
+import (
+	"log"
+	"sort"
+	"strings"
+
+	v1 "github.com/openshift-splat-team/vsphere-capacity-manager/pkg/apis/vspherecapacitymanager.splat.io/v1"
+	"github.com/openshift-splat-team/vsphere-capacity-manager/pkg/scheduler"
+	"github.com/openshift-splat-team/vsphere-capacity-manager/pkg/utils"
+)
+
+// leaseReservations returns the capacity to hold back for the blocking leases. Each blocking lease
+// reserves what it needs per pool in every pool it could be placed on, its nominated pools.
+// Pools it already holds are not reserved again.
+func leaseReservations(blockers []*v1.Lease, allPools []*v1.Pool) map[string]*scheduler.Reservation {
+	reservations := make(map[string]*scheduler.Reservation)
+	for _, blocker := range blockers {
+		held := make(map[string]bool)
+		for _, poolRef := range utils.GetLeasePoolRefs(blocker) {
+			held[poolRef.Name] = true
+		}
+
+		for _, pool := range utils.StructurallyMatchingPools(blocker, allPools) {
+			if held[pool.Name] {
+				continue
+			}
+			reservation, exists := reservations[pool.Name]
+			if !exists {
+				reservation = &scheduler.Reservation{}
+				reservations[pool.Name] = reservation
+			}
+			reservation.VCpus += blocker.Spec.VCpus
+			reservation.Memory += blocker.Spec.Memory
+			reservation.Storage += blocker.Spec.Storage
+			reservation.Networks += blocker.Spec.Networks
+			for name, amount := range blocker.Spec.ExtendedResources {
+				if reservation.ExtendedResources == nil {
+					reservation.ExtendedResources = make(map[string]int)
+				}
+				reservation.ExtendedResources[name] += amount
+			}
+		}
+	}
+	return reservations
+}
+
+// canBackfill reports whether the lease can be placed without using the capacity reserved in
+// state for the leases it would otherwise wait for.
+func (l *LeaseReconciler) canBackfill(state *scheduler.CycleState, lease *v1.Lease, allPools []*v1.Pool) bool {
+	requiredPools := lease.Spec.Pools
+	if requiredPools == 0 {
+		requiredPools = 1
+	}
+
+	candidates, err := l.getScheduler().Schedule(state, lease, allPools)
+	if err != nil {
+		log.Printf("lease %s can not be backfilled: %v", lease.Name, err)
+		return false
+	}
+	return len(candidates) >= requiredPools
+}
+
+// leaseNames returns the sorted, comma separated names of the leases.
+func leaseNames(leaseList []*v1.Lease) string {
+	names := make([]string, 0, len(leaseList))
+	for _, lease := range leaseList {
+		names = append(names, lease.Name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,98 @@
+package controller
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/openshift-splat-team/vsphere-capacity-manager/pkg/apis/vspherecapacitymanager.splat.io/v1"
+	"github.com/openshift-splat-team/vsphere-capacity-manager/pkg/scheduler"
+)
+
+func testBackfillPool(name string, vcpusAvailable int) *v1.Pool {
+	pool := testGangPool(name, "vc1", "pod1", 100)
+	pool.Status.VCpusAvailable = vcpusAvailable
+	pool.Status.MemoryAvailable = 400
+	return pool
+}
+
+func testBackfillLease(name string, vcpus int, requiredPool string) *v1.Lease {
+	return &v1.Lease{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec:       v1.LeaseSpec{VCpus: vcpus, Memory: 16, RequiredPool: requiredPool},
+		Status:     v1.LeaseStatus{Phase: v1.PHASE_PENDING},
+	}
+}
+
+func TestLeaseReservations(t *testing.T) {
+	allPools := []*v1.Pool{testBackfillPool("pool-a", 100), testBackfillPool("pool-b", 100)}
+
+	large := testBackfillLease("large", 64, "")
+	pinned := testBackfillLease("pinned", 8, "pool-a")
+	holding := testBackfillLease("holding", 16, "")
+	holding.OwnerReferences = []metav1.OwnerReference{{Kind: "Pool", Name: "pool-b"}}
+
+	reservations := leaseReservations([]*v1.Lease{large, pinned, holding}, allPools)
+
+	if got := reservations["pool-a"].VCpus; got != 88 {
+		t.Errorf("expected 88 vCPUs reserved in pool-a, got %d", got)
+	}
+	if got := reservations["pool-b"].VCpus; got != 64 {
+		t.Errorf("expected 64 vCPUs reserved in pool-b, got %d", got)
+	}
+	if got := reservations["pool-a"].Memory; got != 48 {
+		t.Errorf("expected 48 GB memory reserved in pool-a, got %d", got)
+	}
+}
+
+func TestCanBackfill(t *testing.T) {
+	tests := []struct {
+		name     string
+		pools    []*v1.Pool
+		blocker  *v1.Lease
+		lease    *v1.Lease
+		expected bool
+	}{
+		{
+			name:     "small lease fits beside the reserved capacity",
+			pools:    []*v1.Pool{testBackfillPool("pool-a", 80)},
+			blocker:  testBackfillLease("large", 64, ""),
+			lease:    testBackfillLease("small", 8, ""),
+			expected: true,
+		},
+		{
+			name:     "small lease would delay the blocked lease",
+			pools:    []*v1.Pool{testBackfillPool("pool-a", 64)},
+			blocker:  testBackfillLease("large", 64, ""),
+			lease:    testBackfillLease("small", 8, ""),
+			expected: false,
+		},
+		{
+			name:     "small lease uses a pool the blocked lease can not use",
+			pools:    []*v1.Pool{testBackfillPool("pool-a", 64), testBackfillPool("pool-b", 64)},
+			blocker:  testBackfillLease("large", 64, "pool-a"),
+			lease:    testBackfillLease("small", 8, ""),
+			expected: true,
+		},
+		{
+			name:     "large lease can not backfill",
+			pools:    []*v1.Pool{testBackfillPool("pool-a", 80)},
+			blocker:  testBackfillLease("large", 64, ""),
+			lease:    testBackfillLease("larger", 32, ""),
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := &scheduler.CycleState{
+				Reservations: leaseReservations([]*v1.Lease{tt.blocker}, tt.pools),
+			}
+
+			reconciler := &LeaseReconciler{}
+			if result := reconciler.canBackfill(state, tt.lease, tt.pools); result != tt.expected {
+				t.Errorf("canBackfill() = %v, expected %v", result, tt.expected)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package controller
 
 import (
 	"sync"
+	"time"
 
 	v1 "github.com/openshift-splat-team/vsphere-capacity-manager/pkg/apis/vspherecapacitymanager.splat.io/v1"
 )
@@ -11,4 +12,14 @@ var (
 	pools         = make(map[string]*v1.Pool)
 	leases        = make(map[string]*v1.Lease)
 	networks      = make(map[string]*v1.Network)
+
+	// leasePriorityClasses is keyed by name since LeasePriorityClass is cluster scoped.
+	leasePriorityClasses = make(map[string]*v1.LeasePriorityClass)
+
+	leaseQuotas  = make(map[string]*v1.LeaseQuota)
+	reservations = make(map[string]*v1.Reservation)
+
+	// leaseHoldTimes are the most recent hold times of released leases, per network type. They are
+	// seeded from the fulfilled leases in the cache when a network type has none, as after a restart.
+	leaseHoldTimes = make(map[v1.NetworkType][]time.Duration)
 )
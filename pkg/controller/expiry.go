@@ -0,0 +1,158 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1 "github.com/openshift-splat-team/vsphere-capacity-manager/pkg/apis/vspherecapacitymanager.splat.io/v1"
+	"github.com/openshift-splat-team/vsphere-capacity-manager/pkg/utils"
+	"github.com/openshift-splat-team/vsphere-capacity-manager/pkg/utils/conditions"
+)
+
+// LEASE_EXPIRY_WARNING_DIVISOR controls when a lease with a TTL is reported as expiring: once
+// less than TTL / LEASE_EXPIRY_WARNING_DIVISOR remains before it expires.
+const LEASE_EXPIRY_WARNING_DIVISOR = 4
+
+// leaseRenewTime returns the last time the lease was renewed: the later of status.renewTime and
+// the renew-time annotation. Renewals in the future are treated as renewals now. Leases that
+// have not been renewed yet are renewed now, which starts their TTL.
+func leaseRenewTime(lease *v1.Lease, now time.Time) time.Time {
+	renewTime := now
+	if lease.Status.RenewTime != nil {
+		renewTime = lease.Status.RenewTime.Time
+	}
+
+	if value, exists := lease.Annotations[v1.LeaseRenewTimeAnnotation]; exists {
+		annotated, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			log.Printf("lease %s has an invalid %s annotation %q: %v", lease.Name, v1.LeaseRenewTimeAnnotation, value, err)
+		} else if annotated.After(renewTime) {
+			renewTime = annotated
+		}
+	}
+
+	if renewTime.After(now) {
+		renewTime = now
+	}
+	return renewTime
+}
+
+// checkLeaseExpiry updates the renew and expire times and the Expiring condition of a lease with
+// a TTL. It returns whether the lease has expired and, if not, how long until its next check.
+func checkLeaseExpiry(lease *v1.Lease, now time.Time) (bool, time.Duration) {
+	if lease.Spec.TTL == nil || lease.Spec.TTL.Duration <= 0 {
+		return false, 0
+	}
+	ttl := lease.Spec.TTL.Duration
+
+	renewTime := metav1.NewTime(leaseRenewTime(lease, now))
+	expireTime := metav1.NewTime(renewTime.Add(ttl))
+	lease.Status.RenewTime = &renewTime
+	lease.Status.ExpireTime = &expireTime
+
+	remaining := expireTime.Sub(now)
+	if remaining <= 0 {
+		return true, 0
+	}
+
+	warningWindow := ttl / LEASE_EXPIRY_WARNING_DIVISOR
+	if remaining <= warningWindow {
+		conditions.Set(lease, conditions.TrueConditionWithReason(
+			v1.LeaseConditionTypeExpiring,
+			v1.ReasonLeaseExpiring,
+			"lease expires at %s unless it is renewed",
+			expireTime.UTC().Format(time.RFC3339),
+		))
+		return false, remaining
+	}
+
+	conditions.Set(lease, conditions.FalseCondition(
+		v1.LeaseConditionTypeExpiring,
+	))
+	return false, remaining - warningWindow
+}
+
+// reconcileLeaseExpiry tracks the renewals of a fulfilled lease with a TTL, warns as it nears
+// expiry and expires it once the TTL passes without a renewal.
+func (l *LeaseReconciler) reconcileLeaseExpiry(ctx context.Context, lease *v1.Lease) (ctrl.Result, error) {
+	previousStatus := lease.Status.DeepCopy()
+	wasExpiring := conditions.IsTrue(lease, v1.LeaseConditionTypeExpiring)
+
+	expired, requeueAfter := checkLeaseExpiry(lease, time.Now())
+	if expired {
+		return ctrl.Result{}, l.expireLease(ctx, lease)
+	}
+
+	expiring := conditions.IsTrue(lease, v1.LeaseConditionTypeExpiring)
+	if expiring && !wasExpiring {
+		l.recordEvent(lease, corev1.EventTypeWarning, v1.ReasonLeaseExpiring,
+			"lease expires at %s unless it is renewed", lease.Status.ExpireTime.UTC().Format(time.RFC3339))
+	} else if !expiring && wasExpiring {
+		l.recordEvent(lease, corev1.EventTypeNormal, v1.ReasonLeaseRenewed,
+			"lease was renewed until %s", lease.Status.ExpireTime.UTC().Format(time.RFC3339))
+	}
+
+	if !equality.Semantic.DeepEqual(previousStatus, &lease.Status) {
+		if err := l.Client.Status().Update(ctx, lease); err != nil {
+			return ctrl.Result{}, fmt.Errorf("error updating lease expiry status: %w", err)
+		}
+	}
+
+	log.Printf("lease %s expires at %s - requeuing in %v", lease.Name, lease.Status.ExpireTime.UTC().Format(time.RFC3339), requeueAfter)
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
+}
+
+// expireLease deletes an expired lease, or fails it and releases its pools and networks when
+// its expiration policy is Release.
+func (l *LeaseReconciler) expireLease(ctx context.Context, lease *v1.Lease) error {
+	message := fmt.Sprintf("lease was not renewed within its ttl of %s", lease.Spec.TTL.Duration)
+	log.Printf("lease %s expired: %s", lease.Name, message)
+
+	policy := lease.Spec.ExpirationPolicy
+	if len(policy) == 0 {
+		policy = v1.LeaseExpirationPolicyDelete
+	}
+	LeaseExpirationsTotal.With(prometheus.Labels{
+		"namespace":   lease.Namespace,
+		"networkType": string(lease.Spec.NetworkType),
+		"policy":      string(policy),
+	}).Inc()
+	l.recordEvent(lease, corev1.EventTypeWarning, v1.ReasonLeaseExpired, "%s", message)
+
+	if policy == v1.LeaseExpirationPolicyDelete {
+		if err := l.Client.Delete(ctx, lease); err != nil {
+			return client.IgnoreNotFound(err)
+		}
+		return nil
+	}
+
+	if ownRef := utils.DoesLeaseHavePool(lease); ownRef != nil {
+		LeasesInUse.With(prometheus.Labels{
+			"namespace": lease.Namespace,
+			"pool":      ownRef.Name,
+		}).Dec()
+	}
+	recordLeaseHoldTime(lease, time.Now())
+	conditions.Set(lease, conditions.FalseCondition(
+		v1.LeaseConditionTypeExpiring,
+	))
+	failLease(lease, v1.ReasonLeaseExpired, message)
+	return l.persistFailedLease(ctx, lease)
+}
+
+// recordEvent emits an event on the lease when the reconciler has an event recorder.
+func (l *LeaseReconciler) recordEvent(lease *v1.Lease, eventType, reason, messageFormat string, messageArgs ...interface{}) {
+	if l.Recorder == nil {
+		return
+	}
+	l.Recorder.Eventf(lease, eventType, reason, messageFormat, messageArgs...)
+}
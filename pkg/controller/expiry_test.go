@@ -0,0 +1,126 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1 "github.com/openshift-splat-team/vsphere-capacity-manager/pkg/apis/vspherecapacitymanager.splat.io/v1"
+	"github.com/openshift-splat-team/vsphere-capacity-manager/pkg/utils/conditions"
+)
+
+func TestLeaseRenewTime(t *testing.T) {
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	statusRenew := metav1.NewTime(now.Add(-time.Hour))
+
+	tests := []struct {
+		name       string
+		renewTime  *metav1.Time
+		annotation string
+		expected   time.Time
+	}{
+		{name: "never renewed starts now", expected: now},
+		{name: "status renew time", renewTime: &statusRenew, expected: statusRenew.Time},
+		{name: "later annotation wins", renewTime: &statusRenew, annotation: now.Add(-time.Minute).Format(time.RFC3339), expected: now.Add(-time.Minute)},
+		{name: "earlier annotation is ignored", renewTime: &statusRenew, annotation: now.Add(-2 * time.Hour).Format(time.RFC3339), expected: statusRenew.Time},
+		{name: "invalid annotation is ignored", renewTime: &statusRenew, annotation: "yesterday", expected: statusRenew.Time},
+		{name: "future annotation counts as now", renewTime: &statusRenew, annotation: now.Add(time.Hour).Format(time.RFC3339), expected: now},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lease := &v1.Lease{ObjectMeta: metav1.ObjectMeta{Name: "lease"}}
+			lease.Status.RenewTime = tt.renewTime
+			if tt.annotation != "" {
+				lease.Annotations = map[string]string{v1.LeaseRenewTimeAnnotation: tt.annotation}
+			}
+
+			if got := leaseRenewTime(lease, now); !got.Equal(tt.expected) {
+				t.Errorf("expected renew time %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestCheckLeaseExpiry(t *testing.T) {
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	ttl := &metav1.Duration{Duration: 4 * time.Hour}
+
+	tests := []struct {
+		name            string
+		ttl             *metav1.Duration
+		renewedAgo      time.Duration
+		expectExpired   bool
+		expectExpiring  bool
+		expectRequeueIn time.Duration
+	}{
+		{name: "no ttl", ttl: nil},
+		{name: "just renewed", ttl: ttl, renewedAgo: 0, expectRequeueIn: 3 * time.Hour},
+		{name: "within warning window", ttl: ttl, renewedAgo: 3*time.Hour + 30*time.Minute, expectExpiring: true, expectRequeueIn: 30 * time.Minute},
+		{name: "expired", ttl: ttl, renewedAgo: 5 * time.Hour, expectExpired: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			renewTime := metav1.NewTime(now.Add(-tt.renewedAgo))
+			lease := &v1.Lease{
+				ObjectMeta: metav1.ObjectMeta{Name: "lease"},
+				Spec:       v1.LeaseSpec{TTL: tt.ttl},
+				Status:     v1.LeaseStatus{Phase: v1.PHASE_FULFILLED, RenewTime: &renewTime},
+			}
+
+			expired, requeueIn := checkLeaseExpiry(lease, now)
+			if expired != tt.expectExpired {
+				t.Fatalf("expected expired to be %v, got %v", tt.expectExpired, expired)
+			}
+			if requeueIn != tt.expectRequeueIn {
+				t.Errorf("expected requeue in %v, got %v", tt.expectRequeueIn, requeueIn)
+			}
+			if tt.ttl == nil {
+				if lease.Status.ExpireTime != nil {
+					t.Errorf("expected no expire time for a lease without ttl, got %v", lease.Status.ExpireTime)
+				}
+				return
+			}
+			if expected := renewTime.Add(tt.ttl.Duration); !lease.Status.ExpireTime.Time.Equal(expected) {
+				t.Errorf("expected expire time %s, got %s", expected, lease.Status.ExpireTime.Time)
+			}
+			if !tt.expectExpired && conditions.IsTrue(lease, v1.LeaseConditionTypeExpiring) != tt.expectExpiring {
+				t.Errorf("expected Expiring condition to be %v, got %v", tt.expectExpiring, lease.Status.Conditions)
+			}
+		})
+	}
+}
+
+// deletingClient is a client.Client stub that records deleted objects.
+type deletingClient struct {
+	client.Client
+	deleted []string
+}
+
+func (d *deletingClient) Delete(_ context.Context, obj client.Object, _ ...client.DeleteOption) error {
+	d.deleted = append(d.deleted, obj.GetName())
+	return nil
+}
+
+func TestReconcileLeaseExpiry_DeletesExpiredLease(t *testing.T) {
+	renewTime := metav1.NewTime(time.Now().Add(-2 * time.Hour))
+	lease := &v1.Lease{
+		ObjectMeta: metav1.ObjectMeta{Name: "abandoned-lease", Namespace: "default"},
+		Spec:       v1.LeaseSpec{TTL: &metav1.Duration{Duration: time.Hour}},
+		Status:     v1.LeaseStatus{Phase: v1.PHASE_FULFILLED, RenewTime: &renewTime},
+	}
+
+	stub := &deletingClient{}
+	reconciler := &LeaseReconciler{Client: stub}
+
+	if _, err := reconciler.reconcileLeaseExpiry(context.Background(), lease); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(stub.deleted) != 1 || stub.deleted[0] != lease.Name {
+		t.Errorf("expected lease %s to be deleted, got %v", lease.Name, stub.deleted)
+	}
+}
@@ -0,0 +1,209 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1 "github.com/openshift-splat-team/vsphere-capacity-manager/pkg/apis/vspherecapacitymanager.splat.io/v1"
+	"github.com/openshift-splat-team/vsphere-capacity-manager/pkg/scheduler"
+)
+
+// statusRecordingClient is a client.Client stub that records Update and Status().Update calls.
+type statusRecordingClient struct {
+	client.Client
+	updates       int
+	statusUpdates int
+}
+
+func (s *statusRecordingClient) Update(_ context.Context, _ client.Object, _ ...client.UpdateOption) error {
+	s.updates++
+	return nil
+}
+
+func (s *statusRecordingClient) Status() client.SubResourceWriter {
+	return &statusRecordingWriter{parent: s}
+}
+
+type statusRecordingWriter struct {
+	client.SubResourceWriter
+	parent *statusRecordingClient
+}
+
+func (w *statusRecordingWriter) Update(_ context.Context, _ client.Object, _ ...client.SubResourceUpdateOption) error {
+	w.parent.statusUpdates++
+	return nil
+}
+
+func testGangPool(name, server, pod string, vcpus int, portGroups ...string) *v1.Pool {
+	pool := &v1.Pool{
+		TypeMeta:   metav1.TypeMeta{Kind: "Pool", APIVersion: "vspherecapacitymanager.splat.io/v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec: v1.PoolSpec{
+			FailureDomainSpec: v1.FailureDomainSpec{
+				VSpherePlatformFailureDomainSpec: configv1.VSpherePlatformFailureDomainSpec{
+					Server: server,
+				},
+			},
+			IBMPoolSpec:     v1.IBMPoolSpec{Pod: pod},
+			VCpus:           vcpus,
+			Memory:          400,
+			OverCommitRatio: "1.0",
+		},
+	}
+	for _, portGroup := range portGroups {
+		pool.Spec.Topology.Networks = append(pool.Spec.Topology.Networks, "/dc1/network/"+portGroup)
+	}
+	return pool
+}
+
+func testGangNetwork(name, pod, vlan string) *v1.Network {
+	return &v1.Network{
+		TypeMeta:   metav1.TypeMeta{Kind: "Network", APIVersion: "vspherecapacitymanager.splat.io/v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec: v1.NetworkSpec{
+			PodName:       &pod,
+			PortGroupName: "pg-" + vlan,
+			VlanId:        vlan,
+		},
+	}
+}
+
+func setupGangInventory(poolList []*v1.Pool, networkList []*v1.Network, lease *v1.Lease) func() {
+	oldPools, oldNetworks, oldLeases := pools, networks, leases
+	pools = make(map[string]*v1.Pool)
+	for _, pool := range poolList {
+		pools["default/"+pool.Name] = pool
+	}
+	networks = make(map[string]*v1.Network)
+	for _, network := range networkList {
+		networks["default/"+network.Name] = network
+	}
+	leases = map[string]*v1.Lease{"default/" + lease.Name: lease}
+	return func() { pools, networks, leases = oldPools, oldNetworks, oldLeases }
+}
+
+func countOwnerRefs(lease *v1.Lease, kind string) int {
+	count := 0
+	for _, ref := range lease.OwnerReferences {
+		if ref.Kind == kind {
+			count++
+		}
+	}
+	return count
+}
+
+func TestAllocateGang(t *testing.T) {
+	t.Run("assigns every pool and a shared VLAN at once", func(t *testing.T) {
+		lease := &v1.Lease{
+			ObjectMeta: metav1.ObjectMeta{Name: "gang", Namespace: "default"},
+			Spec:       v1.LeaseSpec{VCpus: 24, Memory: 96, Pools: 2, VCenters: 1, Networks: 1, NetworkType: v1.NetworkTypeSingleTenant},
+			Status:     v1.LeaseStatus{Phase: v1.PHASE_PENDING},
+		}
+		restore := setupGangInventory(
+			[]*v1.Pool{
+				testGangPool("vc1-a", "vc1", "pod1", 100, "pg-100"),
+				testGangPool("vc1-b", "vc1", "pod2", 100, "pg-100"),
+				testGangPool("vc2-a", "vc2", "pod3", 100, "pg-100"),
+			},
+			[]*v1.Network{
+				testGangNetwork("pod1-100", "pod1", "100"),
+				testGangNetwork("pod2-100", "pod2", "100"),
+				testGangNetwork("pod3-100", "pod3", "100"),
+			},
+			lease,
+		)
+		defer restore()
+
+		stub := &statusRecordingClient{}
+		reconciler := &LeaseReconciler{Client: stub}
+
+		allocated, err := reconciler.allocateGang(context.Background(), &scheduler.CycleState{}, lease, 2)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !allocated {
+			t.Fatalf("expected the lease to be allocated")
+		}
+		if count := countOwnerRefs(lease, "Pool"); count != 2 {
+			t.Errorf("expected 2 pool owner references, got %d", count)
+		}
+		if count := countOwnerRefs(lease, "Network"); count != 2 {
+			t.Errorf("expected 2 network owner references, got %d", count)
+		}
+		for _, ref := range lease.OwnerReferences {
+			if ref.Name == "vc2-a" || ref.Name == "pod3-100" {
+				t.Errorf("expected the vCenters cap to keep %s out of the assignment", ref.Name)
+			}
+		}
+		if !leaseHasCompleteGang(lease, 2) {
+			t.Errorf("expected the assignment to be complete")
+		}
+		if stub.updates != 0 || stub.statusUpdates != 0 {
+			t.Errorf("expected the caller to persist a successful assignment, got %d updates and %d status updates", stub.updates, stub.statusUpdates)
+		}
+	})
+
+	t.Run("releases a partial assignment and holds nothing while pending", func(t *testing.T) {
+		lease := &v1.Lease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "gang",
+				Namespace: "default",
+				OwnerReferences: []metav1.OwnerReference{
+					{Kind: "Pool", Name: "vc1-a"},
+					{Kind: "Network", Name: "pod1-100"},
+					{Kind: "SomeOtherKind", Name: "keep-me"},
+				},
+			},
+			Spec: v1.LeaseSpec{VCpus: 24, Memory: 96, Pools: 2, Networks: 1, NetworkType: v1.NetworkTypeSingleTenant},
+			Status: v1.LeaseStatus{
+				Phase:    v1.PHASE_PARTIAL,
+				PoolInfo: []v1.FailureDomainSpec{{ShortName: "vc1-a"}},
+			},
+		}
+		restore := setupGangInventory(
+			[]*v1.Pool{
+				testGangPool("vc1-a", "vc1", "pod1", 100, "pg-100"),
+				testGangPool("vc1-b", "vc1", "pod2", 10, "pg-100"),
+			},
+			[]*v1.Network{
+				testGangNetwork("pod1-100", "pod1", "100"),
+				testGangNetwork("pod2-100", "pod2", "100"),
+			},
+			lease,
+		)
+		defer restore()
+
+		stub := &statusRecordingClient{}
+		reconciler := &LeaseReconciler{Client: stub}
+
+		allocated, err := reconciler.allocateGang(context.Background(), &scheduler.CycleState{}, lease, 2)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if allocated {
+			t.Fatalf("expected the lease to not be allocated")
+		}
+		if len(lease.OwnerReferences) != 1 || lease.OwnerReferences[0].Name != "keep-me" {
+			t.Errorf("expected only the unrelated owner reference to remain, got %v", lease.OwnerReferences)
+		}
+		if lease.Status.Phase != v1.PHASE_PENDING {
+			t.Errorf("expected Phase to be Pending, got %s", lease.Status.Phase)
+		}
+		if lease.Status.PoolInfo != nil {
+			t.Errorf("expected PoolInfo to be cleared, got %v", lease.Status.PoolInfo)
+		}
+		if lease.Status.UnschedulableReasons == nil {
+			t.Errorf("expected the failed attempt to be recorded in the lease status")
+		}
+		if pools["default/vc1-a"].Status.VCpusAvailable != 100 {
+			t.Errorf("expected the released pool to be available again, got %d vCPUs", pools["default/vc1-a"].Status.VCpusAvailable)
+		}
+		if stub.updates != 1 || stub.statusUpdates != 1 {
+			t.Errorf("expected 1 update and 1 status update, got %d and %d", stub.updates, stub.statusUpdates)
+		}
+	})
+}
@@ -0,0 +1,373 @@
+package controller
+
+import (
+	"fmt"
+	"log"
+	"net/netip"
+	"sort"
+
+	v1 "github.com/openshift-splat-team/vsphere-capacity-manager/pkg/apis/vspherecapacitymanager.splat.io/v1"
+)
+
+// networkPrefix returns the subnet of the network, from the machine network CIDR or, failing
+// that, from the gateway and CIDR prefix length.
+func networkPrefix(network *v1.Network) (netip.Prefix, bool) {
+	if prefix, err := netip.ParsePrefix(network.Spec.MachineNetworkCidr); err == nil {
+		return prefix.Masked(), true
+	}
+	if network.Spec.Gateway == nil || network.Spec.Cidr == nil {
+		return netip.Prefix{}, false
+	}
+	gateway, err := netip.ParseAddr(*network.Spec.Gateway)
+	if err != nil {
+		return netip.Prefix{}, false
+	}
+	prefix, err := gateway.Prefix(*network.Spec.Cidr)
+	if err != nil {
+		return netip.Prefix{}, false
+	}
+	return prefix, true
+}
+
+// networkIPv6Prefix returns the IPv6 prefix of the network, from ipv6prefix or, failing that, from
+// the IPv6 gateway and prefix length.
+func networkIPv6Prefix(network *v1.Network) (netip.Prefix, bool) {
+	if prefix, err := netip.ParsePrefix(network.Spec.IpV6prefix); err == nil && prefix.Addr().Is6() {
+		return prefix.Masked(), true
+	}
+	gateway, err := netip.ParseAddr(network.Spec.GatewayIPv6)
+	if err != nil || !gateway.Is6() || network.Spec.CidrIPv6 == 0 {
+		return netip.Prefix{}, false
+	}
+	prefix, err := gateway.Prefix(network.Spec.CidrIPv6)
+	if err != nil {
+		return netip.Prefix{}, false
+	}
+	return prefix, true
+}
+
+// networkIPFamilies returns the IP families the network carries. IPv4 networks have an IPv4
+// gateway, IPv6 networks an IPv6 gateway within their IPv6 prefix.
+func networkIPFamilies(network *v1.Network) map[v1.IPFamily]bool {
+	families := make(map[v1.IPFamily]bool)
+	if network.Spec.Gateway != nil {
+		if gateway, err := netip.ParseAddr(*network.Spec.Gateway); err == nil && gateway.Is4() {
+			families[v1.IPFamilyIPv4] = true
+		}
+	}
+	if prefix, ok := networkIPv6Prefix(network); ok {
+		if gateway, err := netip.ParseAddr(network.Spec.GatewayIPv6); err == nil && prefix.Contains(gateway) {
+			families[v1.IPFamilyIPv6] = true
+		}
+	}
+	return families
+}
+
+// networkCarriesIPFamilies returns true if the network carries every IP family the lease requests.
+// Leases which don't set ipFamilies fit on every network.
+func networkCarriesIPFamilies(lease *v1.Lease, network *v1.Network) bool {
+	if len(lease.Spec.IPFamilies) == 0 {
+		return true
+	}
+	families := networkIPFamilies(network)
+	for _, family := range lease.Spec.IPFamilies {
+		if !families[family] {
+			return false
+		}
+	}
+	return true
+}
+
+// networkReservedAddresses returns the addresses of the network which are never allocated to a
+// lease: the network address, the gateway and, for IPv4 subnets, the broadcast address, as well
+// as the IPv6 gateway and the network address of the IPv6 prefix.
+func networkReservedAddresses(network *v1.Network) map[netip.Addr]bool {
+	reserved := make(map[netip.Addr]bool)
+	if network.Spec.Gateway != nil {
+		if gateway, err := netip.ParseAddr(*network.Spec.Gateway); err == nil {
+			reserved[gateway] = true
+		}
+	}
+	if gateway, err := netip.ParseAddr(network.Spec.GatewayIPv6); err == nil {
+		reserved[gateway] = true
+	}
+	if prefix, ok := networkIPv6Prefix(network); ok {
+		reserved[prefix.Addr()] = true
+	}
+	prefix, ok := networkPrefix(network)
+	if !ok {
+		return reserved
+	}
+	reserved[prefix.Addr()] = true
+	if prefix.Addr().Is4() {
+		broadcast := prefix.Addr().As4()
+		hostBits := 32 - prefix.Bits()
+		for i := 3; i >= 0 && hostBits > 0; i-- {
+			bits := min(hostBits, 8)
+			broadcast[i] |= byte(1<<bits - 1)
+			hostBits -= bits
+		}
+		reserved[netip.AddrFrom4(broadcast)] = true
+	}
+	return reserved
+}
+
+// assignableAddresses returns the ipAddresses of the network which may be allocated to leases, in
+// the order they are listed on the network.
+func assignableAddresses(network *v1.Network) []netip.Addr {
+	reserved := networkReservedAddresses(network)
+	seen := make(map[netip.Addr]bool)
+	var addresses []netip.Addr
+	for _, ipAddress := range network.Spec.IpAddresses {
+		addr, err := netip.ParseAddr(ipAddress)
+		if err != nil || reserved[addr] || seen[addr] {
+			continue
+		}
+		seen[addr] = true
+		addresses = append(addresses, addr)
+	}
+	return addresses
+}
+
+// addressesAllocatedToOtherLeases returns the addresses of the network allocated to leases other
+// than the given lease. Like networkAddressOwners, only leases in the namespace of the network
+// are considered.
+func addressesAllocatedToOtherLeases(lease *v1.Lease, network *v1.Network) map[netip.Addr]bool {
+	allocated := make(map[netip.Addr]bool)
+	for _, other := range leases {
+		if other.Namespace != network.Namespace || (other.Name == lease.Name && other.Namespace == lease.Namespace) {
+			continue
+		}
+		for _, allocation := range other.Status.IPAllocations {
+			if allocation.Network != network.Name {
+				continue
+			}
+			for _, ipAddress := range leaseIPAllocationAddresses(allocation) {
+				if addr, err := netip.ParseAddr(ipAddress); err == nil {
+					allocated[addr] = true
+				}
+			}
+		}
+	}
+	return allocated
+}
+
+// networkAddressOwners returns the names of the leases each address of the network is allocated
+// to. An address allocated to more than one lease is a conflict.
+func networkAddressOwners(network *v1.Network) map[netip.Addr][]string {
+	owners := make(map[netip.Addr][]string)
+	for _, lease := range leases {
+		if lease.Namespace != network.Namespace {
+			continue
+		}
+		for _, allocation := range lease.Status.IPAllocations {
+			if allocation.Network != network.Name {
+				continue
+			}
+			for _, ipAddress := range leaseIPAllocationAddresses(allocation) {
+				if addr, err := netip.ParseAddr(ipAddress); err == nil {
+					owners[addr] = append(owners[addr], lease.Name)
+				}
+			}
+		}
+	}
+	for _, names := range owners {
+		sort.Strings(names)
+	}
+	return owners
+}
+
+// freeAddresses returns the assignable addresses of the network not allocated to other leases.
+func freeAddresses(lease *v1.Lease, network *v1.Network) []netip.Addr {
+	allocated := addressesAllocatedToOtherLeases(lease, network)
+	var free []netip.Addr
+	for _, addr := range assignableAddresses(network) {
+		if !allocated[addr] {
+			free = append(free, addr)
+		}
+	}
+	return free
+}
+
+// leaseIPAllocationAddresses returns every address in the allocation.
+func leaseIPAllocationAddresses(allocation v1.LeaseIPAllocation) []string {
+	if len(allocation.Addresses) > 0 {
+		// the other addresses are taken from the window
+		return allocation.Addresses
+	}
+	var addresses []string
+	for _, ipAddress := range []string{allocation.APIVIP, allocation.IngressVIP, allocation.Bootstrap} {
+		if len(ipAddress) > 0 {
+			addresses = append(addresses, ipAddress)
+		}
+	}
+	return append(addresses, allocation.Nodes...)
+}
+
+// leaseIPAddressCount returns the number of addresses the lease needs on each of its networks.
+func leaseIPAddressCount(lease *v1.Lease) int {
+	request := lease.Spec.IPAddresses
+	if request == nil {
+		return 0
+	}
+	count := request.Nodes
+	for _, requested := range []bool{request.APIVIP, request.IngressVIP, request.Bootstrap} {
+		if requested {
+			count++
+		}
+	}
+	return count
+}
+
+// leaseNetworkAddressCount returns the number of addresses the lease needs on the network: the
+// window of networks which give each tenant one, otherwise the addresses the lease requests.
+func leaseNetworkAddressCount(lease *v1.Lease, network *v1.Network) (int, error) {
+	requested := leaseIPAddressCount(lease)
+	window := network.Spec.TenantIPAddresses
+	if window == 0 {
+		return requested, nil
+	}
+	if requested > window {
+		return 0, fmt.Errorf("%d addresses are requested, each tenant of the network gets %d", requested, window)
+	}
+	return window, nil
+}
+
+// selectAddresses picks count addresses from free. When contiguous is set, the addresses are the
+// first run of count consecutive addresses.
+func selectAddresses(free []netip.Addr, count int, contiguous bool) ([]netip.Addr, error) {
+	if len(free) < count {
+		return nil, fmt.Errorf("%d addresses are free, %d are needed", len(free), count)
+	}
+	if !contiguous || count == 0 {
+		return free[:count], nil
+	}
+
+	sorted := append([]netip.Addr(nil), free...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Less(sorted[j])
+	})
+	start := 0
+	for i := range sorted {
+		if i > 0 && sorted[i] != sorted[i-1].Next() {
+			start = i
+		}
+		if i-start+1 == count {
+			return sorted[start : i+1], nil
+		}
+	}
+	return nil, fmt.Errorf("no range of %d consecutive addresses is free", count)
+}
+
+// allocateLeaseAddresses allocates the addresses requested by the lease from the network. The
+// API VIP, ingress VIP and bootstrap addresses are taken first, followed by the node addresses.
+// On networks which give each tenant a window of addresses, the window is allocated and the
+// requested addresses are taken from its start.
+func allocateLeaseAddresses(lease *v1.Lease, network *v1.Network) (v1.LeaseIPAllocation, error) {
+	allocation := v1.LeaseIPAllocation{Network: network.Name}
+	request := lease.Spec.IPAddresses
+	if request == nil {
+		request = &v1.LeaseIPAddressRequest{}
+	}
+
+	count, err := leaseNetworkAddressCount(lease, network)
+	if err == nil {
+		var selected []netip.Addr
+		selected, err = selectAddresses(freeAddresses(lease, network), count, request.Contiguous)
+		if err == nil {
+			if network.Spec.TenantIPAddresses > 0 {
+				for _, addr := range selected {
+					allocation.Addresses = append(allocation.Addresses, addr.String())
+				}
+			}
+			assignLeaseAddresses(&allocation, request, selected)
+		}
+	}
+	if err != nil {
+		return allocation, fmt.Errorf("unable to allocate addresses on network %s: %v", network.Name, err)
+	}
+	return allocation, nil
+}
+
+// assignLeaseAddresses gives the requested roles addresses from selected, in order.
+func assignLeaseAddresses(allocation *v1.LeaseIPAllocation, request *v1.LeaseIPAddressRequest, selected []netip.Addr) {
+	next := func() string {
+		addr := selected[0]
+		selected = selected[1:]
+		return addr.String()
+	}
+	if request.APIVIP {
+		allocation.APIVIP = next()
+	}
+	if request.IngressVIP {
+		allocation.IngressVIP = next()
+	}
+	if request.Bootstrap {
+		allocation.Bootstrap = next()
+	}
+	for range request.Nodes {
+		allocation.Nodes = append(allocation.Nodes, next())
+	}
+}
+
+// networkHasAddressesFor returns true if the network carries the IP families of the lease and has
+// enough free addresses for it. Leases which don't request addresses fit on every network which
+// doesn't give tenants a window.
+func networkHasAddressesFor(lease *v1.Lease, network *v1.Network) bool {
+	if !networkCarriesIPFamilies(lease, network) {
+		return false
+	}
+	count, err := leaseNetworkAddressCount(lease, network)
+	if err != nil {
+		return false
+	}
+	if count == 0 {
+		return true
+	}
+	contiguous := lease.Spec.IPAddresses != nil && lease.Spec.IPAddresses.Contiguous
+	_, err = selectAddresses(freeAddresses(lease, network), count, contiguous)
+	return err == nil
+}
+
+// setLeaseIPAllocations allocates the addresses requested by the lease, or the window of
+// addresses given to each tenant, on each network it owns. Allocations on networks the lease
+// still owns are kept, and allocations on networks it no longer owns are released.
+func setLeaseIPAllocations(lease *v1.Lease) {
+	existing := make(map[string]v1.LeaseIPAllocation)
+	for _, allocation := range lease.Status.IPAllocations {
+		existing[allocation.Network] = allocation
+	}
+
+	var allocations []v1.LeaseIPAllocation
+	for _, ownerRef := range lease.OwnerReferences {
+		if ownerRef.Kind != "Network" {
+			continue
+		}
+		if allocation, exists := existing[ownerRef.Name]; exists {
+			allocations = append(allocations, allocation)
+			continue
+		}
+		var network *v1.Network
+		for _, candidate := range networks {
+			if candidate.Name == ownerRef.Name {
+				network = candidate
+				break
+			}
+		}
+		if network == nil {
+			log.Printf("network %s of lease %s not found, no addresses allocated", ownerRef.Name, lease.Name)
+			continue
+		}
+		if count, err := leaseNetworkAddressCount(lease, network); err == nil && count == 0 {
+			continue
+		}
+		allocation, err := allocateLeaseAddresses(lease, network)
+		if err != nil {
+			log.Printf("error allocating addresses for lease %s: %v", lease.Name, err)
+			continue
+		}
+		log.Printf("allocated addresses %v on network %s to lease %s", leaseIPAllocationAddresses(allocation), network.Name, lease.Name)
+		allocations = append(allocations, allocation)
+	}
+	lease.Status.IPAllocations = allocations
+}
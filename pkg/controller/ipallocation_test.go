@@ -0,0 +1,410 @@
+package controller
+
+import (
+	"fmt"
+	"net/netip"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/openshift-splat-team/vsphere-capacity-manager/pkg/apis/vspherecapacitymanager.splat.io/v1"
+)
+
+// testIPNetwork returns a network on 192.168.<vlan>.0/<cidr> listing every address of the subnet,
+// including the network, gateway and broadcast addresses.
+func testIPNetwork(name, vlan string, cidr int) *v1.Network {
+	network := testResizeNetwork(name, "pod1", vlan)
+	network.Spec.Cidr = &cidr
+	for i := 0; i < 1<<(32-cidr); i++ {
+		network.Spec.IpAddresses = append(network.Spec.IpAddresses, fmt.Sprintf("192.168.%s.%d", vlan, i))
+	}
+	return network
+}
+
+func ipAddressLease(name string, request v1.LeaseIPAddressRequest, networkNames ...string) *v1.Lease {
+	lease := &v1.Lease{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec:       v1.LeaseSpec{IPAddresses: &request},
+	}
+	for _, networkName := range networkNames {
+		lease.OwnerReferences = append(lease.OwnerReferences, metav1.OwnerReference{Kind: "Network", Name: networkName})
+	}
+	return lease
+}
+
+func TestAssignableAddresses(t *testing.T) {
+	tests := []struct {
+		name     string
+		network  func() *v1.Network
+		expected []string
+	}{
+		{
+			name:     "skips network, gateway and broadcast addresses",
+			network:  func() *v1.Network { return testIPNetwork("net", "10", 29) },
+			expected: []string{"192.168.10.2", "192.168.10.3", "192.168.10.4", "192.168.10.5", "192.168.10.6"},
+		},
+		{
+			name: "uses the machine network CIDR when set",
+			network: func() *v1.Network {
+				network := testIPNetwork("net", "10", 29)
+				network.Spec.Cidr = nil
+				network.Spec.MachineNetworkCidr = "192.168.10.0/30"
+				return network
+			},
+			expected: []string{"192.168.10.2", "192.168.10.4", "192.168.10.5", "192.168.10.6", "192.168.10.7"},
+		},
+		{
+			name: "skips invalid and duplicate addresses",
+			network: func() *v1.Network {
+				network := testResizeNetwork("net", "pod1", "10")
+				network.Spec.IpAddresses = []string{"192.168.10.1", "bogus", "192.168.10.5", "192.168.10.5"}
+				return network
+			},
+			expected: []string{"192.168.10.5"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, addr := range assignableAddresses(tt.network()) {
+				got = append(got, addr.String())
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestSelectAddresses(t *testing.T) {
+	var free []netip.Addr
+	for _, ipAddress := range []string{"10.0.0.9", "10.0.0.2", "10.0.0.5", "10.0.0.6", "10.0.0.7"} {
+		free = append(free, netip.MustParseAddr(ipAddress))
+	}
+
+	tests := []struct {
+		name       string
+		count      int
+		contiguous bool
+		expected   string
+		expectErr  bool
+	}{
+		{name: "takes addresses in order", count: 3, expected: "10.0.0.9 10.0.0.2 10.0.0.5"},
+		{name: "contiguous takes the first consecutive range", count: 3, contiguous: true, expected: "10.0.0.5 10.0.0.6 10.0.0.7"},
+		{name: "contiguous single address", count: 1, contiguous: true, expected: "10.0.0.2"},
+		{name: "no consecutive range is long enough", count: 4, contiguous: true, expectErr: true},
+		{name: "not enough addresses", count: 6, expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selected, err := selectAddresses(free, tt.count, tt.contiguous)
+			if tt.expectErr {
+				if err == nil {
+					t.Errorf("expected an error, got %v", selected)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var got []string
+			for _, addr := range selected {
+				got = append(got, addr.String())
+			}
+			if strings.Join(got, " ") != tt.expected {
+				t.Errorf("expected %s, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestSetLeaseIPAllocations(t *testing.T) {
+	defer setupTestNetworks(map[string]*v1.Network{
+		"default/net-10": testIPNetwork("net-10", "10", 28),
+		"default/net-11": testIPNetwork("net-11", "11", 28),
+	})()
+
+	other := ipAddressLease("other", v1.LeaseIPAddressRequest{}, "net-10")
+	other.Status.IPAllocations = []v1.LeaseIPAllocation{{Network: "net-10", APIVIP: "192.168.10.2", Nodes: []string{"192.168.10.4"}}}
+	request := v1.LeaseIPAddressRequest{APIVIP: true, IngressVIP: true, Nodes: 2, Contiguous: true}
+	lease := ipAddressLease("lease", request, "net-10")
+	defer setupTestLeases(map[string]*v1.Lease{
+		"default/other": other,
+		"default/lease": lease,
+	})()
+
+	setLeaseIPAllocations(lease)
+	expected := []v1.LeaseIPAllocation{{
+		Network:    "net-10",
+		APIVIP:     "192.168.10.5",
+		IngressVIP: "192.168.10.6",
+		Nodes:      []string{"192.168.10.7", "192.168.10.8"},
+	}}
+	if !reflect.DeepEqual(lease.Status.IPAllocations, expected) {
+		t.Fatalf("expected addresses not held by other leases, got %+v", lease.Status.IPAllocations)
+	}
+
+	t.Run("keeps allocations on networks still owned", func(t *testing.T) {
+		lease.OwnerReferences = append(lease.OwnerReferences, metav1.OwnerReference{Kind: "Network", Name: "net-11"})
+		setLeaseIPAllocations(lease)
+		if len(lease.Status.IPAllocations) != 2 || !reflect.DeepEqual(lease.Status.IPAllocations[0], expected[0]) {
+			t.Fatalf("expected the net-10 allocation to be kept and net-11 added, got %+v", lease.Status.IPAllocations)
+		}
+		if lease.Status.IPAllocations[1].APIVIP != "192.168.11.2" {
+			t.Errorf("expected the first assignable net-11 address for the API VIP, got %s", lease.Status.IPAllocations[1].APIVIP)
+		}
+	})
+
+	t.Run("releases allocations on networks no longer owned", func(t *testing.T) {
+		lease.OwnerReferences = lease.OwnerReferences[1:]
+		setLeaseIPAllocations(lease)
+		if len(lease.Status.IPAllocations) != 1 || lease.Status.IPAllocations[0].Network != "net-11" {
+			t.Errorf("expected only the net-11 allocation, got %+v", lease.Status.IPAllocations)
+		}
+	})
+}
+
+func TestAddressesAllocatedToOtherLeasesNamespace(t *testing.T) {
+	network := testIPNetwork("net-10", "10", 28)
+	local := ipAddressLease("local", v1.LeaseIPAddressRequest{}, "net-10")
+	local.Status.IPAllocations = []v1.LeaseIPAllocation{{Network: "net-10", APIVIP: "192.168.10.2"}}
+	elsewhere := ipAddressLease("elsewhere", v1.LeaseIPAddressRequest{}, "net-10")
+	elsewhere.Namespace = "elsewhere"
+	elsewhere.Status.IPAllocations = []v1.LeaseIPAllocation{{Network: "net-10", APIVIP: "192.168.10.3"}}
+	defer setupTestLeases(map[string]*v1.Lease{
+		"default/local":       local,
+		"elsewhere/elsewhere": elsewhere,
+	})()
+
+	allocated := addressesAllocatedToOtherLeases(ipAddressLease("lease", v1.LeaseIPAddressRequest{}), network)
+	owners := networkAddressOwners(network)
+	if len(allocated) != len(owners) {
+		t.Fatalf("expected the allocated addresses and their owners to agree, got %v and %v", allocated, owners)
+	}
+	for addr := range owners {
+		if !allocated[addr] {
+			t.Errorf("expected %s to be allocated", addr)
+		}
+	}
+	if allocated[netip.MustParseAddr("192.168.10.3")] {
+		t.Errorf("expected the allocation of a lease in another namespace to be ignored")
+	}
+}
+
+func TestNetworkHasAddressesFor(t *testing.T) {
+	network := testIPNetwork("net-10", "10", 29)
+	other := ipAddressLease("other", v1.LeaseIPAddressRequest{}, "net-10")
+	other.Status.IPAllocations = []v1.LeaseIPAllocation{{Network: "net-10", Nodes: []string{"192.168.10.4"}}}
+	defer setupTestLeases(map[string]*v1.Lease{"default/other": other})()
+
+	tests := []struct {
+		name     string
+		lease    *v1.Lease
+		expected bool
+	}{
+		{name: "no addresses requested", lease: &v1.Lease{}, expected: true},
+		{name: "enough free addresses", lease: ipAddressLease("lease", v1.LeaseIPAddressRequest{APIVIP: true, Nodes: 3}), expected: true},
+		{name: "too many addresses", lease: ipAddressLease("lease", v1.LeaseIPAddressRequest{Nodes: 5})},
+		{name: "no contiguous range", lease: ipAddressLease("lease", v1.LeaseIPAddressRequest{Nodes: 3, Contiguous: true})},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := networkHasAddressesFor(tt.lease, network); got != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestSetLeaseNetworkStatusExportsAddresses(t *testing.T) {
+	pool := testGangPool("pool-a", "vc1", "pod1", 100, "pg-10")
+	network := testIPNetwork("net-10", "10", 29)
+	lease := ipAddressLease("lease", v1.LeaseIPAddressRequest{APIVIP: true, IngressVIP: true, Nodes: 2}, "net-10")
+	defer setupGangInventory([]*v1.Pool{pool}, []*v1.Network{network}, lease)()
+
+	setLeaseNetworkStatus(lease, []*v1.Pool{pool})
+	envVars := lease.Status.EnvVarsMap[pool.Name]
+	for _, expected := range []string{
+		`export api_vip="192.168.10.2"`,
+		`export ingress_vip="192.168.10.3"`,
+		`export node_ips="192.168.10.4 192.168.10.5"`,
+	} {
+		if !strings.Contains(envVars, expected) {
+			t.Errorf("expected env vars to contain %s, got:\n%s", expected, envVars)
+		}
+	}
+	if strings.Contains(envVars, "bootstrap_ip") {
+		t.Errorf("expected no bootstrap address to be exported, got:\n%s", envVars)
+	}
+}
+
+func TestSetLeaseIPAllocationsTenantWindow(t *testing.T) {
+	network := testIPNetwork("net-10", "10", 28)
+	network.Spec.TenantIPAddresses = 4
+	defer setupTestNetworks(map[string]*v1.Network{"default/net-10": network})()
+
+	first := ipAddressLease("first", v1.LeaseIPAddressRequest{}, "net-10")
+	first.Spec.IPAddresses = nil
+	second := ipAddressLease("second", v1.LeaseIPAddressRequest{APIVIP: true, Nodes: 1}, "net-10")
+	defer setupTestLeases(map[string]*v1.Lease{
+		"default/first":  first,
+		"default/second": second,
+	})()
+
+	setLeaseIPAllocations(first)
+	expected := []v1.LeaseIPAllocation{{
+		Network:   "net-10",
+		Addresses: []string{"192.168.10.2", "192.168.10.3", "192.168.10.4", "192.168.10.5"},
+	}}
+	if !reflect.DeepEqual(first.Status.IPAllocations, expected) {
+		t.Fatalf("expected a window of 4 addresses without a request, got %+v", first.Status.IPAllocations)
+	}
+
+	setLeaseIPAllocations(second)
+	expected = []v1.LeaseIPAllocation{{
+		Network:   "net-10",
+		Addresses: []string{"192.168.10.6", "192.168.10.7", "192.168.10.8", "192.168.10.9"},
+		APIVIP:    "192.168.10.6",
+		Nodes:     []string{"192.168.10.7"},
+	}}
+	if !reflect.DeepEqual(second.Status.IPAllocations, expected) {
+		t.Fatalf("expected the requested addresses from the start of the next window, got %+v", second.Status.IPAllocations)
+	}
+
+	if networkHasAddressesFor(ipAddressLease("third", v1.LeaseIPAddressRequest{Nodes: 5}), network) {
+		t.Errorf("expected a request larger than the window not to fit")
+	}
+	third := ipAddressLease("third", v1.LeaseIPAddressRequest{Nodes: 1})
+	if !networkHasAddressesFor(third, network) {
+		t.Errorf("expected room for a third window of 4 in the 5 remaining addresses")
+	}
+	network.Spec.TenantIPAddresses = 6
+	if networkHasAddressesFor(third, network) {
+		t.Errorf("expected no room for a window of 6 in the 5 remaining addresses")
+	}
+}
+
+// withIPv6 adds the IPv6 gateway and prefix of fd65:<vlan>::/64 to the network.
+func withIPv6(network *v1.Network) *v1.Network {
+	network.Spec.GatewayIPv6 = fmt.Sprintf("fd65:%s::2", network.Spec.VlanId)
+	network.Spec.IpV6prefix = fmt.Sprintf("fd65:%s::/64", network.Spec.VlanId)
+	network.Spec.CidrIPv6 = 64
+	network.Spec.StartIPv6Address = fmt.Sprintf("fd65:%s::4", network.Spec.VlanId)
+	return network
+}
+
+func TestNetworkCarriesIPFamilies(t *testing.T) {
+	ipv4 := testIPNetwork("net-10", "10", 29)
+	dual := withIPv6(testIPNetwork("net-11", "11", 29))
+	derived := withIPv6(testIPNetwork("net-12", "12", 29))
+	derived.Spec.IpV6prefix = ""
+	outside := withIPv6(testIPNetwork("net-13", "13", 29))
+	outside.Spec.GatewayIPv6 = "fd65:99::2"
+
+	tests := []struct {
+		name     string
+		families []v1.IPFamily
+		expected map[string]bool
+	}{
+		{name: "no families requested", expected: map[string]bool{"net-10": true, "net-11": true, "net-12": true, "net-13": true}},
+		{name: "IPv4", families: []v1.IPFamily{v1.IPFamilyIPv4}, expected: map[string]bool{"net-10": true, "net-11": true, "net-12": true, "net-13": true}},
+		{name: "IPv6", families: []v1.IPFamily{v1.IPFamilyIPv6}, expected: map[string]bool{"net-11": true, "net-12": true}},
+		{name: "dual-stack", families: []v1.IPFamily{v1.IPFamilyIPv4, v1.IPFamilyIPv6}, expected: map[string]bool{"net-11": true, "net-12": true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lease := ipAddressLease("lease", v1.LeaseIPAddressRequest{})
+			lease.Spec.IPFamilies = tt.families
+			for _, network := range []*v1.Network{ipv4, dual, derived, outside} {
+				if got := networkHasAddressesFor(lease, network); got != tt.expected[network.Name] {
+					t.Errorf("expected %v for %s, got %v", tt.expected[network.Name], network.Name, got)
+				}
+			}
+		})
+	}
+}
+
+func TestSetLeaseNetworkStatusExportsIPv6(t *testing.T) {
+	pool := testGangPool("pool-a", "vc1", "pod1", 100, "pg-10")
+	network := withIPv6(testIPNetwork("net-10", "10", 29))
+	lease := ipAddressLease("lease", v1.LeaseIPAddressRequest{}, "net-10")
+	lease.Spec.IPAddresses = nil
+	defer setupGangInventory([]*v1.Pool{pool}, []*v1.Network{network}, lease)()
+
+	setLeaseNetworkStatus(lease, []*v1.Pool{pool})
+	if envVars := lease.Status.EnvVarsMap[pool.Name]; strings.Contains(envVars, "ipv6") || strings.Contains(envVars, "ip_families") {
+		t.Errorf("expected no IPv6 fields without ipFamilies, got:\n%s", envVars)
+	}
+
+	lease.Spec.IPFamilies = []v1.IPFamily{v1.IPFamilyIPv4, v1.IPFamilyIPv6}
+	setLeaseNetworkStatus(lease, []*v1.Pool{pool})
+	envVars := lease.Status.EnvVarsMap[pool.Name]
+	for _, expected := range []string{
+		`export gateway="192.168.10.1"`,
+		`export ip_families="IPv4 IPv6"`,
+		`export gateway_ipv6="fd65:10::2"`,
+		`export ipv6_prefix="fd65:10::/64"`,
+		`export cidr_ipv6="64"`,
+		`export start_ipv6_address="fd65:10::4"`,
+	} {
+		if !strings.Contains(envVars, expected) {
+			t.Errorf("expected env vars to contain %s, got:\n%s", expected, envVars)
+		}
+	}
+}
+
+func TestSetLeaseNetworkStatusIPv6OnlyNetwork(t *testing.T) {
+	pool := testGangPool("pool-a", "vc1", "pod1", 100, "pg-10")
+	network := withIPv6(testIPNetwork("net-10", "10", 29))
+	network.Spec.Gateway = nil
+	lease := ipAddressLease("lease", v1.LeaseIPAddressRequest{}, "net-10")
+	lease.Spec.IPAddresses = nil
+	lease.Spec.IPFamilies = []v1.IPFamily{v1.IPFamilyIPv6}
+	defer setupGangInventory([]*v1.Pool{pool}, []*v1.Network{network}, lease)()
+
+	if !networkCarriesIPFamilies(lease, network) {
+		t.Fatalf("expected the IPv6-only network to carry IPv6")
+	}
+	setLeaseNetworkStatus(lease, []*v1.Pool{pool})
+	envVars := lease.Status.EnvVarsMap[pool.Name]
+	for _, expected := range []string{
+		`export gateway="fd65:10::2"`,
+		`export dns_server="fd65:10::2"`,
+		`export ip_families="IPv6"`,
+		`export gateway_ipv6="fd65:10::2"`,
+	} {
+		if !strings.Contains(envVars, expected) {
+			t.Errorf("expected env vars to contain %s, got:\n%s", expected, envVars)
+		}
+	}
+}
+
+func TestSetLeaseIPAllocationsIPv6OnlyNetwork(t *testing.T) {
+	network := withIPv6(testIPNetwork("net-10", "10", 29))
+	network.Spec.Gateway, network.Spec.Cidr, network.Spec.IpAddresses = nil, nil, nil
+	for i := 0; i < 8; i++ {
+		network.Spec.IpAddresses = append(network.Spec.IpAddresses, fmt.Sprintf("fd65:10::%d", i))
+	}
+	lease := ipAddressLease("lease", v1.LeaseIPAddressRequest{APIVIP: true, Nodes: 2}, "net-10")
+	lease.Spec.IPFamilies = []v1.IPFamily{v1.IPFamilyIPv6}
+	defer setupTestNetworks(map[string]*v1.Network{"default/net-10": network})()
+	defer setupTestLeases(map[string]*v1.Lease{"default/lease": lease})()
+
+	setLeaseIPAllocations(lease)
+	expected := []v1.LeaseIPAllocation{{Network: "net-10", APIVIP: "fd65:10::1", Nodes: []string{"fd65:10::3", "fd65:10::4"}}}
+	if !reflect.DeepEqual(lease.Status.IPAllocations, expected) {
+		t.Errorf("expected the IPv6 gateway and network address to be skipped, got %+v", lease.Status.IPAllocations)
+	}
+
+	setNetworkStatus(network, time.Now())
+	if network.Status.IPAddressesAllocated != 3 || network.Status.IPAddressesFree != 3 {
+		t.Errorf("expected 3 allocated and 3 free addresses, got %d and %d", network.Status.IPAddressesAllocated, network.Status.IPAddressesFree)
+	}
+}
@@ -106,6 +106,100 @@ func TestFailLeaseIfUnsatisfiable(t *testing.T) {
 	})
 }
 
+func TestFailLeaseIfUnknownProfile(t *testing.T) {
+	l := &LeaseReconciler{}
+
+	t.Run("lease requesting an unknown profile is transitioned to Failed", func(t *testing.T) {
+		lease := &v1.Lease{
+			ObjectMeta: metav1.ObjectMeta{Name: "typo-lease"},
+			Spec:       v1.LeaseSpec{SchedulingProfile: "bin-packin"},
+			Status:     v1.LeaseStatus{Phase: v1.PHASE_PENDING},
+		}
+		lease.OwnerReferences = []metav1.OwnerReference{{Kind: "Pool", Name: "vc1-pool1"}}
+
+		if !l.failLeaseIfUnknownProfile(lease) {
+			t.Fatalf("expected failLeaseIfUnknownProfile to return true")
+		}
+		if lease.Status.Phase != v1.PHASE_FAILED {
+			t.Errorf("expected Phase to be Failed, got %s", lease.Status.Phase)
+		}
+		if len(lease.OwnerReferences) != 0 {
+			t.Errorf("expected pool owner reference to be released, got %v", lease.OwnerReferences)
+		}
+		found := false
+		for _, cond := range lease.Status.Conditions {
+			if cond.Type == v1.LeaseConditionTypeFulfilled && cond.Reason == v1.ReasonUnknownProfile {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("expected Fulfilled condition with reason %s, got %v", v1.ReasonUnknownProfile, lease.Status.Conditions)
+		}
+	})
+
+	t.Run("built-in and default profiles are accepted", func(t *testing.T) {
+		for _, profile := range []string{"", "default", "bin-packing", "random"} {
+			lease := &v1.Lease{
+				ObjectMeta: metav1.ObjectMeta{Name: "lease"},
+				Spec:       v1.LeaseSpec{SchedulingProfile: profile},
+				Status:     v1.LeaseStatus{Phase: v1.PHASE_PENDING},
+			}
+			if l.failLeaseIfUnknownProfile(lease) {
+				t.Errorf("expected profile %q to be accepted", profile)
+			}
+		}
+	})
+}
+
+func TestFailLeaseIfPastDeadline(t *testing.T) {
+	now := time.Now()
+	past := metav1.NewTime(now.Add(-time.Minute))
+	future := metav1.NewTime(now.Add(time.Minute))
+
+	tests := []struct {
+		name     string
+		notAfter *metav1.Time
+		expected bool
+	}{
+		{name: "no deadline", notAfter: nil, expected: false},
+		{name: "deadline ahead", notAfter: &future, expected: false},
+		{name: "deadline passed", notAfter: &past, expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lease := &v1.Lease{
+				ObjectMeta: metav1.ObjectMeta{Name: "lease"},
+				Spec:       v1.LeaseSpec{NotAfter: tt.notAfter},
+				Status:     v1.LeaseStatus{Phase: v1.PHASE_PARTIAL},
+			}
+			lease.OwnerReferences = []metav1.OwnerReference{{Kind: "Pool", Name: "vc1-pool1"}}
+
+			if got := failLeaseIfPastDeadline(lease, now); got != tt.expected {
+				t.Fatalf("expected failLeaseIfPastDeadline to return %v, got %v", tt.expected, got)
+			}
+			if !tt.expected {
+				return
+			}
+			if lease.Status.Phase != v1.PHASE_FAILED {
+				t.Errorf("expected Phase to be Failed, got %s", lease.Status.Phase)
+			}
+			if len(lease.OwnerReferences) != 0 {
+				t.Errorf("expected pool owner reference to be released, got %v", lease.OwnerReferences)
+			}
+			found := false
+			for _, cond := range lease.Status.Conditions {
+				if cond.Type == v1.LeaseConditionTypeFulfilled && cond.Reason == v1.ReasonDeadlineExceeded {
+					found = true
+				}
+			}
+			if !found {
+				t.Errorf("expected Fulfilled condition with reason %s, got %v", v1.ReasonDeadlineExceeded, lease.Status.Conditions)
+			}
+		})
+	}
+}
+
 func TestShouldLeaseBeDelayed_SkipsFailed(t *testing.T) {
 	now := metav1.Now()
 	older := metav1.NewTime(now.Add(-time.Minute))
@@ -134,6 +228,24 @@ func TestShouldLeaseBeDelayed_SkipsFailed(t *testing.T) {
 		}
 	})
 
+	t.Run("an older Scheduled lease does not delay a newer Pending lease", func(t *testing.T) {
+		scheduledLease := &v1.Lease{
+			ObjectMeta: metav1.ObjectMeta{Name: "scheduled-lease", CreationTimestamp: older},
+			Spec:       v1.LeaseSpec{NetworkType: v1.NetworkTypeSingleTenant},
+			Status:     v1.LeaseStatus{Phase: v1.PHASE_SCHEDULED},
+		}
+
+		restore := setupTestLeases(map[string]*v1.Lease{
+			"default/new-lease":       newLease,
+			"default/scheduled-lease": scheduledLease,
+		})
+		defer restore()
+
+		if shouldLeaseBeDelayed(newLease) {
+			t.Errorf("expected a Scheduled lease to not block a newer Pending lease")
+		}
+	})
+
 	t.Run("an older Partial lease still delays a newer Pending lease", func(t *testing.T) {
 		partialLease := &v1.Lease{
 			ObjectMeta: metav1.ObjectMeta{Name: "partial-lease", CreationTimestamp: older},
@@ -0,0 +1,128 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1 "github.com/openshift-splat-team/vsphere-capacity-manager/pkg/apis/vspherecapacitymanager.splat.io/v1"
+)
+
+// LEASE_PRIORITY_AGING_INTERVAL is how long a lease has to wait to gain one point of priority.
+// Aging keeps low priority leases from starving: a lease eventually overtakes a younger lease
+// whose class is N points higher once it has waited N intervals longer.
+const LEASE_PRIORITY_AGING_INTERVAL = time.Minute
+
+type LeasePriorityClassReconciler struct {
+	client.Client
+	Scheme     *runtime.Scheme
+	Recorder   record.EventRecorder
+	RESTMapper meta.RESTMapper
+}
+
+func (l *LeasePriorityClassReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if err := ctrl.NewControllerManagedBy(mgr).
+		For(&v1.LeasePriorityClass{}).
+		Complete(l); err != nil {
+		return fmt.Errorf("error setting up controller: %w", err)
+	}
+
+	// Set up API helpers from the manager.
+	l.Client = mgr.GetClient()
+	l.Scheme = mgr.GetScheme()
+	l.Recorder = mgr.GetEventRecorderFor("leasepriorityclasses-controller")
+	l.RESTMapper = mgr.GetRESTMapper()
+
+	return nil
+}
+
+func (l *LeasePriorityClassReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log.Print("Reconciling lease priority class")
+	defer log.Print("Finished reconciling lease priority class")
+
+	reconcileLock.Lock()
+	defer reconcileLock.Unlock()
+
+	priorityClass := &v1.LeasePriorityClass{}
+	if err := l.Get(ctx, req.NamespacedName, priorityClass); err != nil {
+		if client.IgnoreNotFound(err) == nil {
+			delete(leasePriorityClasses, req.Name)
+		}
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if priorityClass.DeletionTimestamp != nil {
+		delete(leasePriorityClasses, req.Name)
+		return ctrl.Result{}, nil
+	}
+
+	leasePriorityClasses[req.Name] = priorityClass
+	return ctrl.Result{}, nil
+}
+
+// leasePriority resolves the priority of the lease's priority class. Leases without a class
+// use the highest global default class, or 0 if there is none. Leases referencing a class that
+// does not exist also get 0.
+func leasePriority(lease *v1.Lease) int32 {
+	if name := lease.Spec.PriorityClassName; len(name) > 0 {
+		if priorityClass, ok := leasePriorityClasses[name]; ok {
+			return priorityClass.Spec.Value
+		}
+		return 0
+	}
+
+	var defaultClass *v1.LeasePriorityClass
+	for _, priorityClass := range leasePriorityClasses {
+		if !priorityClass.Spec.GlobalDefault {
+			continue
+		}
+		if defaultClass == nil || priorityClass.Spec.Value > defaultClass.Spec.Value {
+			defaultClass = priorityClass
+		}
+	}
+	if defaultClass != nil {
+		return defaultClass.Spec.Value
+	}
+	return 0
+}
+
+// leaseQueuedSince returns the time the lease started competing for pools: its creation time, or
+// its NotBefore time when that is later. Leases do not age while they wait for NotBefore.
+func leaseQueuedSince(lease *v1.Lease) metav1.Time {
+	if lease.Spec.NotBefore != nil && lease.Spec.NotBefore.After(lease.CreationTimestamp.Time) {
+		return *lease.Spec.NotBefore
+	}
+	return lease.CreationTimestamp
+}
+
+// effectiveLeasePriority returns the lease's priority plus one for every
+// LEASE_PRIORITY_AGING_INTERVAL since the lease was queued.
+func effectiveLeasePriority(lease *v1.Lease, now time.Time) int64 {
+	priority := int64(leasePriority(lease))
+	if queuedSince := leaseQueuedSince(lease); !queuedSince.IsZero() {
+		if age := now.Sub(queuedSince.Time); age > 0 {
+			priority += int64(age / LEASE_PRIORITY_AGING_INTERVAL)
+		}
+	}
+	return priority
+}
+
+// leaseHasPrecedence reports whether lease a should be scheduled before lease b. Higher
+// effective priority wins and ties go to the lease queued first.
+func leaseHasPrecedence(a, b *v1.Lease, now time.Time) bool {
+	priorityA := effectiveLeasePriority(a, now)
+	priorityB := effectiveLeasePriority(b, now)
+	if priorityA != priorityB {
+		return priorityA > priorityB
+	}
+	queuedA, queuedB := leaseQueuedSince(a), leaseQueuedSince(b)
+	return queuedA.Before(&queuedB)
+}
@@ -0,0 +1,217 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/openshift-splat-team/vsphere-capacity-manager/pkg/apis/vspherecapacitymanager.splat.io/v1"
+)
+
+func setupTestPriorityClasses(classes ...*v1.LeasePriorityClass) func() {
+	old := leasePriorityClasses
+	leasePriorityClasses = make(map[string]*v1.LeasePriorityClass)
+	for _, priorityClass := range classes {
+		leasePriorityClasses[priorityClass.Name] = priorityClass
+	}
+	return func() { leasePriorityClasses = old }
+}
+
+func testPriorityClass(name string, value int32, globalDefault bool) *v1.LeasePriorityClass {
+	return &v1.LeasePriorityClass{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: v1.LeasePriorityClassSpec{
+			Value:         value,
+			GlobalDefault: globalDefault,
+		},
+	}
+}
+
+func testPriorityLease(name, priorityClassName string, created time.Time, phase v1.Phase) *v1.Lease {
+	return &v1.Lease{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         "default",
+			CreationTimestamp: metav1.NewTime(created),
+		},
+		Spec: v1.LeaseSpec{
+			NetworkType:       v1.NetworkTypeSingleTenant,
+			PriorityClassName: priorityClassName,
+		},
+		Status: v1.LeaseStatus{Phase: phase},
+	}
+}
+
+func withNotBefore(lease *v1.Lease, notBefore time.Time) *v1.Lease {
+	lease.Spec.NotBefore = &metav1.Time{Time: notBefore}
+	return lease
+}
+
+func TestLeasePriority(t *testing.T) {
+	tests := []struct {
+		name              string
+		classes           []*v1.LeasePriorityClass
+		priorityClassName string
+		expected          int32
+	}{
+		{
+			name:     "no classes defined",
+			expected: 0,
+		},
+		{
+			name: "named class",
+			classes: []*v1.LeasePriorityClass{
+				testPriorityClass("release-blocking", 1000, false),
+				testPriorityClass("optional", -100, false),
+			},
+			priorityClassName: "release-blocking",
+			expected:          1000,
+		},
+		{
+			name: "unknown class",
+			classes: []*v1.LeasePriorityClass{
+				testPriorityClass("release-blocking", 1000, true),
+			},
+			priorityClassName: "does-not-exist",
+			expected:          0,
+		},
+		{
+			name: "global default used when no class is named",
+			classes: []*v1.LeasePriorityClass{
+				testPriorityClass("release-blocking", 1000, false),
+				testPriorityClass("presubmit", 100, true),
+			},
+			expected: 100,
+		},
+		{
+			name: "highest global default wins",
+			classes: []*v1.LeasePriorityClass{
+				testPriorityClass("presubmit", 100, true),
+				testPriorityClass("periodic", 200, true),
+			},
+			expected: 200,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			restore := setupTestPriorityClasses(tt.classes...)
+			defer restore()
+
+			lease := testPriorityLease("lease", tt.priorityClassName, time.Now(), v1.PHASE_PENDING)
+			if priority := leasePriority(lease); priority != tt.expected {
+				t.Errorf("leasePriority() = %d, expected %d", priority, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLeaseHasPrecedence(t *testing.T) {
+	restore := setupTestPriorityClasses(
+		testPriorityClass("release-blocking", 100, false),
+		testPriorityClass("optional", 0, false),
+	)
+	defer restore()
+
+	now := time.Now()
+
+	tests := []struct {
+		name     string
+		a        *v1.Lease
+		b        *v1.Lease
+		expected bool
+	}{
+		{
+			name:     "equal priority, older lease first",
+			a:        testPriorityLease("a", "optional", now.Add(-10*time.Second), v1.PHASE_PENDING),
+			b:        testPriorityLease("b", "optional", now, v1.PHASE_PENDING),
+			expected: true,
+		},
+		{
+			name:     "equal priority, younger lease second",
+			a:        testPriorityLease("a", "optional", now, v1.PHASE_PENDING),
+			b:        testPriorityLease("b", "optional", now.Add(-10*time.Second), v1.PHASE_PENDING),
+			expected: false,
+		},
+		{
+			name:     "higher priority beats older lease",
+			a:        testPriorityLease("a", "release-blocking", now, v1.PHASE_PENDING),
+			b:        testPriorityLease("b", "optional", now.Add(-30*time.Minute), v1.PHASE_PENDING),
+			expected: true,
+		},
+		{
+			name:     "aging lets a starved lease overtake a higher priority one",
+			a:        testPriorityLease("a", "optional", now.Add(-101*LEASE_PRIORITY_AGING_INTERVAL), v1.PHASE_PENDING),
+			b:        testPriorityLease("b", "release-blocking", now, v1.PHASE_PENDING),
+			expected: true,
+		},
+		{
+			name:     "leases do not age before notBefore",
+			a:        withNotBefore(testPriorityLease("a", "optional", now.Add(-2*24*time.Hour), v1.PHASE_PENDING), now.Add(-time.Minute)),
+			b:        testPriorityLease("b", "release-blocking", now, v1.PHASE_PENDING),
+			expected: false,
+		},
+		{
+			name:     "equal priority, lease queued first wins",
+			a:        withNotBefore(testPriorityLease("a", "optional", now.Add(-time.Hour), v1.PHASE_PENDING), now.Add(-10*time.Second)),
+			b:        testPriorityLease("b", "optional", now.Add(-30*time.Second), v1.PHASE_PENDING),
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := leaseHasPrecedence(tt.a, tt.b, now); result != tt.expected {
+				t.Errorf("leaseHasPrecedence() = %v, expected %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestShouldLeaseBeDelayed_Priority(t *testing.T) {
+	restore := setupTestPriorityClasses(
+		testPriorityClass("release-blocking", 1000, false),
+		testPriorityClass("optional", 0, true),
+	)
+	defer restore()
+
+	now := time.Now()
+	optional := testPriorityLease("optional-lease", "", now.Add(-time.Hour), v1.PHASE_PENDING)
+	blocking := testPriorityLease("blocking-lease", "release-blocking", now, v1.PHASE_PENDING)
+
+	restoreLeases := setupTestLeases(map[string]*v1.Lease{
+		"default/optional-lease": optional,
+		"default/blocking-lease": blocking,
+	})
+	defer restoreLeases()
+
+	if shouldLeaseBeDelayed(blocking) {
+		t.Errorf("expected a release-blocking lease to not wait behind an older optional lease")
+	}
+	if !shouldLeaseBeDelayed(optional) {
+		t.Errorf("expected an optional lease to wait behind a release-blocking lease")
+	}
+}
+
+func TestTriggerLeaseUpdates_Priority(t *testing.T) {
+	restore := setupTestPriorityClasses(testPriorityClass("release-blocking", 1000, false))
+	defer restore()
+
+	now := time.Now()
+	restoreLeases := setupTestLeases(map[string]*v1.Lease{
+		"default/old-lease":      testPriorityLease("old-lease", "", now.Add(-time.Hour), v1.PHASE_PENDING),
+		"default/blocking-lease": testPriorityLease("blocking-lease", "release-blocking", now, v1.PHASE_PENDING),
+	})
+	defer restoreLeases()
+
+	stub := &recordingClient{}
+	reconciler := &LeaseReconciler{Client: stub}
+
+	reconciler.triggerLeaseUpdates(context.Background(), v1.NetworkTypeSingleTenant)
+
+	if len(stub.updated) != 1 || stub.updated[0] != "blocking-lease" {
+		t.Errorf("expected the release-blocking lease to be force-updated, got %v", stub.updated)
+	}
+}
@@ -0,0 +1,289 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1 "github.com/openshift-splat-team/vsphere-capacity-manager/pkg/apis/vspherecapacitymanager.splat.io/v1"
+	"github.com/openshift-splat-team/vsphere-capacity-manager/pkg/utils"
+)
+
+const (
+	quotaResourceVCpus    = "vcpus"
+	quotaResourceMemory   = "memory"
+	quotaResourceLeases   = "leases"
+	quotaResourceNetworks = "networks"
+)
+
+type LeaseQuotaReconciler struct {
+	client.Client
+	Scheme     *runtime.Scheme
+	Recorder   record.EventRecorder
+	RESTMapper meta.RESTMapper
+}
+
+func (l *LeaseQuotaReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if err := ctrl.NewControllerManagedBy(mgr).
+		For(&v1.LeaseQuota{}).
+		Complete(l); err != nil {
+		return fmt.Errorf("error setting up controller: %w", err)
+	}
+
+	// Set up API helpers from the manager.
+	l.Client = mgr.GetClient()
+	l.Scheme = mgr.GetScheme()
+	l.Recorder = mgr.GetEventRecorderFor("leasequotas-controller")
+	l.RESTMapper = mgr.GetRESTMapper()
+
+	return nil
+}
+
+func (l *LeaseQuotaReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log.Print("Reconciling lease quota")
+	defer log.Print("Finished reconciling lease quota")
+
+	reconcileLock.Lock()
+	defer reconcileLock.Unlock()
+
+	quotaKey := fmt.Sprintf("%s/%s", req.Namespace, req.Name)
+
+	quota := &v1.LeaseQuota{}
+	if err := l.Get(ctx, req.NamespacedName, quota); err != nil {
+		if client.IgnoreNotFound(err) == nil {
+			delete(leaseQuotas, quotaKey)
+			deleteLeaseQuotaMetrics(req.Namespace, req.Name)
+		}
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if quota.DeletionTimestamp != nil {
+		delete(leaseQuotas, quotaKey)
+		deleteLeaseQuotaMetrics(req.Namespace, req.Name)
+		return ctrl.Result{}, nil
+	}
+
+	leaseQuotas[quotaKey] = quota
+
+	used := leaseQuotaUsage(quota)
+	if !reflect.DeepEqual(used, quota.Status.Used) {
+		quota.Status.Used = used
+		if err := l.Client.Status().Update(ctx, quota); err != nil {
+			return ctrl.Result{}, fmt.Errorf("error updating lease quota status: %w", err)
+		}
+	}
+
+	updateLeaseQuotaMetrics(quota)
+	return ctrl.Result{}, nil
+}
+
+// leaseMatchesQuota reports whether the lease is counted by the quota.
+func leaseMatchesQuota(lease *v1.Lease, quota *v1.LeaseQuota) bool {
+	if lease.Namespace != quota.Namespace {
+		return false
+	}
+	if len(quota.Spec.JobNamePrefix) > 0 && !strings.HasPrefix(lease.Labels[JobNameLabel], quota.Spec.JobNamePrefix) {
+		return false
+	}
+	if quota.Spec.Selector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(quota.Spec.Selector)
+		if err != nil {
+			log.Printf("lease quota %s has an invalid selector: %v", quota.Name, err)
+			return false
+		}
+		if !selector.Matches(labels.Set(lease.Labels)) {
+			return false
+		}
+	}
+	return true
+}
+
+// leaseResourceUsage returns the resources the lease holds. A lease holding no pools holds nothing.
+func leaseResourceUsage(lease *v1.Lease) v1.LeaseQuotaUsage {
+	poolCount := len(utils.GetLeasePoolRefs(lease))
+	if poolCount == 0 {
+		return v1.LeaseQuotaUsage{}
+	}
+
+	networkCount := 0
+	for _, ownerRef := range lease.OwnerReferences {
+		if ownerRef.Kind == "Network" {
+			networkCount++
+		}
+	}
+
+	allocated := leaseAllocatedResources(lease)
+	usage := v1.LeaseQuotaUsage{
+		VCpus:  allocated.VCpus * poolCount,
+		Memory: allocated.Memory * poolCount,
+		Leases: 1,
+	}
+	if networkCount > 0 {
+		usage.Networks = map[string]int{string(lease.Spec.NetworkType): networkCount}
+	}
+	return usage
+}
+
+// leaseResourceRequest returns the resources the lease will hold once it is fulfilled.
+func leaseResourceRequest(lease *v1.Lease) v1.LeaseQuotaUsage {
+	poolCount := lease.Spec.Pools
+	if poolCount == 0 {
+		poolCount = 1
+	}
+
+	request := v1.LeaseQuotaUsage{
+		VCpus:  lease.Spec.VCpus * poolCount,
+		Memory: lease.Spec.Memory * poolCount,
+		Leases: 1,
+	}
+	if lease.Spec.Networks > 0 {
+		request.Networks = map[string]int{string(lease.Spec.NetworkType): lease.Spec.Networks * poolCount}
+	}
+	return request
+}
+
+// leaseQuotaUsage sums the resources held by the leases counted by the quota.
+func leaseQuotaUsage(quota *v1.LeaseQuota) v1.LeaseQuotaUsage {
+	var used v1.LeaseQuotaUsage
+	for _, lease := range leases {
+		if !leaseMatchesQuota(lease, quota) {
+			continue
+		}
+		usage := leaseResourceUsage(lease)
+		used.VCpus += usage.VCpus
+		used.Memory += usage.Memory
+		used.Leases += usage.Leases
+		for networkType, count := range usage.Networks {
+			if used.Networks == nil {
+				used.Networks = make(map[string]int)
+			}
+			used.Networks[networkType] += count
+		}
+	}
+	return used
+}
+
+// leaseExceedsQuota returns a message naming the first quota the lease would exceed once
+// fulfilled, or an empty string when every quota counting the lease has room for it.
+func leaseExceedsQuota(lease *v1.Lease) string {
+	keys := make([]string, 0, len(leaseQuotas))
+	for key := range leaseQuotas {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	request := leaseResourceRequest(lease)
+	for _, key := range keys {
+		quota := leaseQuotas[key]
+		if !leaseMatchesQuota(lease, quota) {
+			continue
+		}
+
+		used := leaseQuotaUsage(quota)
+		hard := quota.Spec.Hard
+		exceeded := func(resource string, limit *int, used, requested int) string {
+			if limit == nil || used+requested <= *limit {
+				return ""
+			}
+			return fmt.Sprintf("lease quota %s exceeded for %s: requested %d, used %d, limit %d",
+				quota.Name, resource, requested, used, *limit)
+		}
+
+		if msg := exceeded(quotaResourceVCpus, hard.VCpus, used.VCpus, request.VCpus); msg != "" {
+			return msg
+		}
+		if msg := exceeded(quotaResourceMemory, hard.Memory, used.Memory, request.Memory); msg != "" {
+			return msg
+		}
+		if msg := exceeded(quotaResourceLeases, hard.Leases, used.Leases, request.Leases); msg != "" {
+			return msg
+		}
+		for networkType, requested := range request.Networks {
+			if limit, ok := hard.Networks[networkType]; ok {
+				resource := fmt.Sprintf("%s/%s", quotaResourceNetworks, networkType)
+				if msg := exceeded(resource, &limit, used.Networks[networkType], requested); msg != "" {
+					return msg
+				}
+			}
+		}
+	}
+	return ""
+}
+
+func updateLeaseQuotaMetrics(quota *v1.LeaseQuota) {
+	set := func(resource string, limit *int, used int) {
+		promLabels := prometheus.Labels{
+			"namespace": quota.Namespace,
+			"quota":     quota.Name,
+			"resource":  resource,
+		}
+		LeaseQuotaUsed.With(promLabels).Set(float64(used))
+		if limit != nil {
+			LeaseQuotaHard.With(promLabels).Set(float64(*limit))
+		} else {
+			LeaseQuotaHard.Delete(promLabels)
+		}
+	}
+
+	deleteLeaseQuotaMetrics(quota.Namespace, quota.Name)
+	set(quotaResourceVCpus, quota.Spec.Hard.VCpus, quota.Status.Used.VCpus)
+	set(quotaResourceMemory, quota.Spec.Hard.Memory, quota.Status.Used.Memory)
+	set(quotaResourceLeases, quota.Spec.Hard.Leases, quota.Status.Used.Leases)
+
+	networkTypes := make(map[string]bool)
+	for networkType := range quota.Spec.Hard.Networks {
+		networkTypes[networkType] = true
+	}
+	for networkType := range quota.Status.Used.Networks {
+		networkTypes[networkType] = true
+	}
+	for networkType := range networkTypes {
+		var limit *int
+		if value, ok := quota.Spec.Hard.Networks[networkType]; ok {
+			limit = &value
+		}
+		set(fmt.Sprintf("%s/%s", quotaResourceNetworks, networkType), limit, quota.Status.Used.Networks[networkType])
+	}
+}
+
+func deleteLeaseQuotaMetrics(namespace, name string) {
+	promLabels := prometheus.Labels{"namespace": namespace, "quota": name}
+	LeaseQuotaUsed.DeletePartialMatch(promLabels)
+	LeaseQuotaHard.DeletePartialMatch(promLabels)
+}
+
+// triggerQuotaUpdates touches every lease quota so that its usage is recomputed after leases
+// acquire or release resources.
+func (l *LeaseReconciler) triggerQuotaUpdates(ctx context.Context) {
+	for _, quota := range leaseQuotas {
+		err := l.Client.Get(ctx, types.NamespacedName{Name: quota.Name, Namespace: quota.Namespace}, quota)
+		if err != nil {
+			log.Printf("error getting lease quota %s: %v", quota.Name, err)
+			continue
+		}
+
+		if quota.Annotations == nil {
+			quota.Annotations = make(map[string]string)
+		}
+
+		quota.Annotations["last-updated"] = time.Now().Format(time.RFC3339)
+		err = l.Client.Update(ctx, quota)
+		if err != nil {
+			log.Printf("error updating lease quota %s annotations: %v", quota.Name, err)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+package controller
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/openshift-splat-team/vsphere-capacity-manager/pkg/apis/vspherecapacitymanager.splat.io/v1"
+	"github.com/openshift-splat-team/vsphere-capacity-manager/pkg/utils/conditions"
+)
+
+func setupTestLeaseQuotas(quotas ...*v1.LeaseQuota) func() {
+	old := leaseQuotas
+	leaseQuotas = make(map[string]*v1.LeaseQuota)
+	for _, quota := range quotas {
+		leaseQuotas[quota.Namespace+"/"+quota.Name] = quota
+	}
+	return func() { leaseQuotas = old }
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func testQuotaLease(name, jobName string, vcpus, pools int, held bool) *v1.Lease {
+	lease := &v1.Lease{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			Labels:    map[string]string{JobNameLabel: jobName},
+		},
+		Spec: v1.LeaseSpec{
+			VCpus:       vcpus,
+			Memory:      vcpus * 4,
+			Pools:       pools,
+			Networks:    1,
+			NetworkType: v1.NetworkTypeSingleTenant,
+		},
+		Status: v1.LeaseStatus{Phase: v1.PHASE_PENDING},
+	}
+	if held {
+		lease.Status.Phase = v1.PHASE_FULFILLED
+		for i := 0; i < pools; i++ {
+			lease.OwnerReferences = append(lease.OwnerReferences,
+				metav1.OwnerReference{Kind: "Pool", Name: name + "-pool"},
+				metav1.OwnerReference{Kind: "Network", Name: name + "-network"},
+			)
+		}
+	}
+	return lease
+}
+
+func TestLeaseMatchesQuota(t *testing.T) {
+	lease := testQuotaLease("lease", "periodic-ci-e2e-vsphere", 24, 1, false)
+	lease.Labels["team"] = "splat"
+
+	tests := []struct {
+		name     string
+		quota    v1.LeaseQuota
+		expected bool
+	}{
+		{
+			name:     "namespace quota",
+			quota:    v1.LeaseQuota{ObjectMeta: metav1.ObjectMeta{Name: "q", Namespace: "default"}},
+			expected: true,
+		},
+		{
+			name:     "other namespace",
+			quota:    v1.LeaseQuota{ObjectMeta: metav1.ObjectMeta{Name: "q", Namespace: "other"}},
+			expected: false,
+		},
+		{
+			name: "job name prefix matches",
+			quota: v1.LeaseQuota{
+				ObjectMeta: metav1.ObjectMeta{Name: "q", Namespace: "default"},
+				Spec:       v1.LeaseQuotaSpec{JobNamePrefix: "periodic-"},
+			},
+			expected: true,
+		},
+		{
+			name: "job name prefix does not match",
+			quota: v1.LeaseQuota{
+				ObjectMeta: metav1.ObjectMeta{Name: "q", Namespace: "default"},
+				Spec:       v1.LeaseQuotaSpec{JobNamePrefix: "pull-"},
+			},
+			expected: false,
+		},
+		{
+			name: "selector matches",
+			quota: v1.LeaseQuota{
+				ObjectMeta: metav1.ObjectMeta{Name: "q", Namespace: "default"},
+				Spec: v1.LeaseQuotaSpec{
+					Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "splat"}},
+				},
+			},
+			expected: true,
+		},
+		{
+			name: "invalid selector matches nothing",
+			quota: v1.LeaseQuota{
+				ObjectMeta: metav1.ObjectMeta{Name: "q", Namespace: "default"},
+				Spec: v1.LeaseQuotaSpec{
+					Selector: &metav1.LabelSelector{
+						MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "team", Operator: "Sometimes"}},
+					},
+				},
+			},
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := leaseMatchesQuota(lease, &tt.quota); result != tt.expected {
+				t.Errorf("leaseMatchesQuota() = %v, expected %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLeaseQuotaUsage(t *testing.T) {
+	restore := setupTestLeases(map[string]*v1.Lease{
+		"default/single":  testQuotaLease("single", "periodic-a", 24, 1, true),
+		"default/multi":   testQuotaLease("multi", "periodic-b", 8, 2, true),
+		"default/pending": testQuotaLease("pending", "periodic-c", 24, 1, false),
+		"default/other":   testQuotaLease("other", "pull-a", 24, 1, true),
+	})
+	defer restore()
+
+	quota := &v1.LeaseQuota{
+		ObjectMeta: metav1.ObjectMeta{Name: "periodics", Namespace: "default"},
+		Spec:       v1.LeaseQuotaSpec{JobNamePrefix: "periodic-"},
+	}
+
+	used := leaseQuotaUsage(quota)
+	if used.VCpus != 40 {
+		t.Errorf("expected 40 vCPUs used, got %d", used.VCpus)
+	}
+	if used.Memory != 160 {
+		t.Errorf("expected 160 GB memory used, got %d", used.Memory)
+	}
+	if used.Leases != 2 {
+		t.Errorf("expected 2 leases, got %d", used.Leases)
+	}
+	if used.Networks[string(v1.NetworkTypeSingleTenant)] != 3 {
+		t.Errorf("expected 3 single-tenant networks, got %v", used.Networks)
+	}
+}
+
+func TestLeaseExceedsQuota(t *testing.T) {
+	restoreLeases := setupTestLeases(map[string]*v1.Lease{
+		"default/held": testQuotaLease("held", "periodic-a", 24, 1, true),
+	})
+	defer restoreLeases()
+
+	tests := []struct {
+		name     string
+		hard     v1.LeaseQuotaLimits
+		lease    *v1.Lease
+		resource string
+	}{
+		{
+			name:  "no limits",
+			lease: testQuotaLease("lease", "periodic-b", 24, 1, false),
+		},
+		{
+			name:  "fits under the vcpu limit",
+			hard:  v1.LeaseQuotaLimits{VCpus: intPtr(48)},
+			lease: testQuotaLease("lease", "periodic-b", 24, 1, false),
+		},
+		{
+			name:     "multi-pool lease exceeds the vcpu limit",
+			hard:     v1.LeaseQuotaLimits{VCpus: intPtr(48)},
+			lease:    testQuotaLease("lease", "periodic-b", 24, 2, false),
+			resource: "vcpus",
+		},
+		{
+			name:     "lease count limit",
+			hard:     v1.LeaseQuotaLimits{Leases: intPtr(1)},
+			lease:    testQuotaLease("lease", "periodic-b", 24, 1, false),
+			resource: "leases",
+		},
+		{
+			name:     "network limit for the lease's network type",
+			hard:     v1.LeaseQuotaLimits{Networks: map[string]int{string(v1.NetworkTypeSingleTenant): 1}},
+			lease:    testQuotaLease("lease", "periodic-b", 24, 1, false),
+			resource: "networks/single-tenant",
+		},
+		{
+			name:  "network limit for another network type",
+			hard:  v1.LeaseQuotaLimits{Networks: map[string]int{string(v1.NetworkTypeMultiTenant): 0}},
+			lease: testQuotaLease("lease", "periodic-b", 24, 1, false),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			restore := setupTestLeaseQuotas(&v1.LeaseQuota{
+				ObjectMeta: metav1.ObjectMeta{Name: "quota", Namespace: "default"},
+				Spec:       v1.LeaseQuotaSpec{Hard: tt.hard},
+			})
+			defer restore()
+
+			msg := leaseExceedsQuota(tt.lease)
+			if tt.resource == "" && msg != "" {
+				t.Errorf("expected the lease to fit, got %q", msg)
+			}
+			if tt.resource != "" && !strings.Contains(msg, "exceeded for "+tt.resource+":") {
+				t.Errorf("expected %s to be exceeded, got %q", tt.resource, msg)
+			}
+		})
+	}
+}
+
+func TestShouldLeaseBeDelayed_QuotaExceeded(t *testing.T) {
+	now := time.Now()
+	overQuota := testPriorityLease("over-quota", "", now.Add(-time.Hour), v1.PHASE_PENDING)
+	conditions.Set(overQuota, conditions.TrueCondition(v1.LeaseConditionTypeQuotaExceeded))
+	lease := testPriorityLease("lease", "", now, v1.PHASE_PENDING)
+
+	restore := setupTestLeases(map[string]*v1.Lease{
+		"default/over-quota": overQuota,
+		"default/lease":      lease,
+	})
+	defer restore()
+
+	if shouldLeaseBeDelayed(lease) {
+		t.Errorf("expected a lease to not wait behind an older lease that is over quota")
+	}
+}
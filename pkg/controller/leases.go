@@ -2,12 +2,11 @@ package controller
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"math/rand/v2"
 	"path"
-	"sort"
-	"strconv"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -21,6 +20,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	v1 "github.com/openshift-splat-team/vsphere-capacity-manager/pkg/apis/vspherecapacitymanager.splat.io/v1"
+	"github.com/openshift-splat-team/vsphere-capacity-manager/pkg/scheduler"
 	"github.com/openshift-splat-team/vsphere-capacity-manager/pkg/utils"
 	"github.com/openshift-splat-team/vsphere-capacity-manager/pkg/utils/conditions"
 )
@@ -79,6 +79,10 @@ type LeaseReconciler struct {
 
 	// Option to allow multi-tenant lease to use single-tenant networks
 	AllowMultiToUseSingle bool
+
+	// Scheduler filters and ranks candidate pools for leases. When nil, a framework with
+	// the built-in plugins and profiles is used.
+	Scheduler *scheduler.Framework
 }
 
 func (l *LeaseReconciler) SetupWithManager(mgr ctrl.Manager) error {
@@ -93,6 +97,7 @@ func (l *LeaseReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	l.Scheme = mgr.GetScheme()
 	l.Recorder = mgr.GetEventRecorderFor("leases-controller")
 	l.RESTMapper = mgr.GetRESTMapper()
+	l.getScheduler()
 
 	leases = make(map[string]*v1.Lease)
 	pools = make(map[string]*v1.Pool)
@@ -100,14 +105,26 @@ func (l *LeaseReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return nil
 }
 
-// getNetworksForPool get all networks for the provided pool.
+// getNetworksForPool get all networks for the provided pool. When the pool lists networkRefs, those
+// networks belong to the pool. Otherwise a network belongs to the pool when its port group is the
+// last element of a topology network path and it is in the pod of the pool.
 func getNetworksForPool(pool *v1.Pool) map[string]*v1.Network {
 	networksInPool := make(map[string]*v1.Network)
+	if len(pool.Spec.NetworkRefs) > 0 {
+		for _, networkName := range pool.Spec.NetworkRefs {
+			if network, exists := networks[fmt.Sprintf("%s/%s", pool.Namespace, networkName)]; exists {
+				networksInPool[network.Name] = network
+			}
+		}
+		return networksInPool
+	}
+
 	for _, portGroupPath := range pool.Spec.Topology.Networks {
 		_, networkName := path.Split(portGroupPath)
 
 		for _, network := range networks {
-			if (*network.Spec.PodName == pool.Spec.IBMPoolSpec.Pod) &&
+			if network.Spec.PodName != nil &&
+				(*network.Spec.PodName == pool.Spec.IBMPoolSpec.Pod) &&
 				(network.Spec.PortGroupName == networkName) {
 				networksInPool[network.Name] = network
 				break
@@ -147,30 +164,95 @@ func poolMissingNetworks(lease *v1.Lease, assignedPools []*v1.Pool) (string, boo
 	return "", false
 }
 
-// getAvailableNetworks retrieves networks which are not owned by a lease
-func (l *LeaseReconciler) getAvailableNetworks(pool *v1.Pool, networkType v1.NetworkType) []*v1.Network {
-	networksInPool := getNetworksForPool(pool)
-	availableNetworks := make([]*v1.Network, 0)
+// setLeaseNetworkStatus populates the pool info, status.topology.networks, IP allocations and env
+// vars of the lease from the networks it owns on each assigned pool. Network paths in
+// status.topology.networks use the datacenter of the first pool.
+func setLeaseNetworkStatus(lease *v1.Lease, assignedPools []*v1.Pool) {
+	setLeaseIPAllocations(lease)
 
-	for _, network := range networksInPool {
-		hasOwner := false
-		for _, lease := range leases {
-			for _, ownerRef := range lease.OwnerReferences {
-				if ownerRef.Name == network.Name &&
-					ownerRef.Kind == network.Kind {
-					hasOwner = true
-					break
+	// Populate poolInfo array with FailureDomainSpec from each assigned pool
+	lease.Status.PoolInfo = make([]v1.FailureDomainSpec, 0, len(assignedPools))
+	for _, poolItem := range assignedPools {
+		// Get networks available in this pool
+		poolNetworksMap := getNetworksForPool(poolItem)
+
+		// Find networks assigned to this lease for this specific pool
+		assignedNetworks := []string{}
+		var networkForEnvVars *v1.Network
+		for _, ownerRef := range lease.OwnerReferences {
+			if ownerRef.Kind == "Network" {
+				if net, exists := poolNetworksMap[ownerRef.Name]; exists {
+					// This network belongs to this pool and is assigned to the lease
+					networkPath := fmt.Sprintf("/%s/network/%s", poolItem.Spec.Topology.Datacenter, net.Spec.PortGroupName)
+					assignedNetworks = append(assignedNetworks, networkPath)
+					if networkForEnvVars == nil {
+						networkForEnvVars = net
+					}
 				}
 			}
-			if hasOwner {
-				break
+		}
+
+		// Create a copy of the FailureDomainSpec from the pool
+		poolFailureDomain := poolItem.Spec.FailureDomainSpec
+		// Update the topology with only assigned networks
+		poolFailureDomain.Topology.Networks = assignedNetworks
+
+		lease.Status.PoolInfo = append(lease.Status.PoolInfo, poolFailureDomain)
+		log.Printf("Added pool info for pool %s to lease %s with %d networks", poolItem.Name, lease.Name, len(assignedNetworks))
+
+		// Generate env vars for this pool from the first network assigned to it
+		if networkForEnvVars != nil {
+			log.Printf("Generating env vars for lease %v with pool %v and network %v", lease.Name, poolItem.Name, networkForEnvVars.Name)
+			if err := utils.GenerateEnvVars(lease, poolItem, networkForEnvVars); err != nil {
+				log.Printf("error generating env vars: %v", err)
+			}
+		}
+	}
+
+	// Build the status.topology.networks list (using first pool's datacenter for the path)
+	var allNetworks []string
+	for _, ownerRef := range lease.OwnerReferences {
+		if ownerRef.Kind == "Network" {
+			for _, net := range networks {
+				if net.Name == ownerRef.Name {
+					// Only add each unique port group once (even if multiple pools use same VLAN)
+					networkPath := fmt.Sprintf("/%s/network/%s", assignedPools[0].Spec.Topology.Datacenter, net.Spec.PortGroupName)
+					// Check if already added
+					alreadyAdded := false
+					for _, existing := range allNetworks {
+						if existing == networkPath {
+							alreadyAdded = true
+							break
+						}
+					}
+					if !alreadyAdded {
+						allNetworks = append(allNetworks, networkPath)
+					}
+					break
+				}
 			}
 		}
+	}
 
+	lease.Status.Topology.Networks = allNetworks
+}
+
+// getAvailableNetworks retrieves networks which have room for another tenant, are not held by an active
+// reservation, carry the IP families of the lease and have enough free addresses for the IP addresses
+// requested by the lease
+func (l *LeaseReconciler) getAvailableNetworks(lease *v1.Lease, pool *v1.Pool, networkType v1.NetworkType) []*v1.Network {
+	networksInPool := getNetworksForPool(pool)
+	availableNetworks := make([]*v1.Network, 0)
+	reservedNetworks := heldNetworks(time.Now())
+
+	for _, network := range networksInPool {
 		if getNetworkType(network) != string(networkType) {
 			continue
 		}
-		if !hasOwner {
+		if _, reserved := reservedNetworks[network.Name]; reserved {
+			continue
+		}
+		if networkAcceptsLease(lease, network) && networkHasAddressesFor(lease, network) {
 			availableNetworks = append(availableNetworks, network)
 		}
 	}
@@ -191,47 +273,61 @@ func getIBMDatacenterAndPod(server string) (string, string) {
 func reconcilePoolStates() []*v1.Pool {
 	var outList []*v1.Pool
 
-	networksInUse := make(map[string]map[string]string)
+	// leases holding each port group, by datacenter and pod
+	networksInUse := make(map[string]map[string]map[string]bool)
+	reservedNetworks := make(map[string]int)
+	now := time.Now()
 
 	for poolName, pool := range pools {
 		vcpus := 0
 		memory := 0
+		storage := 0
 		leaseCount := 0
+		extendedResources := make(map[string]int)
 
 		for _, lease := range leases {
 			for _, ownerRef := range lease.OwnerReferences {
 				if ownerRef.Kind == pool.Kind && ownerRef.Name == pool.Name {
-					vcpus += lease.Spec.VCpus
-					memory += lease.Spec.Memory
+					allocated := leaseAllocatedResources(lease)
+					vcpus += allocated.VCpus
+					memory += allocated.Memory
+					storage += allocated.Storage
+					for name, amount := range allocated.ExtendedResources {
+						extendedResources[name] += amount
+					}
 					leaseCount++
 
-					var serverNetworks map[string]string
+					var serverNetworks map[string]map[string]bool
 					var exists bool
 
 					dc, pod := getIBMDatacenterAndPod(lease.Status.Server)
 					dcId := fmt.Sprintf("dcid-%s-%s", dc, pod)
 					if serverNetworks, exists = networksInUse[dcId]; !exists {
-						serverNetworks = make(map[string]string)
+						serverNetworks = make(map[string]map[string]bool)
 						networksInUse[dcId] = serverNetworks
 					}
 
 					for _, networkPath := range lease.Status.Topology.Networks {
 						_, networkName := path.Split(networkPath)
-						serverNetworks[networkName] = networkName
+						if serverNetworks[networkName] == nil {
+							serverNetworks[networkName] = make(map[string]bool)
+						}
+						serverNetworks[networkName][lease.Namespace+"/"+lease.Name] = true
 					}
 					break
 				}
 			}
 		}
 
-		overCommitRatio, err := strconv.ParseFloat(pool.Spec.OverCommitRatio, 32)
-		if err != nil {
-			log.Printf("error converting overCommitRatio to float %v setting to 1.0", err)
-			overCommitRatio = 1.0
-		}
+		// active reservations hold the capacity their leases have not consumed yet
+		reservedVCpus, reservedMemory, reservedNetworkCount := reservationHolds(pool, now)
+		reservedNetworks[pool.Name] = reservedNetworkCount
 
-		pool.Status.VCpusAvailable = int(float64(pool.Spec.VCpus)*overCommitRatio) - vcpus
-		pool.Status.MemoryAvailable = pool.Spec.Memory - memory
+		vcpusCapacity, memoryCapacity, storageCapacity := utils.PoolCapacity(pool)
+		pool.Status.VCpusAvailable = vcpusCapacity - vcpus - reservedVCpus
+		pool.Status.MemoryAvailable = memoryCapacity - memory - reservedMemory
+		pool.Status.DatastoreAvailable = storageCapacity - storage
+		pool.Status.ExtendedResourcesAvailable = extendedResourcesAvailable(pool, extendedResources)
 		pool.Status.LeaseCount = leaseCount
 
 		pools[poolName] = pool
@@ -239,21 +335,39 @@ func reconcilePoolStates() []*v1.Pool {
 	}
 
 	for _, pool := range outList {
+		// only networks bound to the pool are counted, topology paths without a network are not
 		availableNetworks := 0
-		for _, network := range pool.Spec.Topology.Networks {
-			_, networkName := path.Split(network)
-			dcId := fmt.Sprintf("dcid-%s-%s", pool.Spec.IBMPoolSpec.Datacenter, pool.Spec.IBMPoolSpec.Pod)
-			serverNetworks := networksInUse[dcId]
-			if _, ok := serverNetworks[networkName]; !ok {
+		dcId := fmt.Sprintf("dcid-%s-%s", pool.Spec.IBMPoolSpec.Datacenter, pool.Spec.IBMPoolSpec.Pod)
+		serverNetworks := networksInUse[dcId]
+		for _, network := range getNetworksForPool(pool) {
+			// shared networks are available until every tenant slot is taken
+			if len(serverNetworks[network.Spec.PortGroupName]) < networkMaxTenants(network) {
 				availableNetworks++
 			}
 		}
-		pool.Status.NetworkAvailable = availableNetworks
+		pool.Status.NetworkAvailable = availableNetworks - reservedNetworks[pool.Name]
 	}
 
 	return outList
 }
 
+// extendedResourcesAvailable returns how much of each extended resource of the pool is left once
+// the amounts used by its leases are taken out. Resources leases use that the pool no longer
+// offers are reported as overcommitted.
+func extendedResourcesAvailable(pool *v1.Pool, used map[string]int) map[string]int {
+	if len(pool.Spec.ExtendedResources) == 0 && len(used) == 0 {
+		return nil
+	}
+	available := make(map[string]int, len(pool.Spec.ExtendedResources))
+	for name, capacity := range pool.Spec.ExtendedResources {
+		available[name] = capacity
+	}
+	for name, amount := range used {
+		available[name] -= amount
+	}
+	return available
+}
+
 func (l *LeaseReconciler) triggerPoolUpdates(ctx context.Context) {
 	for _, pool := range pools {
 
@@ -275,8 +389,11 @@ func (l *LeaseReconciler) triggerPoolUpdates(ctx context.Context) {
 	}
 }
 
+// triggerLeaseUpdates forces a reconcile of the Pending or Partial lease of the network type that
+// has precedence over all others: the highest priority lease, oldest first among equal priorities.
 func (l *LeaseReconciler) triggerLeaseUpdates(ctx context.Context, networkType v1.NetworkType) {
-	var oldestLease *v1.Lease
+	var nextLease *v1.Lease
+	now := time.Now()
 	for _, lease := range leases {
 		// If networkType doesn't match desired, then skip
 		if lease.Spec.NetworkType != networkType {
@@ -284,7 +401,7 @@ func (l *LeaseReconciler) triggerLeaseUpdates(ctx context.Context, networkType v
 		}
 
 		// We only want to force an update for leases that are Pending or Partial
-		if lease.Status.Phase == v1.PHASE_FULFILLED || lease.Status.Phase == v1.PHASE_FAILED {
+		if lease.Status.Phase == v1.PHASE_FULFILLED || lease.Status.Phase == v1.PHASE_FAILED || lease.Status.Phase == v1.PHASE_SCHEDULED {
 			continue
 		}
 
@@ -294,23 +411,26 @@ func (l *LeaseReconciler) triggerLeaseUpdates(ctx context.Context, networkType v
 			continue
 		}
 
-		// If lease creation time is older than oldestLease, make current lease the oldestLease
-		if oldestLease == nil || lease.CreationTimestamp.Before(&oldestLease.CreationTimestamp) {
-			oldestLease = lease
+		if conditions.IsTrue(lease, v1.LeaseConditionTypeQuotaExceeded) {
+			continue
+		}
+
+		if nextLease == nil || leaseHasPrecedence(lease, nextLease, now) {
+			nextLease = lease
 		}
 
 	}
 
-	if oldestLease != nil {
-		if oldestLease.Annotations == nil {
-			oldestLease.Annotations = make(map[string]string)
+	if nextLease != nil {
+		if nextLease.Annotations == nil {
+			nextLease.Annotations = make(map[string]string)
 		}
 
-		log.Printf("triggering lease update %v", oldestLease.Name)
-		oldestLease.Annotations["last-updated"] = time.Now().Format(time.RFC3339)
-		err := l.Client.Update(ctx, oldestLease)
+		log.Printf("triggering lease update %v", nextLease.Name)
+		nextLease.Annotations["last-updated"] = now.Format(time.RFC3339)
+		err := l.Client.Update(ctx, nextLease)
 		if err != nil {
-			log.Printf("error updating lease %s annotations: %v", oldestLease.Name, err)
+			log.Printf("error updating lease %s annotations: %v", nextLease.Name, err)
 		}
 	}
 }
@@ -342,12 +462,14 @@ func updateLeaseMetrics() {
 	}
 
 	updateNetworkTypeMetrics()
+	updateLeaseQueueMetrics()
 }
 
 func updateNetworkTypeMetrics() {
 	PoolNetworksAvailableByType.Reset()
 	PoolNetworksTotalByType.Reset()
 	NetworkLeaseCount.Reset()
+	NetworkMaxTenants.Reset()
 
 	networkLeaseCount := make(map[string]float64)
 	for _, lease := range leases {
@@ -368,16 +490,19 @@ func updateNetworkTypeMetrics() {
 			totalByType[netType]++
 
 			count := networkLeaseCount[network.Name]
-			if count == 0 {
+			maxTenants := float64(networkMaxTenants(network))
+			if count < maxTenants {
 				availByType[netType]++
 			}
 
-			NetworkLeaseCount.With(prometheus.Labels{
+			networkLabels := prometheus.Labels{
 				"namespace":   pool.Namespace,
 				"network":     network.Name,
 				"networkType": netType,
 				"pool":        pool.Name,
-			}).Set(count)
+			}
+			NetworkLeaseCount.With(networkLabels).Set(count)
+			NetworkMaxTenants.With(networkLabels).Set(maxTenants)
 		}
 
 		for netType, total := range totalByType {
@@ -450,7 +575,49 @@ func failLeaseIfUnsatisfiable(lease *v1.Lease, allPools []*v1.Pool) bool {
 	}
 
 	log.Printf("lease %s is not satisfiable: %s", lease.Name, reason)
+	failLease(lease, v1.ReasonLeaseUnschedulable, reason)
+	return true
+}
+
+// failLeaseIfPastDeadline fails the lease when spec.notAfter passed before it was fulfilled.
+// Like failLeaseIfUnsatisfiable, the caller persists the lease.
+func failLeaseIfPastDeadline(lease *v1.Lease, now time.Time) bool {
+	if lease.Spec.NotAfter == nil || now.Before(lease.Spec.NotAfter.Time) {
+		return false
+	}
+
+	message := fmt.Sprintf("lease was not fulfilled before its deadline %s", lease.Spec.NotAfter.UTC().Format(time.RFC3339))
+	log.Printf("lease %s failed: %s", lease.Name, message)
+	failLease(lease, v1.ReasonDeadlineExceeded, message)
+	return true
+}
+
+// failLeaseIfUnknownProfile fails the lease when it requests a scheduling profile that is not
+// registered with the scheduler. Like failLeaseIfUnsatisfiable, the caller persists the lease.
+func (l *LeaseReconciler) failLeaseIfUnknownProfile(lease *v1.Lease) bool {
+	if _, err := l.getScheduler().Profile(lease); err != nil {
+		log.Printf("lease %s can not be scheduled: %v", lease.Name, err)
+		failLease(lease, v1.ReasonUnknownProfile, err.Error())
+		return true
+	}
+	return false
+}
+
+// recordUnschedulableReasons stores why the pools were rejected in a failed scheduling attempt
+// in the lease status. Errors without per-pool results, such as a failed multi-pool search
+// over pools that all passed the filters, record the attempt with no pools.
+func recordUnschedulableReasons(lease *v1.Lease, err error) {
+	var unschedulable *scheduler.UnschedulableError
+	var results []*utils.PoolFittingInfo
+	if errors.As(err, &unschedulable) {
+		results = unschedulable.Results
+	}
+	lease.Status.UnschedulableReasons = utils.NewUnschedulableReasons(results, metav1.Now())
+}
 
+// failLease releases the pools and networks held by the lease and moves it into the terminal
+// Failed state with the given reason.
+func failLease(lease *v1.Lease, reason, message string) {
 	newOwnerRefs := []metav1.OwnerReference{}
 	for _, ref := range lease.OwnerReferences {
 		if ref.Kind != "Pool" && ref.Kind != "Network" {
@@ -460,25 +627,280 @@ func failLeaseIfUnsatisfiable(lease *v1.Lease, allPools []*v1.Pool) bool {
 	lease.OwnerReferences = newOwnerRefs
 	lease.Status.PoolInfo = nil
 	lease.Status.EnvVarsMap = nil
+	lease.Status.IPAllocations = nil
 	// status.topology.networks is a required, non-empty field on the CRD; it can't be
 	// cleared outright, so reset it to the same placeholder used before any pool is assigned.
 	lease.Status.Topology.Networks = []string{"/pending/network/pending"}
 	lease.Status.Phase = v1.PHASE_FAILED
+	clearLeaseQueueStatus(lease)
 
 	conditions.Set(lease, conditions.FalseConditionWithReason(
-		v1.LeaseConditionTypeFulfilled, v1.ReasonLeaseUnschedulable, v1.ConditionSeverityError, reason))
+		v1.LeaseConditionTypeFulfilled, reason, v1.ConditionSeverityError, message))
 	conditions.Set(lease, conditions.FalseCondition(v1.LeaseConditionTypePending))
 	conditions.Set(lease, conditions.FalseCondition(v1.LeaseConditionTypePartial))
 	conditions.Set(lease, conditions.FalseCondition(v1.LeaseConditionTypeDelayed))
+}
+
+// reconcileFulfilledLease applies spec changes to a fulfilled lease and expires it once its TTL
+// passes. The lease is requeued for whichever is due first.
+func (l *LeaseReconciler) reconcileFulfilledLease(ctx context.Context, lease *v1.Lease) (ctrl.Result, error) {
+	var result ctrl.Result
+	if leaseResizeRequested(lease) {
+		var err error
+		if result, err = l.resizeLease(ctx, lease); err != nil {
+			return result, err
+		}
+	}
+
+	if lease.Spec.TTL == nil {
+		return result, nil
+	}
+	expiryResult, err := l.reconcileLeaseExpiry(ctx, lease)
+	if err != nil || result.RequeueAfter == 0 || (expiryResult.RequeueAfter > 0 && expiryResult.RequeueAfter < result.RequeueAfter) {
+		return expiryResult, err
+	}
+	return result, nil
+}
+
+// persistFailedLease saves a lease moved into the Failed state by failLease, then lets the
+// leases waiting for the resources it released try again.
+func (l *LeaseReconciler) persistFailedLease(ctx context.Context, lease *v1.Lease) error {
+	leaseStatus := lease.Status.DeepCopy()
+	if err := l.Client.Update(ctx, lease); err != nil {
+		return fmt.Errorf("error releasing owner refs for failed lease: %w", err)
+	}
+	// l.Client.Update overwrites lease in place with the API server's response, which
+	// carries the pre-update (non-Failed) status since status is a subresource. Restore
+	// the Failed status computed by failLease before persisting it below.
+	leaseStatus.DeepCopyInto(&lease.Status)
+	LeaseTransitionsTotal.With(prometheus.Labels{
+		"namespace":   lease.Namespace,
+		"networkType": string(lease.Spec.NetworkType),
+		"phase":       string(v1.PHASE_FAILED),
+	}).Inc()
+	if err := l.Client.Status().Update(ctx, lease); err != nil {
+		return fmt.Errorf("error updating lease status to Failed: %w", err)
+	}
+	l.triggerPoolUpdates(ctx)
+	l.triggerQuotaUpdates(ctx)
+	l.triggerReservationUpdates(ctx)
+	l.triggerNetworkUpdates(ctx)
+	l.triggerLeaseUpdates(ctx, lease.Spec.NetworkType)
+	updateLeaseMetrics()
+	return nil
+}
+
+// getScheduler returns the scheduling framework, creating one with the built-in plugins and
+// profiles if none was configured.
+func (l *LeaseReconciler) getScheduler() *scheduler.Framework {
+	if l.Scheduler == nil {
+		l.Scheduler = scheduler.New()
+	}
+	return l.Scheduler
+}
+
+// getPlacedLeases returns the other leases in the namespace of lease that hold pools, as used by
+// the LeaseAffinity scheduler plugin.
+func getPlacedLeases(lease *v1.Lease) []scheduler.PlacedLease {
+	var placed []scheduler.PlacedLease
+	for _, other := range leases {
+		if other.Namespace != lease.Namespace || other.Name == lease.Name {
+			continue
+		}
+		var otherPools []*v1.Pool
+		for _, poolRef := range utils.GetLeasePoolRefs(other) {
+			if pool, exists := pools[fmt.Sprintf("%s/%s", other.Namespace, poolRef.Name)]; exists {
+				otherPools = append(otherPools, pool)
+			}
+		}
+		if len(otherPools) > 0 {
+			placed = append(placed, scheduler.PlacedLease{Lease: other, Pools: otherPools})
+		}
+	}
+	return placed
+}
+
+// leaseHasCompleteGang reports whether every pool of a multi-pool lease is assigned and has the
+// required number of networks.
+func leaseHasCompleteGang(lease *v1.Lease, requiredPools int) bool {
+	poolRefs := utils.GetLeasePoolRefs(lease)
+	if len(poolRefs) != requiredPools {
+		return false
+	}
+	for _, poolRef := range poolRefs {
+		pool, exists := pools[fmt.Sprintf("%s/%s", lease.Namespace, poolRef.Name)]
+		if !exists {
+			return false
+		}
+		poolNetworksMap := getNetworksForPool(pool)
+		count := 0
+		for _, ownerRef := range lease.OwnerReferences {
+			if _, exists := poolNetworksMap[ownerRef.Name]; exists && ownerRef.Kind == "Network" {
+				count++
+			}
+		}
+		if count < lease.Spec.Networks {
+			return false
+		}
+	}
 	return true
 }
 
+func leaseOwnsNetwork(lease *v1.Lease, network *v1.Network) bool {
+	for _, ownerRef := range lease.OwnerReferences {
+		if ownerRef.Kind == "Network" && ownerRef.Name == network.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// countLeaseBackfill counts a lease whose pools were committed ahead of the leases it waits for.
+func countLeaseBackfill(lease *v1.Lease) {
+	LeaseBackfillsTotal.With(prometheus.Labels{
+		"namespace":   lease.Namespace,
+		"networkType": string(lease.Spec.NetworkType),
+	}).Inc()
+}
+
+// allocateGang assigns all pools and networks of a multi-pool lease at once. The scheduler ranks
+// the pools able to host the lease and scheduler.SolveGang picks a combination that respects the
+// vCenters cap and shares VLANs across every pool. Owner references are only added when the whole
+// set is available; otherwise the lease holds nothing and is left Pending. Leases left Partial by
+// an earlier allocation are released and placed again. Returns true when the lease holds a
+// complete assignment. When false is returned, the lease has been persisted and the caller should
+// requeue.
+func (l *LeaseReconciler) allocateGang(ctx context.Context, state *scheduler.CycleState, lease *v1.Lease, requiredPools int) (bool, error) {
+	if leaseHasCompleteGang(lease, requiredPools) {
+		return true, nil
+	}
+
+	released := false
+	newOwnerRefs := []metav1.OwnerReference{}
+	for _, ref := range lease.OwnerReferences {
+		if ref.Kind != "Pool" && ref.Kind != "Network" {
+			newOwnerRefs = append(newOwnerRefs, ref)
+		} else {
+			released = true
+		}
+	}
+	if released {
+		log.Printf("lease %s holds an incomplete assignment, releasing it before gang allocation", lease.Name)
+		lease.OwnerReferences = newOwnerRefs
+	}
+
+	// pool states must not count the assignment released above
+	availablePools := poolsForLease(lease, reconcilePoolStates())
+	candidates, err := l.getScheduler().Schedule(state, lease, availablePools)
+
+	var assignment *scheduler.GangAssignment
+	if err == nil {
+		commonNetworks, commonErr := l.getCommonNetworksForLease(lease)
+		if commonErr != nil {
+			commonNetworks = nil
+		}
+
+		poolNetworks := make(map[string][]*v1.Network)
+		for _, pool := range candidates {
+			poolNetworksMap := getNetworksForPool(pool)
+			for _, network := range commonNetworks {
+				if _, exists := poolNetworksMap[network.Name]; exists && networkHasAddressesFor(lease, network) {
+					poolNetworks[pool.Name] = append(poolNetworks[pool.Name], network)
+				}
+			}
+			poolNetworks[pool.Name] = append(poolNetworks[pool.Name], l.getReservedNetworks(lease, pool)...)
+			poolNetworks[pool.Name] = append(poolNetworks[pool.Name], l.getAvailableNetworks(lease, pool, lease.Spec.NetworkType)...)
+
+			// We can allow multi-tenant leases to use single-tenant networks if there are not enough multi-tenant leases.
+			if l.AllowMultiToUseSingle && lease.Spec.NetworkType == v1.NetworkTypeMultiTenant {
+				poolNetworks[pool.Name] = append(poolNetworks[pool.Name], l.getAvailableNetworks(lease, pool, v1.NetworkTypeSingleTenant)...)
+			}
+		}
+
+		assignment, err = scheduler.SolveGang(&scheduler.GangRequest{
+			Pools:             requiredPools,
+			VCenters:          lease.Spec.VCenters,
+			Networks:          lease.Spec.Networks,
+			Candidates:        candidates,
+			PoolNetworks:      poolNetworks,
+			PreferredNetworks: commonNetworks,
+			Spread:            lease.Spec.TopologySpreadConstraints,
+		})
+	}
+
+	if err == nil {
+		for _, pool := range assignment.Pools {
+			utils.AddPoolOwnerReference(lease, pool)
+			for _, network := range assignment.Networks[pool.Name] {
+				// pools in the same pod share networks, only reference them once
+				if !leaseOwnsNetwork(lease, network) {
+					lease.OwnerReferences = append(lease.OwnerReferences, metav1.OwnerReference{
+						APIVersion: network.APIVersion,
+						Kind:       network.Kind,
+						Name:       network.Name,
+						UID:        network.UID,
+					})
+				}
+			}
+			log.Printf("assigned pool %s with networks on VLANs %v to lease %s", pool.Name, assignment.VLANs, lease.Name)
+		}
+		return true, nil
+	}
+
+	log.Printf("unable to allocate %d pools for lease %s: %v", requiredPools, lease.Name, err)
+	recordUnschedulableReasons(lease, err)
+	if released {
+		lease.Status.PoolInfo = nil
+		lease.Status.EnvVarsMap = nil
+		lease.Status.IPAllocations = nil
+		lease.Status.Topology.Networks = []string{"/pending/network/pending"}
+	}
+	lease.Status.Phase = v1.PHASE_PENDING
+	conditions.Set(lease, conditions.TrueCondition(
+		v1.LeaseConditionTypePending,
+	))
+	conditions.Set(lease, conditions.FalseCondition(
+		v1.LeaseConditionTypePartial,
+	))
+	conditions.Set(lease, conditions.FalseConditionWithReason(
+		v1.LeaseConditionTypeFulfilled,
+		v1.ReasonLeaseNoPool,
+		v1.ConditionSeverityWarning,
+		err.Error(),
+	))
+
+	if released {
+		leaseStatus := lease.Status.DeepCopy()
+		if err := l.Client.Update(ctx, lease); err != nil {
+			return false, fmt.Errorf("error releasing incomplete assignment of lease %s: %w", lease.Name, err)
+		}
+		leaseStatus.DeepCopyInto(&lease.Status)
+	}
+	if err := l.Client.Status().Update(ctx, lease); err != nil {
+		return false, fmt.Errorf("error updating lease status to Pending: %w", err)
+	}
+	return false, nil
+}
+
 // shouldLeaseBeDelayed is used to determine if current lease should be delayed.
 func shouldLeaseBeDelayed(lease *v1.Lease) bool {
+	return len(getBlockingLeases(lease)) > 0
+}
+
+// getBlockingLeases returns the leases the current lease has to wait for.
+func getBlockingLeases(lease *v1.Lease) []*v1.Lease {
+	var blockers []*v1.Lease
 	// Iterate through all leases.  Ignore fulfilled.  If we see Partial, block if needing same pool.  If Pending, we
 	// can only run if there are no other partials that are interested in the same pools as current lease.  If there are
-	// no partials, then we need to make sure we have no other leases that are older.  Oldest should go first.
+	// no partials, then we need to make sure we have no other leases with precedence.  Highest priority goes first,
+	// and the oldest goes first among leases of equal priority.
+	// Leases that fit in the remaining capacity of their reservation use capacity held for them,
+	// so they neither wait for other leases nor block them.
+	if leaseFitsReservation(lease) {
+		return nil
+	}
 	if lease.Status.Phase == v1.PHASE_PENDING {
+		now := time.Now()
 		for _, curLease := range leases {
 
 			// skip if lease is the target lease
@@ -491,6 +913,15 @@ func shouldLeaseBeDelayed(lease *v1.Lease) bool {
 				continue
 			}
 
+			// Leases held back by a quota can not use the pools, so they do not block others.
+			if conditions.IsTrue(curLease, v1.LeaseConditionTypeQuotaExceeded) {
+				continue
+			}
+
+			if leaseFitsReservation(curLease) {
+				continue
+			}
+
 			// If lease is multi network and required pool is blank, then we want to make sure the current assigned pool
 			// is checked instead of desired pool.
 			requiredPool := curLease.Spec.RequiredPool
@@ -505,19 +936,21 @@ func shouldLeaseBeDelayed(lease *v1.Lease) bool {
 				continue
 			case v1.PHASE_FAILED:
 				continue
+			case v1.PHASE_SCHEDULED:
+				// Scheduled leases do not claim pools before their start time.
+				continue
 			case v1.PHASE_PARTIAL:
 				// We want partial to prevent others wanting same pool.
 				if requiredPool == lease.Spec.RequiredPool || lease.Spec.RequiredPool == "" {
-					return true
+					blockers = append(blockers, curLease)
 				}
 			case v1.PHASE_PENDING:
 				// If leases are both from the same pool, give priority to oldest.  If either of them are blank for the
 				// desired pool, they could be assigned to the same pool depending on availability.  So in this case,
 				// compare them as well.
 				if requiredPool == lease.Spec.RequiredPool || requiredPool == "" || lease.Spec.RequiredPool == "" {
-					leaseTime := curLease.CreationTimestamp
-					if leaseTime.Time.Before(lease.CreationTimestamp.Time) {
-						return true
+					if leaseHasPrecedence(curLease, lease, now) {
+						blockers = append(blockers, curLease)
 					}
 				}
 			default:
@@ -525,7 +958,7 @@ func shouldLeaseBeDelayed(lease *v1.Lease) bool {
 			}
 		}
 	}
-	return false
+	return blockers
 }
 
 // doesLeaseContainPortGroup checks to see if the supplied network is part of a portgroup that is already assigned to the lease.
@@ -679,12 +1112,16 @@ func (l *LeaseReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl
 			promLabels["pool"] = ownRef.Name
 		}
 
+		recordLeaseHoldTime(lease, time.Now())
 		delete(leases, leaseKey)
 		if len(promLabels) >= 2 {
 			LeasesInUse.With(promLabels).Dec()
 		}
 		reconcilePoolStates()
 		l.triggerPoolUpdates(ctx)
+		l.triggerQuotaUpdates(ctx)
+		l.triggerReservationUpdates(ctx)
+		l.triggerNetworkUpdates(ctx)
 		l.triggerLeaseUpdates(ctx, lease.Spec.NetworkType)
 		updateLeaseMetrics()
 		return ctrl.Result{}, nil
@@ -694,32 +1131,63 @@ func (l *LeaseReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl
 
 	if lease.Status.Phase == v1.PHASE_FULFILLED || lease.Status.Phase == v1.PHASE_FAILED {
 		log.Print("lease is already fulfilled or failed")
+		if lease.Status.Phase == v1.PHASE_FULFILLED {
+			return l.reconcileFulfilledLease(ctx, lease)
+		}
 		return ctrl.Result{}, nil
 	}
 
 	updatedPools := reconcilePoolStates()
 
-	if failLeaseIfUnsatisfiable(lease, updatedPools) {
-		leaseStatus := lease.Status.DeepCopy()
-		if err := l.Client.Update(ctx, lease); err != nil {
-			return ctrl.Result{}, fmt.Errorf("error releasing owner refs for unschedulable lease: %w", err)
+	if failLeaseIfPastDeadline(lease, time.Now()) || failLeaseIfUnsatisfiable(lease, updatedPools) || l.failLeaseIfUnknownProfile(lease) {
+		return ctrl.Result{}, l.persistFailedLease(ctx, lease)
+	}
+
+	// Leases with a start time wait in the Scheduled phase without claiming pools.
+	if lease.Spec.NotBefore != nil && time.Now().Before(lease.Spec.NotBefore.Time) && len(utils.GetLeasePoolRefs(lease)) == 0 {
+		if lease.Status.Phase != v1.PHASE_SCHEDULED {
+			log.Printf("setting lease %s status to %s", lease.Name, v1.PHASE_SCHEDULED)
+			lease.Status.Phase = v1.PHASE_SCHEDULED
+			LeaseTransitionsTotal.With(prometheus.Labels{
+				"namespace":   lease.Namespace,
+				"networkType": string(lease.Spec.NetworkType),
+				"phase":       string(v1.PHASE_SCHEDULED),
+			}).Inc()
 		}
-		// l.Client.Update overwrites lease in place with the API server's response, which
-		// carries the pre-update (non-Failed) status since status is a subresource. Restore
-		// the Failed status computed by failLeaseIfUnsatisfiable before persisting it below.
-		leaseStatus.DeepCopyInto(&lease.Status)
+
+		conditions.Set(lease, conditions.FalseCondition(
+			v1.LeaseConditionTypePending,
+		))
+		conditions.Set(lease, conditions.FalseConditionWithReason(
+			v1.LeaseConditionTypeFulfilled,
+			v1.ReasonLeaseNotBefore,
+			v1.ConditionSeverityInfo,
+			"lease is scheduled to start at %s",
+			lease.Spec.NotBefore.UTC().Format(time.RFC3339),
+		))
+
+		if err := l.Client.Status().Update(ctx, lease); err != nil {
+			return ctrl.Result{}, fmt.Errorf("error updating lease status to Scheduled: %w", err)
+		}
+
+		updateLeaseMetrics()
+		wait := time.Until(lease.Spec.NotBefore.Time)
+		log.Printf("lease %s is SCHEDULED - requeuing in %v", lease.Name, wait)
+		return ctrl.Result{RequeueAfter: wait}, nil
+	}
+
+	if lease.Status.Phase == v1.PHASE_SCHEDULED {
+		log.Printf("lease %s reached its start time, setting status to %s", lease.Name, v1.PHASE_PENDING)
+		lease.Status.Phase = v1.PHASE_PENDING
 		LeaseTransitionsTotal.With(prometheus.Labels{
 			"namespace":   lease.Namespace,
 			"networkType": string(lease.Spec.NetworkType),
-			"phase":       string(v1.PHASE_FAILED),
+			"phase":       string(v1.PHASE_PENDING),
 		}).Inc()
-		if err := l.Client.Status().Update(ctx, lease); err != nil {
-			return ctrl.Result{}, fmt.Errorf("error updating lease status to Failed: %w", err)
-		}
-		l.triggerPoolUpdates(ctx)
-		l.triggerLeaseUpdates(ctx, lease.Spec.NetworkType)
-		updateLeaseMetrics()
-		return ctrl.Result{}, nil
+
+		conditions.Set(lease, conditions.TrueCondition(
+			v1.LeaseConditionTypePending,
+		))
 	}
 
 	// TODO: How often are we hitting this and can we remove this and just use the one above?
@@ -757,9 +1225,79 @@ func (l *LeaseReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl
 		lease.Spec.NetworkType = v1.NetworkTypeSingleTenant
 	}
 
+	lease.Status.Priority = leasePriority(lease)
+	if len(lease.Spec.PriorityClassName) > 0 {
+		if _, ok := leasePriorityClasses[lease.Spec.PriorityClassName]; !ok {
+			log.Printf("lease %s references unknown priority class %s, using priority 0", lease.Name, lease.Spec.PriorityClassName)
+		}
+	}
+
+	// Leases that would take their team over quota wait without holding anything. They do not delay other
+	// leases, so a team at its quota can not stall the queue for everyone else.
+	if len(utils.GetLeasePoolRefs(lease)) == 0 {
+		if msg := leaseExceedsQuota(lease); msg != "" {
+			log.Printf("lease %s is held back by quota: %s", lease.Name, msg)
+			clearLeaseQueueStatus(lease)
+			conditions.Set(lease, conditions.TrueConditionWithReason(
+				v1.LeaseConditionTypeQuotaExceeded,
+				v1.ReasonQuotaExceeded,
+				"%s",
+				msg,
+			))
+			conditions.Set(lease, conditions.FalseConditionWithReason(
+				v1.LeaseConditionTypeFulfilled,
+				v1.ReasonQuotaExceeded,
+				v1.ConditionSeverityWarning,
+				"%s",
+				msg,
+			))
+
+			if err := l.Client.Status().Update(ctx, lease); err != nil {
+				return reconcile.Result{}, err
+			}
+
+			updateLeaseMetrics()
+			log.Printf("lease %s is over quota - requeuing in %v", lease.Name, LEASE_PENDING_RETRY_INTERVAL)
+			return ctrl.Result{RequeueAfter: LEASE_PENDING_RETRY_INTERVAL}, nil
+		}
+	}
+	conditions.Set(lease, conditions.FalseCondition(
+		v1.LeaseConditionTypeQuotaExceeded,
+	))
+
+	// Leases consuming a reservation wait until it is active, then only see the reservation's pool.
+	if len(lease.Spec.ReservationName) > 0 && len(utils.GetLeasePoolRefs(lease)) == 0 {
+		if _, msg := leaseReservation(lease); msg != "" {
+			log.Printf("lease %s is waiting for its reservation: %s", lease.Name, msg)
+			conditions.Set(lease, conditions.FalseConditionWithReason(
+				v1.LeaseConditionTypeFulfilled,
+				v1.ReasonReservationNotReady,
+				v1.ConditionSeverityInfo,
+				"%s",
+				msg,
+			))
+
+			if err := l.Client.Status().Update(ctx, lease); err != nil {
+				return reconcile.Result{}, err
+			}
+
+			updateLeaseMetrics()
+			log.Printf("lease %s is waiting for reservation %s - requeuing in %v", lease.Name, lease.Spec.ReservationName, LEASE_PENDING_RETRY_INTERVAL)
+			return ctrl.Result{RequeueAfter: LEASE_PENDING_RETRY_INTERVAL}, nil
+		}
+	}
+	updatedPools = poolsForLease(lease, updatedPools)
+
 	// We need to check to see if any other leases are waiting for resources that this lease may want.  We need to
-	// ensure that older leases get to finish getting their requests fulfilled before their Ci jobs timeout.
-	if shouldLeaseBeDelayed(lease) {
+	// ensure that higher priority and older leases get to finish getting their requests fulfilled before their Ci
+	// jobs timeout. A lease may still be backfilled ahead of them when it does not use capacity they need.
+	state := &scheduler.CycleState{PlacedLeases: getPlacedLeases(lease)}
+	blockers := getBlockingLeases(lease)
+	setLeaseQueueStatus(lease, blockers, time.Now())
+	if len(blockers) > 0 {
+		state.Reservations = leaseReservations(blockers, updatedPools)
+	}
+	if len(blockers) > 0 && !l.canBackfill(state, lease, updatedPools) {
 		log.Printf("=========== lease %v is being delayed due to presence of higher priority leases ===========", lease.Name)
 		LeaseDelaysTotal.With(prometheus.Labels{
 			"namespace":   lease.Namespace,
@@ -769,6 +1307,9 @@ func (l *LeaseReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl
 		conditions.Set(lease, conditions.TrueCondition(
 			v1.LeaseConditionTypeDelayed,
 		))
+		conditions.Set(lease, conditions.FalseCondition(
+			v1.LeaseConditionTypeBackfilled,
+		))
 		conditions.Set(lease, conditions.FalseConditionWithReason(
 			v1.LeaseConditionTypeFulfilled,
 			v1.ReasonLeaseDelayed,
@@ -792,6 +1333,14 @@ func (l *LeaseReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl
 	conditions.Set(lease, conditions.FalseCondition(
 		v1.LeaseConditionTypeDelayed,
 	))
+	// A lease placed ahead of the leases it waits for is only marked backfilled once it holds
+	// its pools. Until then, and when the attempt fails, Backfilled stays False.
+	backfill := len(blockers) > 0 && len(utils.GetLeasePoolRefs(lease)) == 0
+	if len(blockers) == 0 || backfill {
+		conditions.Set(lease, conditions.FalseCondition(
+			v1.LeaseConditionTypeBackfilled,
+		))
+	}
 
 	// Determine how many pools are required
 	requiredPools := lease.Spec.Pools
@@ -799,6 +1348,20 @@ func (l *LeaseReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl
 		requiredPools = 1 // default to 1 pool
 	}
 
+	// Multi-pool leases are placed as a whole so that they never hold a subset of their pools
+	// while waiting for the rest. Topology spread constraints are enforced by the same search.
+	if requiredPools > 1 || len(lease.Spec.TopologySpreadConstraints) > 0 {
+		allocated, err := l.allocateGang(ctx, state, lease, requiredPools)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if !allocated {
+			updateLeaseMetrics()
+			log.Printf("lease %s is PENDING, no complete pool assignment available - requeuing in %v", lease.Name, LEASE_PENDING_RETRY_INTERVAL)
+			return ctrl.Result{RequeueAfter: LEASE_PENDING_RETRY_INTERVAL}, nil
+		}
+	}
+
 	// Get all currently assigned pools
 	assignedPoolRefs := utils.GetLeasePoolRefs(lease)
 	assignedPools := make([]*v1.Pool, 0, len(assignedPoolRefs))
@@ -816,7 +1379,7 @@ func (l *LeaseReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl
 		assignedPoolNames[pool.Name] = true
 	}
 
-	// Assign additional pools if needed
+	// Assign a pool to single-pool leases. Multi-pool leases were fully assigned above.
 	log.Printf("Lease %s requires %d pools, currently has %d pools assigned", lease.Name, requiredPools, len(assignedPools))
 	for len(assignedPools) < requiredPools {
 		// Filter out already assigned pools
@@ -827,247 +1390,11 @@ func (l *LeaseReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl
 			}
 		}
 
-		// Enforce the vCenters cap with smart filtering:
-		// 1. If cap reached: only allow vCenters already in use
-		// 2. If approaching cap with remaining pools > remaining slots: require vCenters with multiple pools
-		// 3. Initial selection (no pools assigned): pre-filter to avoid low-capacity vCenters
-		var excludedVCenters map[string]bool
-		if lease.Spec.VCenters > 0 {
-			vcentersInUse := utils.GetVCentersInUse(assignedPools)
-			remainingVCenterSlots := lease.Spec.VCenters - len(vcentersInUse)
-			remainingPools := requiredPools - len(assignedPools)
-
-			log.Printf("Lease %s: cap=%d, using=%d, remaining_slots=%d, remaining_pools=%d",
-				lease.Name, lease.Spec.VCenters, len(vcentersInUse), remainingVCenterSlots, remainingPools)
-
-			if len(vcentersInUse) >= lease.Spec.VCenters {
-				// Cap reached — only allow pools from vCenters already in use
-				excludedVCenters = make(map[string]bool)
-				for _, p := range availablePools {
-					srv := p.Spec.Server
-					if srv != "" && !vcentersInUse[srv] {
-						excludedVCenters[srv] = true
-					}
-				}
-				log.Printf("Lease %s: vCenter cap reached, only allowing vCenters in use", lease.Name)
-			} else if remainingVCenterSlots > 0 && remainingPools > remainingVCenterSlots {
-				// We need multiple pools per remaining vCenter slot
-				// Apply dynamic filtering: exclude vCenters that don't have enough pools
-				minPoolsPerVCenter := (remainingPools-1)/remainingVCenterSlots + 1
-
-				log.Printf("Lease %s: need %d pools from %d remaining slots, min %d pools per vCenter required",
-					lease.Name, remainingPools, remainingVCenterSlots, minPoolsPerVCenter)
-
-				// Count fitting pools per vCenter
-				fittingPools, _ := utils.GetFittingPools(lease, availablePools, nil)
-				fittingPoolsPerVCenter := make(map[string]int)
-				for _, p := range fittingPools {
-					if p.Spec.Server != "" && !vcentersInUse[p.Spec.Server] {
-						fittingPoolsPerVCenter[p.Spec.Server]++
-					}
-				}
-
-				// Exclude vCenters (not already in use) that don't have enough pools
-				excludedVCenters = make(map[string]bool)
-				for _, p := range availablePools {
-					srv := p.Spec.Server
-					if srv != "" && !vcentersInUse[srv] {
-						if fittingPoolsPerVCenter[srv] < minPoolsPerVCenter {
-							excludedVCenters[srv] = true
-						}
-					}
-				}
-
-				if len(excludedVCenters) > 0 {
-					log.Printf("Lease %s: excluded %d vCenters with < %d pools (dynamic filtering)",
-						lease.Name, len(excludedVCenters), minPoolsPerVCenter)
-				}
-			} else if lease.Spec.VCenters < requiredPools && len(assignedPools) == 0 {
-				// Special case: if we need more pools than vCenters allowed (VCenters < Pools),
-				// and we haven't assigned any pools yet, we must ensure we only pick from
-				// vCenters that can participate in a valid combination to fulfill the lease.
-				//
-				// Use greedy selection: sort vCenters by pool count descending, check if
-				// the top VCenters vCenters have enough pools total. Only exclude vCenters
-				// that cannot participate in any valid combination.
-
-				// Reuse GetFittingPools to apply consistent filtering logic
-				fittingPools, _ := utils.GetFittingPools(lease, availablePools, nil)
-
-				// Group fitting pools by vCenter and count them
-				fittingPoolsPerVCenter := make(map[string][]*v1.Pool)
-				for _, p := range fittingPools {
-					if p.Spec.Server != "" {
-						fittingPoolsPerVCenter[p.Spec.Server] = append(fittingPoolsPerVCenter[p.Spec.Server], p)
-					}
-				}
-
-				// Build sorted list of vCenters by pool count (descending)
-				type vcenterPoolCount struct {
-					server string
-					count  int
-				}
-				vcenterCounts := make([]vcenterPoolCount, 0, len(fittingPoolsPerVCenter))
-				for server, pools := range fittingPoolsPerVCenter {
-					vcenterCounts = append(vcenterCounts, vcenterPoolCount{server: server, count: len(pools)})
-				}
-				sort.Slice(vcenterCounts, func(i, j int) bool {
-					return vcenterCounts[i].count > vcenterCounts[j].count
-				})
-
-				// Calculate total pools available from top VCenters vCenters
-				topVCentersPoolCount := 0
-				numVCentersToUse := lease.Spec.VCenters
-				if numVCentersToUse > len(vcenterCounts) {
-					numVCentersToUse = len(vcenterCounts)
-				}
-				for i := 0; i < numVCentersToUse; i++ {
-					topVCentersPoolCount += vcenterCounts[i].count
-				}
-
-				// If the top VCenters vCenters don't have enough pools total, we can't fulfill
-				// In this case, keep all vCenters (no exclusions) and let the normal flow handle it
-				if topVCentersPoolCount < requiredPools {
-					log.Printf("Lease %s: top %d vCenters only have %d pools total, need %d - no exclusions applied",
-						lease.Name, numVCentersToUse, topVCentersPoolCount, requiredPools)
-				} else {
-					// We can potentially fulfill. Use a hybrid strategy that balances:
-					// 1. Allowing valid combinations (maintenance scenario)
-					// 2. Preventing greedy trap (low-pool vCenters exhausting cap)
-
-					// Find minimum vCenters needed from the top
-					cumulativePoolCount := 0
-					minVCentersNeeded := 0
-					for i := 0; i < len(vcenterCounts); i++ {
-						cumulativePoolCount += vcenterCounts[i].count
-						minVCentersNeeded++
-						if cumulativePoolCount >= requiredPools {
-							break
-						}
-					}
-
-					excludedVCenters = make(map[string]bool)
-
-					if minVCentersNeeded < lease.Spec.VCenters {
-						// We have slack (min < cap): can be selective to avoid greedy trap
-						// Keep top minVCentersNeeded, apply ceiling filter to remainder
-						ceiling := (requiredPools-1)/lease.Spec.VCenters + 1
-
-						for i := minVCentersNeeded; i < len(vcenterCounts); i++ {
-							if vcenterCounts[i].count < ceiling {
-								excludedVCenters[vcenterCounts[i].server] = true
-							}
-						}
-
-						if len(excludedVCenters) > 0 {
-							log.Printf("Lease %s: excluded %d low-pool vCenters (need %d pools from %d vCenters, top %d sufficient)",
-								lease.Name, len(excludedVCenters), requiredPools, lease.Spec.VCenters, minVCentersNeeded)
-						}
-					} else {
-						// No slack (min >= cap): use all vCenter slots, apply combination-aware filtering
-						// This handles maintenance scenarios where we need flexibility
-						for idx, current := range vcenterCounts {
-							// For this vCenter, find the best (VCenters-1) OTHER vCenters
-							bestOthersSum := 0
-							othersCollected := 0
-							othersNeeded := lease.Spec.VCenters - 1
-
-							for i := 0; i < len(vcenterCounts) && othersCollected < othersNeeded; i++ {
-								if i != idx {
-									bestOthersSum += vcenterCounts[i].count
-									othersCollected++
-								}
-							}
-
-							// Can this vCenter + best others reach the requirement?
-							if current.count+bestOthersSum < requiredPools {
-								excludedVCenters[current.server] = true
-							}
-						}
-
-						if len(excludedVCenters) > 0 {
-							log.Printf("Lease %s: excluded %d vCenters that cannot combine to reach requirement (need %d pools from %d vCenters)",
-								lease.Name, len(excludedVCenters), requiredPools, lease.Spec.VCenters)
-						}
-					}
-				}
-			}
-		}
-
-		log.Printf("Attempting to assign pool %d/%d for lease %s, %d pools available after filtering", len(assignedPools)+1, requiredPools, lease.Name, len(availablePools))
-		log.Printf("Lease %s currently has %d owner references before GetPoolWithStrategy", lease.Name, len(lease.OwnerReferences))
-		if len(excludedVCenters) > 0 {
-			log.Printf("Lease %s: %d vCenters excluded from pool selection", lease.Name, len(excludedVCenters))
-		}
-
-		pool, err := utils.GetPoolWithStrategy(lease, availablePools, v1.RESOURCE_ALLOCATION_STRATEGY_UNDERUTILIZED, excludedVCenters)
+		log.Printf("Attempting to assign pool %d/%d for lease %s, %d pools available", len(assignedPools)+1, requiredPools, lease.Name, len(availablePools))
+		candidates, err := l.getScheduler().Schedule(state, lease, availablePools)
 		if err != nil {
-			log.Printf("GetPoolWithStrategy error for lease %s: %v", lease.Name, err)
-
-			// If we already have some pools assigned but can't get more due to vCenter filtering constraints,
-			// we should release what we have and go back to PENDING to try again later with different pools
-			if len(assignedPools) > 0 && lease.Spec.VCenters > 0 {
-				vcentersInUse := utils.GetVCentersInUse(assignedPools)
-
-				// Check if we're stuck because of vCenter constraints:
-				// 1. Cap reached: using all allowed vCenters
-				// 2. Dynamic filtering: excluded remaining vCenters due to insufficient pool count
-				capReached := len(vcentersInUse) >= lease.Spec.VCenters
-				dynamicFilteringApplied := len(excludedVCenters) > 0 && !capReached
-
-				if capReached || dynamicFilteringApplied {
-					reason := "vCenter cap"
-					if dynamicFilteringApplied {
-						reason = "dynamic vCenter filtering"
-					}
-					log.Printf("Lease %s: stuck at PARTIAL due to %s - releasing %d assigned pools to retry",
-						lease.Name, reason, len(assignedPools))
-
-					// Remove all pool AND network owner references to release them
-					// Networks are tied to pools, so if we're releasing pools, we should also release their networks
-					// to avoid resource leaks (networks staying locked to a lease that no longer owns the pools)
-					newOwnerRefs := []metav1.OwnerReference{}
-					for _, ref := range lease.OwnerReferences {
-						if ref.Kind != "Pool" && ref.Kind != "Network" {
-							newOwnerRefs = append(newOwnerRefs, ref)
-						}
-					}
-					lease.OwnerReferences = newOwnerRefs
-
-					// First update the lease metadata (OwnerReferences)
-					if err := l.Client.Update(ctx, lease); err != nil {
-						log.Printf("Failed to update lease metadata (release pools): %v", err)
-						return ctrl.Result{}, err
-					}
-
-					// Then update the status (conditions). Clear PoolInfo/EnvVarsMap since they still
-					// reference the pools/networks we just released ownership of. status.topology.networks
-					// is a required, non-empty field on the CRD, so reset it to the same placeholder used
-					// before any pool is assigned rather than clearing it outright.
-					lease.Status.PoolInfo = nil
-					lease.Status.EnvVarsMap = nil
-					lease.Status.Topology.Networks = []string{"/pending/network/pending"}
-					conditions.Set(lease, conditions.FalseConditionWithReason(
-						v1.LeaseConditionTypeFulfilled,
-						v1.ReasonLeaseNoPool,
-						v1.ConditionSeverityWarning,
-						fmt.Sprintf("Released %d pools due to %s constraint, retrying", len(assignedPools), reason),
-					))
-
-					if err := l.Client.Status().Update(ctx, lease); err != nil {
-						log.Printf("Failed to update lease status (set PENDING): %v", err)
-						return ctrl.Result{}, err
-					}
-
-					updateLeaseMetrics()
-					log.Printf("lease %s released pools and is PENDING - requeuing in %v", lease.Name, LEASE_PENDING_RETRY_INTERVAL)
-					return ctrl.Result{RequeueAfter: LEASE_PENDING_RETRY_INTERVAL}, nil
-				}
-
-				// Otherwise just mark as partial (not vCenter filtering related)
-				log.Printf("lease %s needs %d pools but only %d available (insufficient pool resources, not vCenter filtering)", lease.Name, requiredPools, len(assignedPools))
-				break
-			}
+			log.Printf("scheduling error for lease %s: %v", lease.Name, err)
+			recordUnschedulableReasons(lease, err)
 
 			conditions.Set(lease, conditions.FalseConditionWithReason(
 				v1.LeaseConditionTypeFulfilled,
@@ -1086,7 +1413,9 @@ func (l *LeaseReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl
 			return ctrl.Result{RequeueAfter: LEASE_PENDING_RETRY_INTERVAL}, nil
 		}
 
-		log.Printf("Lease %s now has %d owner references after GetPoolWithStrategy", lease.Name, len(lease.OwnerReferences))
+		pool := candidates[0]
+		utils.AddPoolOwnerReference(lease, pool)
+		log.Printf("Lease %s now has %d owner references after scheduling", lease.Name, len(lease.OwnerReferences))
 		assignedPools = append(assignedPools, pool)
 		assignedPoolNames[pool.Name] = true
 		log.Printf("assigned pool %s to lease %s (%d/%d pools)", pool.Name, lease.Name, len(assignedPools), requiredPools)
@@ -1103,6 +1432,16 @@ func (l *LeaseReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl
 
 	log.Printf("Finished assigning pools for lease %s: %d pools assigned, %d total owner references", lease.Name, len(assignedPools), len(lease.OwnerReferences))
 
+	if backfill {
+		log.Printf("lease %s is backfilled ahead of %d waiting leases", lease.Name, len(blockers))
+		conditions.Set(lease, conditions.TrueConditionWithReason(
+			v1.LeaseConditionTypeBackfilled,
+			v1.ReasonLeaseBackfilled,
+			"lease was scheduled ahead of %s without using capacity reserved for them",
+			leaseNames(blockers),
+		))
+	}
+
 	// Use the first pool for backward compatibility with status fields
 	pool := assignedPools[0]
 
@@ -1162,14 +1501,14 @@ func (l *LeaseReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl
 			log.Printf("Searching for networks to assign to pool %v for lease %v", currentPool.Name, lease.Name)
 
 			// First, try to get common networks (for cross-pool communication)
-			var availableNetworks []*v1.Network
+			var availableNetworks, reservedNetworks []*v1.Network
 			availableNetworks, err = l.getCommonNetworksForLease(lease)
 			if err == nil {
 				// Filter common networks to only those in the current pool's topology.
 				// Sibling leases may be on different pools whose networks don't exist here.
 				var poolFiltered []*v1.Network
 				for _, n := range availableNetworks {
-					if _, exists := poolNetworksMap[n.Name]; exists {
+					if _, exists := poolNetworksMap[n.Name]; exists && networkHasAddressesFor(lease, n) {
 						poolFiltered = append(poolFiltered, n)
 					}
 				}
@@ -1183,12 +1522,13 @@ func (l *LeaseReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl
 			if err != nil {
 				log.Printf("error getting common network for lease, will attempt to allocate new networks: %v", err)
 
-				availableNetworks = l.getAvailableNetworks(currentPool, lease.Spec.NetworkType)
+				availableNetworks = l.getAvailableNetworks(lease, currentPool, lease.Spec.NetworkType)
+				reservedNetworks = l.getReservedNetworks(lease, currentPool)
 
 				// We can allow multi-tenant leases to use single-tenant networks if there are not enough multi-tenant leases.
 				if l.AllowMultiToUseSingle && lease.Spec.NetworkType == v1.NetworkTypeMultiTenant {
 					log.Println("Adding single tenant networks to multi-tenant collection...")
-					availableNetworks = append(availableNetworks, l.getAvailableNetworks(currentPool, v1.NetworkTypeSingleTenant)...)
+					availableNetworks = append(availableNetworks, l.getAvailableNetworks(lease, currentPool, v1.NetworkTypeSingleTenant)...)
 				}
 			}
 
@@ -1198,6 +1538,8 @@ func (l *LeaseReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl
 			rand.Shuffle(len(availableNetworks), func(i, j int) {
 				availableNetworks[i], availableNetworks[j] = availableNetworks[j], availableNetworks[i]
 			})
+			// networks held by the lease's reservation are used before shared ones
+			availableNetworks = append(reservedNetworks, availableNetworks...)
 
 			// For the first pool, we assign networks and track their VLANs
 			// For subsequent pools, we try to match VLANs from the first pool
@@ -1249,95 +1591,31 @@ func (l *LeaseReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl
 				log.Printf("Warning: Pool %s only has %d/%d networks assigned", currentPool.Name, poolNetworkCount, networksPerPool)
 			}
 		}
-
-		// Generate env vars for this pool
-		// Find any network assigned to this pool to use for env var generation
-		var networkForEnvVars *v1.Network
-		for _, ownerRef := range lease.OwnerReferences {
-			if ownerRef.Kind == "Network" {
-				if net, exists := poolNetworksMap[ownerRef.Name]; exists {
-					networkForEnvVars = net
-					break
-				}
-			}
-		}
-
-		if networkForEnvVars != nil {
-			log.Printf("Generating env vars for lease %v with pool %v and network %v", lease.Name, currentPool.Name, networkForEnvVars.Name)
-			err = utils.GenerateEnvVars(lease, currentPool, networkForEnvVars)
-			if err != nil {
-				log.Printf("error generating env vars: %v", err)
-			}
-		}
 	}
 
 	// CRD validation requires MinItems=1 for topology.networks.
 	// If any pool has zero assigned networks, skip the status update to avoid rejection.
 	if poolName, missing := poolMissingNetworks(lease, assignedPools); missing {
 		log.Printf("pool %s has no networks assigned for lease %s, saving owner refs and requeuing", poolName, lease.Name)
+		leaseStatus := lease.Status.DeepCopy()
 		err = l.Client.Update(ctx, lease)
 		if err != nil {
 			return ctrl.Result{}, fmt.Errorf("error updating lease owner references: %v", err)
 		}
+		if backfill {
+			leaseStatus.DeepCopyInto(&lease.Status)
+			if err := l.Client.Status().Update(ctx, lease); err != nil {
+				return ctrl.Result{}, fmt.Errorf("error updating lease status: %v", err)
+			}
+			countLeaseBackfill(lease)
+		}
 		updateLeaseMetrics()
 		return ctrl.Result{RequeueAfter: LEASE_PARTIAL_RETRY_INTERVAL}, nil
 	}
 
-	// Populate poolInfo array with FailureDomainSpec from each assigned pool
 	// This must happen AFTER network assignment to ensure networks are in owner references
-	lease.Status.PoolInfo = make([]v1.FailureDomainSpec, 0, len(assignedPools))
-	for _, poolItem := range assignedPools {
-		// Get networks available in this pool
-		poolNetworksMap := getNetworksForPool(poolItem)
-
-		// Find networks assigned to this lease for this specific pool
-		assignedNetworks := []string{}
-		for _, ownerRef := range lease.OwnerReferences {
-			if ownerRef.Kind == "Network" {
-				if net, exists := poolNetworksMap[ownerRef.Name]; exists {
-					// This network belongs to this pool and is assigned to the lease
-					networkPath := fmt.Sprintf("/%s/network/%s", poolItem.Spec.Topology.Datacenter, net.Spec.PortGroupName)
-					assignedNetworks = append(assignedNetworks, networkPath)
-				}
-			}
-		}
-
-		// Create a copy of the FailureDomainSpec from the pool
-		poolFailureDomain := poolItem.Spec.FailureDomainSpec
-		// Update the topology with only assigned networks
-		poolFailureDomain.Topology.Networks = assignedNetworks
-
-		lease.Status.PoolInfo = append(lease.Status.PoolInfo, poolFailureDomain)
-		log.Printf("Added pool info for pool %s to lease %s with %d networks", poolItem.Name, lease.Name, len(assignedNetworks))
-	}
-
-	// Build the status.topology.networks list (using first pool's datacenter for the path)
-	var allNetworks []string
-	for _, ownerRef := range lease.OwnerReferences {
-		if ownerRef.Kind == "Network" {
-			for _, net := range networks {
-				if net.Name == ownerRef.Name {
-					// Only add each unique port group once (even if multiple pools use same VLAN)
-					networkPath := fmt.Sprintf("/%s/network/%s", pool.Spec.Topology.Datacenter, net.Spec.PortGroupName)
-					// Check if already added
-					alreadyAdded := false
-					for _, existing := range allNetworks {
-						if existing == networkPath {
-							alreadyAdded = true
-							break
-						}
-					}
-					if !alreadyAdded {
-						allNetworks = append(allNetworks, networkPath)
-					}
-					break
-				}
-			}
-		}
-	}
-
-	lease.Status.Topology.Networks = allNetworks
-	log.Printf("Lease %v has %d total network owner references, %d unique networks in status", lease.Name, len(vlanToNetworks), len(allNetworks))
+	setLeaseNetworkStatus(lease, assignedPools)
+	log.Printf("Lease %v has %d total network owner references, %d unique networks in status", lease.Name, len(vlanToNetworks), len(lease.Status.Topology.Networks))
 
 	// Check if all pools and networks have been assigned
 	// Each pool must have the full number of networks
@@ -1378,6 +1656,8 @@ func (l *LeaseReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl
 
 	if poolsFulfilled && networksFulfilled {
 		lease.Status.Phase = v1.PHASE_FULFILLED
+		lease.Status.UnschedulableReasons = nil
+		clearLeaseQueueStatus(lease)
 		LeaseTransitionsTotal.With(prometheus.Labels{
 			"namespace":   lease.Namespace,
 			"networkType": string(lease.Spec.NetworkType),
@@ -1393,6 +1673,12 @@ func (l *LeaseReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl
 		conditions.Set(lease, conditions.FalseCondition(
 			v1.LeaseConditionTypePartial,
 		))
+
+		allocated := leaseRequestedResources(lease)
+		lease.Status.Allocated = &allocated
+
+		// the TTL of a lease starts when it is fulfilled
+		checkLeaseExpiry(lease, time.Now())
 	} else {
 		lease.Status.Phase = v1.PHASE_PARTIAL
 		LeaseTransitionsTotal.With(prometheus.Labels{
@@ -1437,6 +1723,9 @@ func (l *LeaseReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl
 	if err != nil {
 		return ctrl.Result{}, fmt.Errorf("error updating lease status, requeuing: %v", err)
 	}
+	if backfill {
+		countLeaseBackfill(lease)
+	}
 
 	if lease.Status.Phase == v1.PHASE_FULFILLED {
 		promLabels["pool"] = pool.Name
@@ -1447,6 +1736,9 @@ func (l *LeaseReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl
 		}
 
 		l.triggerPoolUpdates(ctx)
+		l.triggerQuotaUpdates(ctx)
+		l.triggerReservationUpdates(ctx)
+		l.triggerNetworkUpdates(ctx)
 		l.triggerLeaseUpdates(ctx, lease.Spec.NetworkType)
 		updateLeaseMetrics()
 
@@ -1,11 +1,13 @@
 package controller
 
 import (
+	"reflect"
 	"sort"
 	"testing"
 
 	configv1 "github.com/openshift/api/config/v1"
 	"github.com/prometheus/client_golang/prometheus/testutil"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	v1 "github.com/openshift-splat-team/vsphere-capacity-manager/pkg/apis/vspherecapacitymanager.splat.io/v1"
@@ -312,14 +314,14 @@ func TestGetCommonNetworksForLease(t *testing.T) {
 	})
 
 	t.Run("getAvailableNetworks returns pool-local network", func(t *testing.T) {
-		got := reconciler.getAvailableNetworks(poolB, v1.NetworkTypeMultiTenant)
+		got := reconciler.getAvailableNetworks(targetLease, poolB, v1.NetworkTypeMultiTenant)
 		if len(got) != 1 || got[0].Name != netPoolB.Name {
 			t.Errorf("expected pool B's network %s, got %v", netPoolB.Name, got)
 		}
 	})
 
 	t.Run("getAvailableNetworks excludes cross-pool network", func(t *testing.T) {
-		got := reconciler.getAvailableNetworks(poolA, v1.NetworkTypeMultiTenant)
+		got := reconciler.getAvailableNetworks(targetLease, poolA, v1.NetworkTypeMultiTenant)
 		for _, n := range got {
 			if n.Name == netPoolB.Name {
 				t.Error("pool A's available networks should not include pool B's network")
@@ -560,6 +562,142 @@ func TestUpdateLeaseMetrics(t *testing.T) {
 	}
 }
 
+func TestReconcilePoolStatesStorage(t *testing.T) {
+	oldPools := pools
+	oldLeases := leases
+	defer func() {
+		pools = oldPools
+		leases = oldLeases
+	}()
+
+	pool := &v1.Pool{
+		TypeMeta:   metav1.TypeMeta{Kind: "Pool"},
+		ObjectMeta: metav1.ObjectMeta{Name: "pool1", Namespace: "default"},
+		Spec: v1.PoolSpec{
+			VCpus:           100,
+			Memory:          400,
+			Storage:         4000,
+			OverCommitRatio: "1.0",
+		},
+	}
+	pools = map[string]*v1.Pool{"default/pool1": pool}
+
+	owner := []metav1.OwnerReference{{Kind: "Pool", Name: "pool1"}}
+	leases = map[string]*v1.Lease{
+		"default/lease-a": {
+			ObjectMeta: metav1.ObjectMeta{Name: "lease-a", Namespace: "default", OwnerReferences: owner},
+			Spec:       v1.LeaseSpec{VCpus: 24, Memory: 96, Storage: 720},
+		},
+		"default/lease-b": {
+			ObjectMeta: metav1.ObjectMeta{Name: "lease-b", Namespace: "default", OwnerReferences: owner},
+			Spec:       v1.LeaseSpec{VCpus: 24, Memory: 96, Storage: 1000},
+		},
+		"default/lease-unassigned": {
+			ObjectMeta: metav1.ObjectMeta{Name: "lease-unassigned", Namespace: "default"},
+			Spec:       v1.LeaseSpec{VCpus: 24, Memory: 96, Storage: 1000},
+		},
+	}
+
+	reconcilePoolStates()
+
+	if pool.Status.DatastoreAvailable != 2280 {
+		t.Errorf("expected 2280 GB datastore available, got %d", pool.Status.DatastoreAvailable)
+	}
+	if pool.Status.VCpusAvailable != 52 {
+		t.Errorf("expected 52 vCPUs available, got %d", pool.Status.VCpusAvailable)
+	}
+	if pool.Status.LeaseCount != 2 {
+		t.Errorf("expected 2 leases, got %d", pool.Status.LeaseCount)
+	}
+}
+
+func TestReconcilePoolStatesOverCommit(t *testing.T) {
+	oldPools := pools
+	oldLeases := leases
+	defer func() {
+		pools = oldPools
+		leases = oldLeases
+	}()
+
+	cpuRatio := resource.MustParse("2.5")
+	memoryRatio := resource.MustParse("1.2")
+	pool := &v1.Pool{
+		TypeMeta:   metav1.TypeMeta{Kind: "Pool"},
+		ObjectMeta: metav1.ObjectMeta{Name: "pool1", Namespace: "default"},
+		Spec: v1.PoolSpec{
+			VCpus:      100,
+			Memory:     400,
+			Storage:    4000,
+			OverCommit: v1.PoolOverCommit{CPU: &cpuRatio, Memory: &memoryRatio},
+		},
+	}
+	pools = map[string]*v1.Pool{"default/pool1": pool}
+	leases = map[string]*v1.Lease{
+		"default/lease-a": {
+			ObjectMeta: metav1.ObjectMeta{Name: "lease-a", Namespace: "default", OwnerReferences: []metav1.OwnerReference{{Kind: "Pool", Name: "pool1"}}},
+			Spec:       v1.LeaseSpec{VCpus: 24, Memory: 96, Storage: 720},
+		},
+	}
+
+	reconcilePoolStates()
+
+	if pool.Status.VCpusAvailable != 226 {
+		t.Errorf("expected 226 vCPUs available, got %d", pool.Status.VCpusAvailable)
+	}
+	if pool.Status.MemoryAvailable != 384 {
+		t.Errorf("expected 384 GB memory available, got %d", pool.Status.MemoryAvailable)
+	}
+	if pool.Status.DatastoreAvailable != 3280 {
+		t.Errorf("expected storage without a ratio not to be overcommitted, got %d GB available", pool.Status.DatastoreAvailable)
+	}
+}
+
+func TestReconcilePoolStatesExtendedResources(t *testing.T) {
+	oldPools := pools
+	oldLeases := leases
+	defer func() {
+		pools = oldPools
+		leases = oldLeases
+	}()
+
+	pool := &v1.Pool{
+		TypeMeta:   metav1.TypeMeta{Kind: "Pool"},
+		ObjectMeta: metav1.ObjectMeta{Name: "pool1", Namespace: "default"},
+		Spec: v1.PoolSpec{
+			VCpus:             100,
+			Memory:            400,
+			ExtendedResources: map[string]int{"licenses/vgpu": 4, "nested-esxi-slots": 2},
+		},
+	}
+	pools = map[string]*v1.Pool{"default/pool1": pool}
+	owner := []metav1.OwnerReference{{Kind: "Pool", Name: "pool1"}}
+	leases = map[string]*v1.Lease{
+		"default/lease-a": {
+			ObjectMeta: metav1.ObjectMeta{Name: "lease-a", Namespace: "default", OwnerReferences: owner},
+			Spec:       v1.LeaseSpec{VCpus: 24, Memory: 96, ExtendedResources: map[string]int{"licenses/vgpu": 1}},
+		},
+		"default/lease-b": {
+			ObjectMeta: metav1.ObjectMeta{Name: "lease-b", Namespace: "default", OwnerReferences: owner},
+			Spec:       v1.LeaseSpec{VCpus: 24, Memory: 96, ExtendedResources: map[string]int{"licenses/vgpu": 2, "ip/public-v4": 1}},
+		},
+	}
+
+	reconcilePoolStates()
+
+	expected := map[string]int{"licenses/vgpu": 1, "nested-esxi-slots": 2, "ip/public-v4": -1}
+	if !reflect.DeepEqual(pool.Status.ExtendedResourcesAvailable, expected) {
+		t.Errorf("expected extended resources available %v, got %v", expected, pool.Status.ExtendedResourcesAvailable)
+	}
+
+	// fulfilled leases are accounted for by their allocation, not their spec
+	leases["default/lease-a"].Status.Allocated = &v1.LeaseResources{VCpus: 24, Memory: 96, ExtendedResources: map[string]int{"licenses/vgpu": 1}}
+	leases["default/lease-a"].Spec.ExtendedResources = map[string]int{"licenses/vgpu": 3}
+	reconcilePoolStates()
+	if !reflect.DeepEqual(pool.Status.ExtendedResourcesAvailable, expected) {
+		t.Errorf("expected an edited spec not to change extended resources available %v, got %v", expected, pool.Status.ExtendedResourcesAvailable)
+	}
+}
+
 func TestPoolMissingNetworks(t *testing.T) {
 	dc := "dc1"
 	pod := "pod1"
@@ -1815,3 +1953,32 @@ func TestLeaseVCentersPoolsInteraction(t *testing.T) {
 		})
 	}
 }
+
+func TestGetPlacedLeases(t *testing.T) {
+	oldPools := pools
+	defer func() { pools = oldPools }()
+	pools = map[string]*v1.Pool{
+		"default/pool1": {ObjectMeta: metav1.ObjectMeta{Name: "pool1", Namespace: "default"}},
+	}
+
+	owner := []metav1.OwnerReference{{Kind: "Pool", Name: "pool1"}}
+	lease := &v1.Lease{ObjectMeta: metav1.ObjectMeta{Name: "lease", Namespace: "default", OwnerReferences: owner}}
+	restore := setupTestLeases(map[string]*v1.Lease{
+		"default/lease":   lease,
+		"default/placed":  {ObjectMeta: metav1.ObjectMeta{Name: "placed", Namespace: "default", OwnerReferences: owner}},
+		"default/pending": {ObjectMeta: metav1.ObjectMeta{Name: "pending", Namespace: "default"}},
+		"other/placed":    {ObjectMeta: metav1.ObjectMeta{Name: "placed", Namespace: "other", OwnerReferences: owner}},
+	})
+	defer restore()
+
+	placed := getPlacedLeases(lease)
+	if len(placed) != 1 {
+		t.Fatalf("expected 1 placed lease, got %d", len(placed))
+	}
+	if placed[0].Lease.Name != "placed" || placed[0].Lease.Namespace != "default" {
+		t.Errorf("expected default/placed, got %s/%s", placed[0].Lease.Namespace, placed[0].Lease.Name)
+	}
+	if len(placed[0].Pools) != 1 || placed[0].Pools[0].Name != "pool1" {
+		t.Errorf("expected the lease to be placed on pool1, got %v", placed[0].Pools)
+	}
+}
@@ -26,6 +26,16 @@ var (
 		Help: "The total amount of cpus of a pool",
 	}, []string{"namespace", "pool"})
 
+	PoolStorageAvailable = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "pool_storage_available",
+		Help: "The amount of storage in GB available in a pool",
+	}, []string{"namespace", "pool"})
+
+	PoolStorageTotal = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "pool_storage_total",
+		Help: "The total amount of storage in GB of a pool",
+	}, []string{"namespace", "pool"})
+
 	PoolNetworksAvailable = prometheus.NewGaugeVec(prometheus.GaugeOpts{
 		Name: "pool_networks_available",
 		Help: "Number of available (not in use) networks per pool",
@@ -36,6 +46,16 @@ var (
 		Help: "Total number of networks in a pool",
 	}, []string{"namespace", "pool"})
 
+	PoolExtendedResourceAvailable = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "pool_extended_resource_available",
+		Help: "The amount of an extended resource available in a pool",
+	}, []string{"namespace", "pool", "resource"})
+
+	PoolExtendedResourceTotal = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "pool_extended_resource_total",
+		Help: "The total amount of an extended resource of a pool",
+	}, []string{"namespace", "pool", "resource"})
+
 	PoolNetworksAvailableByType = prometheus.NewGaugeVec(prometheus.GaugeOpts{
 		Name: "pool_networks_available_by_type",
 		Help: "Number of available (not in use) networks per pool, broken down by network type",
@@ -56,6 +76,11 @@ var (
 		Help: "Ratio of memory in use to total available per pool",
 	}, []string{"namespace", "pool"})
 
+	PoolStorageUtilizationRatio = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "pool_storage_utilization_ratio",
+		Help: "Ratio of storage in use to total available per pool",
+	}, []string{"namespace", "pool"})
+
 	PoolNetworksUtilizationRatio = prometheus.NewGaugeVec(prometheus.GaugeOpts{
 		Name: "pool_networks_utilization_ratio",
 		Help: "Ratio of networks in use to total available per pool",
@@ -96,10 +121,51 @@ var (
 		Help: "Total number of times leases have been delayed",
 	}, []string{"namespace", "networkType"})
 
+	LeaseBackfillsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "lease_backfills_total",
+		Help: "Total number of times leases have been scheduled ahead of waiting older leases",
+	}, []string{"namespace", "networkType"})
+
+	LeaseExpirationsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "lease_expirations_total",
+		Help: "Total number of leases expired after their TTL passed without a renewal",
+	}, []string{"namespace", "networkType", "policy"})
+
+	LeaseHoldSeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "lease_hold_seconds",
+		Help:    "Time released leases were held after they were fulfilled",
+		Buckets: prometheus.ExponentialBuckets(300, 2, 10),
+	}, []string{"networkType"})
+
+	LeaseQueueLength = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "lease_queue_length",
+		Help: "Number of pending leases waiting in the queue",
+	}, []string{"namespace", "networkType"})
+
+	LeaseEstimatedWaitSeconds = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "lease_estimated_wait_seconds",
+		Help: "Longest estimated time a pending lease waits for the leases ahead of it",
+	}, []string{"namespace", "networkType"})
+
 	NetworkLeaseCount = prometheus.NewGaugeVec(prometheus.GaugeOpts{
 		Name: "network_lease_count",
 		Help: "Number of leases currently using each network",
 	}, []string{"namespace", "network", "networkType", "pool"})
+
+	NetworkMaxTenants = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "network_max_tenants",
+		Help: "Number of leases which may hold each network at the same time",
+	}, []string{"namespace", "network", "networkType", "pool"})
+
+	LeaseQuotaUsed = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "lease_quota_used",
+		Help: "Amount of a resource held by the leases counted by a lease quota",
+	}, []string{"namespace", "quota", "resource"})
+
+	LeaseQuotaHard = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "lease_quota_hard",
+		Help: "Limit of a resource enforced by a lease quota",
+	}, []string{"namespace", "quota", "resource"})
 )
 
 func InitMetrics() {
@@ -108,10 +174,16 @@ func InitMetrics() {
 		PoolNetworksAvailable, PoolNetworksTotal,
 		PoolNetworksAvailableByType, PoolNetworksTotalByType,
 		PoolCpusAvailable, PoolCpusTotal,
-		PoolVcpusUtilizationRatio, PoolMemoryUtilizationRatio, PoolNetworksUtilizationRatio,
+		PoolStorageAvailable, PoolStorageTotal,
+		PoolExtendedResourceAvailable, PoolExtendedResourceTotal,
+		PoolVcpusUtilizationRatio, PoolMemoryUtilizationRatio, PoolStorageUtilizationRatio, PoolNetworksUtilizationRatio,
 		PoolNoSchedule, PoolExcluded,
 		LeasesInUse, LeaseCounts,
-		LeaseAgeSeconds, LeaseTransitionsTotal, LeaseDelaysTotal,
+		LeaseAgeSeconds, LeaseTransitionsTotal, LeaseDelaysTotal, LeaseBackfillsTotal,
+		LeaseExpirationsTotal, LeaseHoldSeconds,
+		LeaseQueueLength, LeaseEstimatedWaitSeconds,
 		NetworkLeaseCount,
+		NetworkMaxTenants,
+		LeaseQuotaUsed, LeaseQuotaHard,
 	)
 }
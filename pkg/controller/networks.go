@@ -4,10 +4,18 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"net/netip"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
 
 	v1 "github.com/openshift-splat-team/vsphere-capacity-manager/pkg/apis/vspherecapacitymanager.splat.io/v1"
+	"github.com/openshift-splat-team/vsphere-capacity-manager/pkg/utils/conditions"
 	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -72,7 +80,8 @@ func (l *NetworkReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 				return ctrl.Result{}, fmt.Errorf("error updating network: %w", err)
 			}
 		}
-		delete(pools, networkKey)
+		delete(networks, networkKey)
+		l.triggerPoolUpdates(ctx)
 		return ctrl.Result{}, nil
 	}
 
@@ -85,6 +94,262 @@ func (l *NetworkReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 		}
 	}
 
+	_, known := networks[networkKey]
 	networks[networkKey] = network
+	if !known {
+		l.triggerPoolUpdates(ctx)
+	}
+
+	previous := network.Status.DeepCopy()
+	setNetworkStatus(network, time.Now())
+	if !reflect.DeepEqual(&network.Status, previous) {
+		log.Printf("network %s is owned by %d leases, %d addresses free", network.Name, network.Status.Tenants, network.Status.IPAddressesFree)
+		if err := l.Client.Status().Update(ctx, network); err != nil {
+			return ctrl.Result{}, fmt.Errorf("error updating network status: %w", err)
+		}
+	}
 	return ctrl.Result{}, nil
 }
+
+// triggerPoolUpdates touches every pool whose NetworksResolved condition no longer matches the
+// networks in the cache, so that it is recomputed after a network is added or deleted.
+func (l *NetworkReconciler) triggerPoolUpdates(ctx context.Context) {
+	for _, pool := range pools {
+		if !poolNetworksResolvedStale(pool) {
+			continue
+		}
+
+		err := l.Client.Get(ctx, types.NamespacedName{Name: pool.Name, Namespace: pool.Namespace}, pool)
+		if err != nil {
+			log.Printf("error getting pool %s: %v", pool.Name, err)
+			continue
+		}
+
+		if pool.Annotations == nil {
+			pool.Annotations = make(map[string]string)
+		}
+
+		pool.Annotations["last-updated"] = time.Now().Format(time.RFC3339)
+		err = l.Client.Update(ctx, pool)
+		if err != nil {
+			log.Printf("error updating pool %s annotations: %v", pool.Name, err)
+		}
+	}
+}
+
+// networkTenants returns the leases which own the network.
+func networkTenants(network *v1.Network) []*v1.Lease {
+	var tenants []*v1.Lease
+	for _, lease := range leases {
+		if lease.Namespace != network.Namespace {
+			continue
+		}
+		for _, ownerRef := range lease.OwnerReferences {
+			if ownerRef.Kind == v1.NetworkKind && ownerRef.Name == network.Name {
+				tenants = append(tenants, lease)
+				break
+			}
+		}
+	}
+	return tenants
+}
+
+// networkLeases returns the names of the leases which own the network, sorted by name.
+func networkLeases(network *v1.Network) []string {
+	var names []string
+	for _, lease := range networkTenants(network) {
+		names = append(names, lease.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// networkPools returns the names of the pools the network belongs to, sorted by name.
+func networkPools(network *v1.Network) []string {
+	var names []string
+	for _, pool := range pools {
+		if bound, exists := getNetworksForPool(pool)[network.Name]; exists && bound.Namespace == network.Namespace {
+			names = append(names, pool.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// networkMaxTenants returns the number of leases which may hold the network at the same time.
+func networkMaxTenants(network *v1.Network) int {
+	return max(network.Spec.MaxTenants, 1)
+}
+
+// networkAcceptsLease reports whether the lease can be assigned the network alongside the leases
+// already holding it. A network is shared by up to maxTenants leases of the same network type;
+// single-tenant leases only take networks nobody holds.
+func networkAcceptsLease(lease *v1.Lease, network *v1.Network) bool {
+	tenants := networkTenants(network)
+	if len(tenants) == 0 {
+		return true
+	}
+	if lease.Spec.NetworkType == v1.NetworkTypeSingleTenant || len(tenants) >= networkMaxTenants(network) {
+		return false
+	}
+	for _, tenant := range tenants {
+		if tenant.Name == lease.Name || tenant.Spec.NetworkType != lease.Spec.NetworkType {
+			return false
+		}
+	}
+	return true
+}
+
+// networkSpecProblems returns the reasons the spec of the network can not be used to assign the
+// network and its addresses to leases.
+func networkSpecProblems(network *v1.Network) []string {
+	var problems []string
+	if network.Spec.PodName == nil {
+		problems = append(problems, "podName is not set")
+	}
+	if network.Spec.Gateway == nil {
+		if len(network.Spec.GatewayIPv6) == 0 {
+			problems = append(problems, "gateway is not set")
+		}
+	} else if _, err := netip.ParseAddr(*network.Spec.Gateway); err != nil {
+		problems = append(problems, fmt.Sprintf("gateway %q is not a valid address", *network.Spec.Gateway))
+	}
+	if len(network.Spec.MachineNetworkCidr) > 0 {
+		if _, err := netip.ParsePrefix(network.Spec.MachineNetworkCidr); err != nil {
+			problems = append(problems, fmt.Sprintf("machineNetworkCidr %q is not a valid CIDR", network.Spec.MachineNetworkCidr))
+		}
+	}
+	if len(network.Spec.GatewayIPv6) > 0 {
+		if gateway, err := netip.ParseAddr(network.Spec.GatewayIPv6); err != nil || !gateway.Is6() {
+			problems = append(problems, fmt.Sprintf("gatewayipv6 %q is not a valid IPv6 address", network.Spec.GatewayIPv6))
+		}
+	}
+	if len(network.Spec.IpV6prefix) > 0 {
+		if prefix, err := netip.ParsePrefix(network.Spec.IpV6prefix); err != nil || !prefix.Addr().Is6() {
+			problems = append(problems, fmt.Sprintf("ipv6prefix %q is not a valid IPv6 prefix", network.Spec.IpV6prefix))
+		}
+	}
+
+	prefix, hasPrefix := networkPrefix(network)
+	invalid, outside := 0, 0
+	for _, ipAddress := range network.Spec.IpAddresses {
+		addr, err := netip.ParseAddr(ipAddress)
+		if err != nil {
+			invalid++
+		} else if hasPrefix && !prefix.Contains(addr) {
+			outside++
+		}
+	}
+	if invalid > 0 {
+		problems = append(problems, fmt.Sprintf("%d ipAddresses are not valid addresses", invalid))
+	}
+	if outside > 0 {
+		problems = append(problems, fmt.Sprintf("%d ipAddresses are outside of %s", outside, prefix))
+	}
+	return problems
+}
+
+// setNetworkStatus populates the status of the network from the leases which own it and the
+// addresses allocated to them. The assignment and release times are moved to now when leases are
+// added to or removed from the network.
+func setNetworkStatus(network *v1.Network, now time.Time) {
+	status := &network.Status
+	owners := networkLeases(network)
+
+	previous := make(map[string]bool)
+	for _, name := range status.Leases {
+		previous[name] = true
+	}
+	current := make(map[string]bool)
+	for _, name := range owners {
+		current[name] = true
+		if !previous[name] {
+			status.LastAssignedTime = &metav1.Time{Time: now}
+		}
+	}
+	for name := range previous {
+		if !current[name] {
+			status.LastReleasedTime = &metav1.Time{Time: now}
+		}
+	}
+	status.Leases = owners
+	status.Tenants = len(owners)
+
+	addressOwners := networkAddressOwners(network)
+	status.IPAddressesAllocated = len(addressOwners)
+	status.IPAddressesFree = 0
+	for _, addr := range assignableAddresses(network) {
+		if _, allocated := addressOwners[addr]; !allocated {
+			status.IPAddressesFree++
+		}
+	}
+
+	var conflicts []string
+	for addr, names := range addressOwners {
+		if len(names) > 1 {
+			conflicts = append(conflicts, fmt.Sprintf("%s is allocated to %s", addr, strings.Join(names, ", ")))
+		}
+	}
+	sort.Strings(conflicts)
+
+	problems := networkSpecProblems(network)
+	switch {
+	case len(problems) > 0:
+		message := strings.Join(problems, "; ")
+		conditions.Set(network, conditions.TrueConditionWithReason(
+			v1.NetworkConditionTypeDegraded, v1.ReasonNetworkInvalid, message))
+		conditions.Set(network, conditions.FalseConditionWithReason(
+			v1.NetworkConditionTypeReady, v1.ReasonNetworkInvalid, v1.ConditionSeverityError, message))
+	case len(conflicts) > 0:
+		conditions.Set(network, conditions.TrueConditionWithReason(
+			v1.NetworkConditionTypeDegraded, v1.ReasonIPAddressConflict, strings.Join(conflicts, "; ")))
+		conditions.Set(network, conditions.TrueCondition(v1.NetworkConditionTypeReady))
+	default:
+		conditions.Set(network, conditions.FalseCondition(v1.NetworkConditionTypeDegraded))
+		conditions.Set(network, conditions.TrueCondition(v1.NetworkConditionTypeReady))
+	}
+
+	if len(owners) > 0 {
+		conditions.Set(network, conditions.TrueCondition(v1.NetworkConditionTypeInUse))
+	} else {
+		conditions.Set(network, conditions.FalseCondition(v1.NetworkConditionTypeInUse))
+	}
+
+	status.Pools = networkPools(network)
+	if len(status.Pools) > 0 {
+		conditions.Set(network, conditions.TrueCondition(v1.NetworkConditionTypeBound))
+	} else {
+		conditions.Set(network, conditions.FalseConditionWithReason(
+			v1.NetworkConditionTypeBound, v1.ReasonNetworkNotInPool, v1.ConditionSeverityWarning,
+			"the network belongs to no pool, it is never assigned to leases"))
+	}
+}
+
+// triggerNetworkUpdates touches every network whose status no longer matches the leases which
+// own it, so that its status is recomputed after leases are fulfilled or released.
+func (l *LeaseReconciler) triggerNetworkUpdates(ctx context.Context) {
+	now := time.Now()
+	for _, network := range networks {
+		updated := network.DeepCopy()
+		setNetworkStatus(updated, now)
+		if reflect.DeepEqual(updated.Status, network.Status) {
+			continue
+		}
+
+		err := l.Client.Get(ctx, types.NamespacedName{Name: network.Name, Namespace: network.Namespace}, network)
+		if err != nil {
+			log.Printf("error getting network %s: %v", network.Name, err)
+			continue
+		}
+
+		if network.Annotations == nil {
+			network.Annotations = make(map[string]string)
+		}
+
+		network.Annotations[v1.NETWORKS_LAST_LEASE_UPDATE_ANNOTATION] = now.Format(time.RFC3339)
+		err = l.Client.Update(ctx, network)
+		if err != nil {
+			log.Printf("error updating network %s annotations: %v", network.Name, err)
+		}
+	}
+}
@@ -0,0 +1,273 @@
+package controller
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/openshift-splat-team/vsphere-capacity-manager/pkg/apis/vspherecapacitymanager.splat.io/v1"
+	"github.com/openshift-splat-team/vsphere-capacity-manager/pkg/utils/conditions"
+)
+
+func TestNetworkSpecProblems(t *testing.T) {
+	tests := []struct {
+		name     string
+		modify   func(network *v1.Network)
+		expected []string
+	}{
+		{name: "valid network", modify: func(network *v1.Network) {}},
+		{
+			name:     "missing pod and gateway",
+			modify:   func(network *v1.Network) { network.Spec.PodName, network.Spec.Gateway = nil, nil },
+			expected: []string{"podName is not set", "gateway is not set"},
+		},
+		{
+			name: "invalid addresses",
+			modify: func(network *v1.Network) {
+				network.Spec.MachineNetworkCidr = "192.168.10.0/33"
+				network.Spec.IpAddresses = append(network.Spec.IpAddresses, "bogus", "192.168.20.2")
+			},
+			expected: []string{
+				`machineNetworkCidr "192.168.10.0/33" is not a valid CIDR`,
+				"1 ipAddresses are not valid addresses",
+				"1 ipAddresses are outside of 192.168.10.0/29",
+			},
+		},
+		{
+			name: "IPv6-only network",
+			modify: func(network *v1.Network) {
+				withIPv6(network)
+				network.Spec.Gateway = nil
+			},
+		},
+		{
+			name: "invalid IPv6 fields",
+			modify: func(network *v1.Network) {
+				network.Spec.GatewayIPv6 = "192.168.10.1"
+				network.Spec.IpV6prefix = "fd65::/129"
+			},
+			expected: []string{
+				`gatewayipv6 "192.168.10.1" is not a valid IPv6 address`,
+				`ipv6prefix "fd65::/129" is not a valid IPv6 prefix`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			network := testIPNetwork("net-10", "10", 29)
+			tt.modify(network)
+			if got := networkSpecProblems(network); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestSetNetworkStatus(t *testing.T) {
+	network := testIPNetwork("net-10", "10", 29)
+	defer setupTestNetworks(map[string]*v1.Network{"default/net-10": network})()
+
+	first := ipAddressLease("first", v1.LeaseIPAddressRequest{}, "net-10")
+	first.Status.IPAllocations = []v1.LeaseIPAllocation{{Network: "net-10", APIVIP: "192.168.10.2", Nodes: []string{"192.168.10.3"}}}
+	second := ipAddressLease("second", v1.LeaseIPAddressRequest{}, "net-10")
+	unrelated := ipAddressLease("unrelated", v1.LeaseIPAddressRequest{}, "net-11")
+	cached := map[string]*v1.Lease{
+		"default/first":     first,
+		"default/second":    second,
+		"default/unrelated": unrelated,
+	}
+	defer setupTestLeases(cached)()
+
+	assignedAt := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	setNetworkStatus(network, assignedAt)
+	status := network.Status
+	if !reflect.DeepEqual(status.Leases, []string{"first", "second"}) || status.Tenants != 2 {
+		t.Errorf("expected the network to be owned by first and second, got %v (%d tenants)", status.Leases, status.Tenants)
+	}
+	if status.IPAddressesAllocated != 2 || status.IPAddressesFree != 3 {
+		t.Errorf("expected 2 allocated and 3 free addresses, got %d and %d", status.IPAddressesAllocated, status.IPAddressesFree)
+	}
+	if status.LastAssignedTime == nil || !status.LastAssignedTime.Time.Equal(assignedAt) || status.LastReleasedTime != nil {
+		t.Errorf("expected only the assignment time to be set, got %v and %v", status.LastAssignedTime, status.LastReleasedTime)
+	}
+	if !conditions.IsTrue(network, v1.NetworkConditionTypeReady) || !conditions.IsTrue(network, v1.NetworkConditionTypeInUse) || conditions.IsTrue(network, v1.NetworkConditionTypeDegraded) {
+		t.Errorf("expected a ready network in use, got %+v", status.Conditions)
+	}
+
+	t.Run("address allocated to several leases", func(t *testing.T) {
+		second.Status.IPAllocations = []v1.LeaseIPAllocation{{Network: "net-10", Nodes: []string{"192.168.10.3"}}}
+		defer func() { second.Status.IPAllocations = nil }()
+
+		setNetworkStatus(network, assignedAt)
+		degraded := conditions.Get(network, v1.NetworkConditionTypeDegraded)
+		if degraded == nil || degraded.Status != v1.ConditionTrue || degraded.Reason != v1.ReasonIPAddressConflict {
+			t.Fatalf("expected the network to be degraded by an address conflict, got %+v", degraded)
+		}
+		if !strings.Contains(degraded.Message, "192.168.10.3 is allocated to first, second") {
+			t.Errorf("expected the conflict to name both leases, got %q", degraded.Message)
+		}
+	})
+
+	t.Run("released by every lease", func(t *testing.T) {
+		delete(cached, "default/first")
+		delete(cached, "default/second")
+		releasedAt := assignedAt.Add(time.Hour)
+
+		setNetworkStatus(network, releasedAt)
+		status := network.Status
+		if len(status.Leases) != 0 || status.Tenants != 0 || status.IPAddressesFree != 5 {
+			t.Errorf("expected an unowned network with 5 free addresses, got %+v", status)
+		}
+		if !status.LastAssignedTime.Time.Equal(assignedAt) || status.LastReleasedTime == nil || !status.LastReleasedTime.Time.Equal(releasedAt) {
+			t.Errorf("expected the release time to be set, got %v and %v", status.LastAssignedTime, status.LastReleasedTime)
+		}
+		if conditions.IsTrue(network, v1.NetworkConditionTypeInUse) || conditions.IsTrue(network, v1.NetworkConditionTypeDegraded) {
+			t.Errorf("expected an unused network which is not degraded, got %+v", status.Conditions)
+		}
+	})
+
+	t.Run("invalid spec", func(t *testing.T) {
+		network.Spec.PodName = nil
+		setNetworkStatus(network, assignedAt)
+		ready := conditions.Get(network, v1.NetworkConditionTypeReady)
+		if ready == nil || ready.Status != v1.ConditionFalse || ready.Reason != v1.ReasonNetworkInvalid || ready.Message != "podName is not set" {
+			t.Errorf("expected the network not to be ready, got %+v", ready)
+		}
+	})
+}
+
+func TestNetworkLeasesIgnoresOtherNamespaces(t *testing.T) {
+	network := testIPNetwork("net-10", "10", 29)
+	other := ipAddressLease("other", v1.LeaseIPAddressRequest{}, "net-10")
+	other.Namespace = "elsewhere"
+	defer setupTestLeases(map[string]*v1.Lease{"elsewhere/other": other})()
+
+	if got := networkLeases(network); len(got) != 0 {
+		t.Errorf("expected no owners, got %v", got)
+	}
+}
+
+func sharedNetworkLease(name string, networkType v1.NetworkType, networkNames ...string) *v1.Lease {
+	lease := ipAddressLease(name, v1.LeaseIPAddressRequest{}, networkNames...)
+	lease.Spec.IPAddresses = nil
+	lease.Spec.NetworkType = networkType
+	return lease
+}
+
+func TestNetworkAcceptsLease(t *testing.T) {
+	network := testGangNetwork("net-10", "pod1", "10")
+
+	tests := []struct {
+		name       string
+		maxTenants int
+		tenants    []*v1.Lease
+		lease      *v1.Lease
+		expected   bool
+	}{
+		{name: "unowned network", maxTenants: 1, lease: sharedNetworkLease("new", v1.NetworkTypeSingleTenant), expected: true},
+		{
+			name:       "room for another tenant",
+			maxTenants: 3,
+			tenants:    []*v1.Lease{sharedNetworkLease("a", v1.NetworkTypeMultiTenant, "net-10")},
+			lease:      sharedNetworkLease("new", v1.NetworkTypeMultiTenant),
+			expected:   true,
+		},
+		{
+			name:       "every tenant slot taken",
+			maxTenants: 2,
+			tenants:    []*v1.Lease{sharedNetworkLease("a", v1.NetworkTypeMultiTenant, "net-10"), sharedNetworkLease("b", v1.NetworkTypeMultiTenant, "net-10")},
+			lease:      sharedNetworkLease("new", v1.NetworkTypeMultiTenant),
+		},
+		{
+			name:    "default of a single tenant",
+			tenants: []*v1.Lease{sharedNetworkLease("a", v1.NetworkTypeMultiTenant, "net-10")},
+			lease:   sharedNetworkLease("new", v1.NetworkTypeMultiTenant),
+		},
+		{
+			name:       "single-tenant leases never share",
+			maxTenants: 3,
+			tenants:    []*v1.Lease{sharedNetworkLease("a", v1.NetworkTypeMultiTenant, "net-10")},
+			lease:      sharedNetworkLease("new", v1.NetworkTypeSingleTenant),
+		},
+		{
+			name:       "different network type",
+			maxTenants: 3,
+			tenants:    []*v1.Lease{sharedNetworkLease("a", v1.NetworkTypeSingleTenant, "net-10")},
+			lease:      sharedNetworkLease("new", v1.NetworkTypeMultiTenant),
+		},
+		{
+			name:       "already held by the lease",
+			maxTenants: 3,
+			tenants:    []*v1.Lease{sharedNetworkLease("new", v1.NetworkTypeMultiTenant, "net-10")},
+			lease:      sharedNetworkLease("new", v1.NetworkTypeMultiTenant, "net-10"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cached := make(map[string]*v1.Lease)
+			for _, tenant := range tt.tenants {
+				cached["default/"+tenant.Name] = tenant
+			}
+			defer setupTestLeases(cached)()
+			network.Spec.MaxTenants = tt.maxTenants
+
+			if got := networkAcceptsLease(tt.lease, network); got != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestSharedNetworkAvailability(t *testing.T) {
+	pool := testGangPool("pool-a", "vc1", "pod1", 100, "pg-10", "pg-11")
+	shared := testGangNetwork("net-10", "pod1", "10")
+	shared.Labels = map[string]string{v1.NetworkTypeLabel: string(v1.NetworkTypeMultiTenant)}
+	shared.Spec.MaxTenants = 2
+	exclusive := testGangNetwork("net-11", "pod1", "11")
+	exclusive.Labels = map[string]string{v1.NetworkTypeLabel: string(v1.NetworkTypeMultiTenant)}
+
+	holding := func(name string) *v1.Lease {
+		lease := sharedNetworkLease(name, v1.NetworkTypeMultiTenant, "net-10")
+		lease.OwnerReferences = append(lease.OwnerReferences, metav1.OwnerReference{Kind: "Pool", Name: "pool-a"})
+		lease.Status.Server = "vc1"
+		lease.Status.Topology.Networks = []string{"/dc1/network/pg-10"}
+		return lease
+	}
+	first := holding("first")
+	defer setupGangInventory([]*v1.Pool{pool}, []*v1.Network{shared, exclusive}, first)()
+
+	reconciler := &LeaseReconciler{}
+	waiting := sharedNetworkLease("waiting", v1.NetworkTypeMultiTenant)
+
+	reconcilePoolStates()
+	if pool.Status.NetworkAvailable != 2 {
+		t.Errorf("expected the shared network to still be available, got %d networks available", pool.Status.NetworkAvailable)
+	}
+	if got := reconciler.getAvailableNetworks(waiting, pool, v1.NetworkTypeMultiTenant); len(got) != 2 {
+		t.Errorf("expected both networks to be available, got %d", len(got))
+	}
+
+	leases["default/second"] = holding("second")
+	reconcilePoolStates()
+	if pool.Status.NetworkAvailable != 1 {
+		t.Errorf("expected only the unshared network to be available, got %d networks available", pool.Status.NetworkAvailable)
+	}
+	got := reconciler.getAvailableNetworks(waiting, pool, v1.NetworkTypeMultiTenant)
+	if len(got) != 1 || got[0].Name != "net-11" {
+		t.Errorf("expected only net-11 to be available, got %v", got)
+	}
+
+	updateNetworkTypeMetrics()
+	if count := testutil.ToFloat64(NetworkLeaseCount.WithLabelValues("default", "net-10", "multi-tenant", "pool-a")); count != 2 {
+		t.Errorf("expected net-10 to be used by 2 leases, got %v", count)
+	}
+	if maxTenants := testutil.ToFloat64(NetworkMaxTenants.WithLabelValues("default", "net-10", "multi-tenant", "pool-a")); maxTenants != 2 {
+		t.Errorf("expected net-10 to allow 2 tenants, got %v", maxTenants)
+	}
+}
@@ -0,0 +1,299 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/netip"
+	"reflect"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1 "github.com/openshift-splat-team/vsphere-capacity-manager/pkg/apis/vspherecapacitymanager.splat.io/v1"
+)
+
+type NetworkSplitReconciler struct {
+	client.Client
+	Scheme     *runtime.Scheme
+	Recorder   record.EventRecorder
+	RESTMapper meta.RESTMapper
+}
+
+func (r *NetworkSplitReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if err := ctrl.NewControllerManagedBy(mgr).
+		For(&v1.NetworkSplit{}).
+		Owns(&v1.Network{}).
+		Complete(r); err != nil {
+		return fmt.Errorf("error setting up controller: %w", err)
+	}
+
+	// Set up API helpers from the manager.
+	r.Client = mgr.GetClient()
+	r.Scheme = mgr.GetScheme()
+	r.Recorder = mgr.GetEventRecorderFor("networksplits-controller")
+	r.RESTMapper = mgr.GetRESTMapper()
+
+	return nil
+}
+
+func (r *NetworkSplitReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log.Print("Reconciling network split")
+	defer log.Print("Finished reconciling network split")
+
+	reconcileLock.Lock()
+	defer reconcileLock.Unlock()
+
+	split := &v1.NetworkSplit{}
+	if err := r.Get(ctx, req.NamespacedName, split); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	children := &v1.NetworkList{}
+	if err := r.List(ctx, children, client.InNamespace(split.Namespace), client.MatchingLabels{v1.NetworkSplitLabel: split.Name}); err != nil {
+		return ctrl.Result{}, fmt.Errorf("error listing networks of network split: %w", err)
+	}
+	leased := leasedNetworks(children.Items)
+
+	previous := split.Status.DeepCopy()
+	status := &split.Status
+	result := ctrl.Result{}
+
+	if split.DeletionTimestamp != nil {
+		if len(leased) > 0 {
+			status.Message = fmt.Sprintf("deletion waits until networks %s are released", strings.Join(leased, ", "))
+			result.RequeueAfter = LEASE_PENDING_RETRY_INTERVAL
+		} else {
+			for i := range children.Items {
+				if err := r.Delete(ctx, &children.Items[i]); client.IgnoreNotFound(err) != nil {
+					return ctrl.Result{}, fmt.Errorf("error deleting network %s: %w", children.Items[i].Name, err)
+				}
+			}
+			log.Printf("deleted %d networks of network split %s", len(children.Items), split.Name)
+			split.Finalizers = nil
+			if err := r.Update(ctx, split); err != nil {
+				return ctrl.Result{}, fmt.Errorf("error updating network split: %w", err)
+			}
+			return ctrl.Result{}, nil
+		}
+	} else {
+		if split.Finalizers == nil {
+			log.Print("setting finalizer on network split")
+			split.Finalizers = []string{v1.NetworkSplitFinalizer}
+			if err := r.Update(ctx, split); err != nil {
+				return ctrl.Result{}, fmt.Errorf("error setting network split finalizer: %w", err)
+			}
+		}
+
+		shards, err := networkSplitShards(split)
+		create, update, remove := planNetworkSplit(children.Items, shards)
+		switch {
+		case err != nil:
+			status.Message = err.Error()
+		case len(create)+len(update)+len(remove) == 0:
+			status.Message = ""
+		case len(leased) > 0:
+			status.Message = fmt.Sprintf("re-sharding waits until networks %s are released", strings.Join(leased, ", "))
+			result.RequeueAfter = LEASE_PENDING_RETRY_INTERVAL
+		default:
+			if err := r.applyNetworkSplit(ctx, create, update, remove); err != nil {
+				return ctrl.Result{}, err
+			}
+			log.Printf("network split %s created %d, updated %d and deleted %d networks", split.Name, len(create), len(update), len(remove))
+			status.Message = ""
+		}
+		if err == nil && len(status.Message) == 0 {
+			status.Networks = nil
+			for _, shard := range shards {
+				status.Networks = append(status.Networks, shard.Name)
+			}
+			status.Shards = len(shards)
+			status.ShardSize = len(shards[0].Spec.IpAddresses)
+		}
+	}
+
+	if !reflect.DeepEqual(status, previous) {
+		if err := r.Client.Status().Update(ctx, split); err != nil {
+			return ctrl.Result{}, fmt.Errorf("error updating network split status: %w", err)
+		}
+	}
+
+	return result, nil
+}
+
+// applyNetworkSplit creates, updates and deletes the networks of a network split.
+func (r *NetworkSplitReconciler) applyNetworkSplit(ctx context.Context, create, update, remove []*v1.Network) error {
+	for _, network := range create {
+		if err := r.Create(ctx, network); err != nil {
+			return fmt.Errorf("error creating network %s: %w", network.Name, err)
+		}
+	}
+	for _, network := range update {
+		if err := r.Update(ctx, network); err != nil {
+			return fmt.Errorf("error updating network %s: %w", network.Name, err)
+		}
+	}
+	for _, network := range remove {
+		if err := r.Delete(ctx, network); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("error deleting network %s: %w", network.Name, err)
+		}
+	}
+	return nil
+}
+
+// leasedNetworks returns the names of the networks owned by a lease, sorted by name.
+func leasedNetworks(networkList []v1.Network) []string {
+	var names []string
+	for i := range networkList {
+		if len(networkTenants(&networkList[i])) > 0 {
+			names = append(names, networkList[i].Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// networkSplitShards returns the networks generated by the split. The assignable addresses of the
+// subnet, which leave out the network, gateway and broadcast addresses, are given to the networks
+// in consecutive ranges.
+func networkSplitShards(split *v1.NetworkSplit) ([]*v1.Network, error) {
+	prefix, err := netip.ParsePrefix(split.Spec.MachineNetworkCidr)
+	if err != nil || !prefix.Addr().Is4() {
+		return nil, fmt.Errorf("machineNetworkCidr %q is not a valid IPv4 CIDR", split.Spec.MachineNetworkCidr)
+	}
+	prefix = prefix.Masked()
+	if prefix.Bits() < 16 {
+		return nil, fmt.Errorf("machineNetworkCidr %s is larger than a /16", prefix)
+	}
+	gateway, err := netip.ParseAddr(split.Spec.Gateway)
+	if err != nil || !prefix.Contains(gateway) {
+		return nil, fmt.Errorf("gateway %q is not an address of %s", split.Spec.Gateway, prefix)
+	}
+
+	bits := prefix.Bits()
+	gatewayAddress := gateway.String()
+	subnet := &v1.Network{Spec: v1.NetworkSpec{MachineNetworkCidr: prefix.String(), Gateway: &gatewayAddress}}
+	reserved := networkReservedAddresses(subnet)
+	var addresses []string
+	for addr := prefix.Addr(); prefix.Contains(addr); addr = addr.Next() {
+		if !reserved[addr] {
+			addresses = append(addresses, addr.String())
+		}
+	}
+
+	shards, shardSize := split.Spec.Shards, split.Spec.ShardSize
+	switch {
+	case shards > 0 && shardSize == 0:
+		shardSize = len(addresses) / shards
+	case shardSize > 0 && shards == 0:
+		shards = len(addresses) / shardSize
+	case shards == 0 && shardSize == 0:
+		return nil, fmt.Errorf("either shards or shardSize must be set")
+	}
+	if shards == 0 || shardSize == 0 || shards*shardSize > len(addresses) {
+		return nil, fmt.Errorf("the %d addresses of %s can not be split into %d networks of %d addresses",
+			len(addresses), prefix, max(shards, split.Spec.Shards, 1), max(shardSize, split.Spec.ShardSize, 1))
+	}
+
+	networkType := split.Spec.NetworkType
+	if len(networkType) == 0 {
+		networkType = v1.NetworkTypeMultiTenant
+	}
+
+	var networkList []*v1.Network
+	for i := 0; i < shards; i++ {
+		ipAddresses := append([]string(nil), addresses[i*shardSize:(i+1)*shardSize]...)
+		ipAddressCount := uint(len(ipAddresses))
+		network := &v1.Network{
+			TypeMeta: metav1.TypeMeta{Kind: v1.NetworkKind, APIVersion: v1.GroupVersion.String()},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      fmt.Sprintf("%s-%d", split.Name, i+1),
+				Namespace: split.Namespace,
+				Labels: map[string]string{
+					v1.NetworkTypeLabel:  string(networkType),
+					v1.NetworkSplitLabel: split.Name,
+				},
+				OwnerReferences: []metav1.OwnerReference{{
+					APIVersion: v1.GroupVersion.String(),
+					Kind:       v1.NetworkSplitKind,
+					Name:       split.Name,
+					UID:        split.UID,
+					Controller: ptr.To(true),
+				}},
+			},
+			Spec: v1.NetworkSpec{
+				PortGroupName:         fmt.Sprintf("%s-%d", split.Spec.PortGroupName, i+1),
+				VlanId:                split.Spec.VlanId,
+				Cidr:                  &bits,
+				Gateway:               &gatewayAddress,
+				IpAddressCount:        &ipAddressCount,
+				MachineNetworkCidr:    prefix.String(),
+				IpAddresses:           ipAddresses,
+				PrimaryRouterHostname: split.Spec.PrimaryRouterHostname,
+				Nameservers:           split.Spec.Nameservers,
+			},
+		}
+		if len(split.Spec.PodName) > 0 {
+			podName := split.Spec.PodName
+			network.Spec.PodName = &podName
+		}
+		if len(split.Spec.DatacenterName) > 0 {
+			datacenterName := split.Spec.DatacenterName
+			network.Spec.DatacenterName = &datacenterName
+		}
+		networkList = append(networkList, network)
+	}
+	return networkList, nil
+}
+
+// planNetworkSplit compares the networks of a split with the shards it generates. It returns the
+// shards to create, the existing networks updated to match their shard and the networks which no
+// longer have a shard.
+func planNetworkSplit(children []v1.Network, shards []*v1.Network) (create, update, remove []*v1.Network) {
+	existing := make(map[string]*v1.Network)
+	for i := range children {
+		existing[children[i].Name] = &children[i]
+	}
+
+	for _, shard := range shards {
+		network, exists := existing[shard.Name]
+		if !exists {
+			create = append(create, shard)
+			continue
+		}
+		delete(existing, shard.Name)
+
+		changed := !reflect.DeepEqual(network.Spec, shard.Spec)
+		for key, value := range shard.Labels {
+			if network.Labels[key] != value {
+				changed = true
+			}
+		}
+		if !changed {
+			continue
+		}
+		network.Spec = shard.Spec
+		if network.Labels == nil {
+			network.Labels = make(map[string]string)
+		}
+		for key, value := range shard.Labels {
+			network.Labels[key] = value
+		}
+		update = append(update, network)
+	}
+
+	for _, network := range existing {
+		remove = append(remove, network)
+	}
+	sort.Slice(remove, func(i, j int) bool {
+		return remove[i].Name < remove[j].Name
+	})
+	return create, update, remove
+}
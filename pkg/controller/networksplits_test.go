@@ -0,0 +1,174 @@
+package controller
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/openshift-splat-team/vsphere-capacity-manager/pkg/apis/vspherecapacitymanager.splat.io/v1"
+)
+
+func testNetworkSplit(shards, shardSize int) *v1.NetworkSplit {
+	return &v1.NetworkSplit{
+		ObjectMeta: metav1.ObjectMeta{Name: "vlan-10", Namespace: "default", UID: "split-uid"},
+		Spec: v1.NetworkSplitSpec{
+			PortGroupName:      "pg-10",
+			VlanId:             "10",
+			PodName:            "pod1",
+			MachineNetworkCidr: "192.168.10.0/28",
+			Gateway:            "192.168.10.1",
+			Shards:             shards,
+			ShardSize:          shardSize,
+		},
+	}
+}
+
+func TestNetworkSplitShards(t *testing.T) {
+	tests := []struct {
+		name      string
+		split     *v1.NetworkSplit
+		expected  [][]string
+		expectErr string
+	}{
+		{
+			name:  "divides the assignable addresses evenly",
+			split: testNetworkSplit(3, 0),
+			expected: [][]string{
+				{"192.168.10.2", "192.168.10.3", "192.168.10.4", "192.168.10.5"},
+				{"192.168.10.6", "192.168.10.7", "192.168.10.8", "192.168.10.9"},
+				{"192.168.10.10", "192.168.10.11", "192.168.10.12", "192.168.10.13"},
+			},
+		},
+		{
+			name:  "as many shards of a size as fit",
+			split: testNetworkSplit(0, 6),
+			expected: [][]string{
+				{"192.168.10.2", "192.168.10.3", "192.168.10.4", "192.168.10.5", "192.168.10.6", "192.168.10.7"},
+				{"192.168.10.8", "192.168.10.9", "192.168.10.10", "192.168.10.11", "192.168.10.12", "192.168.10.13"},
+			},
+		},
+		{
+			name:     "both shards and size",
+			split:    testNetworkSplit(1, 2),
+			expected: [][]string{{"192.168.10.2", "192.168.10.3"}},
+		},
+		{name: "neither shards nor size", split: testNetworkSplit(0, 0), expectErr: "either shards or shardSize must be set"},
+		{name: "shards too large", split: testNetworkSplit(4, 4), expectErr: "can not be split into 4 networks of 4 addresses"},
+		{name: "size larger than the subnet", split: testNetworkSplit(0, 14), expectErr: "can not be split into 1 networks of 14 addresses"},
+		{
+			name: "gateway outside of the subnet",
+			split: func() *v1.NetworkSplit {
+				split := testNetworkSplit(2, 0)
+				split.Spec.Gateway = "192.168.20.1"
+				return split
+			}(),
+			expectErr: `gateway "192.168.20.1" is not an address of 192.168.10.0/28`,
+		},
+		{
+			name: "IPv6 subnet",
+			split: func() *v1.NetworkSplit {
+				split := testNetworkSplit(2, 0)
+				split.Spec.MachineNetworkCidr = "fd00::/64"
+				return split
+			}(),
+			expectErr: `machineNetworkCidr "fd00::/64" is not a valid IPv4 CIDR`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shards, err := networkSplitShards(tt.split)
+			if len(tt.expectErr) > 0 {
+				if err == nil || !strings.Contains(err.Error(), tt.expectErr) {
+					t.Errorf("expected error %q, got %v", tt.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var got [][]string
+			for _, shard := range shards {
+				got = append(got, shard.Spec.IpAddresses)
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestNetworkSplitShardNetworks(t *testing.T) {
+	shards, err := networkSplitShards(testNetworkSplit(2, 0))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	shard := shards[1]
+	if shard.Name != "vlan-10-2" || shard.Namespace != "default" || shard.Spec.PortGroupName != "pg-10-2" {
+		t.Errorf("expected the second shard to be named after the split, got %s/%s on %s", shard.Namespace, shard.Name, shard.Spec.PortGroupName)
+	}
+	if shard.Labels[v1.NetworkTypeLabel] != string(v1.NetworkTypeMultiTenant) || shard.Labels[v1.NetworkSplitLabel] != "vlan-10" {
+		t.Errorf("expected a multi-tenant network labeled with the split, got %v", shard.Labels)
+	}
+	if len(shard.OwnerReferences) != 1 || shard.OwnerReferences[0].Kind != v1.NetworkSplitKind || shard.OwnerReferences[0].UID != "split-uid" {
+		t.Errorf("expected the shard to be owned by the split, got %+v", shard.OwnerReferences)
+	}
+	if *shard.Spec.PodName != "pod1" || *shard.Spec.Cidr != 28 || *shard.Spec.IpAddressCount != 6 || shard.Spec.DatacenterName != nil {
+		t.Errorf("unexpected shard spec %+v", shard.Spec)
+	}
+	if problems := networkSpecProblems(shard); len(problems) > 0 {
+		t.Errorf("expected a valid network, got %v", problems)
+	}
+	if got := len(assignableAddresses(shard)); got != 6 {
+		t.Errorf("expected every address of the shard to be assignable, got %d", got)
+	}
+}
+
+func TestPlanNetworkSplit(t *testing.T) {
+	shards, err := networkSplitShards(testNetworkSplit(3, 0))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	old, err := networkSplitShards(testNetworkSplit(2, 0))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	first := *shards[0].DeepCopy()
+	first.Labels["unrelated"] = "kept"
+	stale := *old[1].DeepCopy()
+	extra := *testGangNetwork("vlan-10-9", "pod1", "10")
+	children := []v1.Network{first, stale, extra}
+
+	create, update, remove := planNetworkSplit(children, shards)
+	if len(create) != 1 || create[0].Name != "vlan-10-3" {
+		t.Errorf("expected vlan-10-3 to be created, got %v", create)
+	}
+	if len(update) != 1 || update[0].Name != "vlan-10-2" || !reflect.DeepEqual(update[0].Spec, shards[1].Spec) {
+		t.Errorf("expected vlan-10-2 to be updated to its new range, got %v", update)
+	}
+	if len(remove) != 1 || remove[0].Name != "vlan-10-9" {
+		t.Errorf("expected vlan-10-9 to be deleted, got %v", remove)
+	}
+
+	create, update, remove = planNetworkSplit([]v1.Network{first, *shards[1], *shards[2]}, shards)
+	if len(create)+len(update)+len(remove) > 0 {
+		t.Errorf("expected networks matching their shards to be left alone, got %v, %v and %v", create, update, remove)
+	}
+}
+
+func TestLeasedNetworks(t *testing.T) {
+	shards, err := networkSplitShards(testNetworkSplit(3, 0))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer setupTestLeases(map[string]*v1.Lease{
+		"default/lease": sharedNetworkLease("lease", v1.NetworkTypeMultiTenant, "vlan-10-3", "vlan-10-1"),
+	})()
+
+	children := []v1.Network{*shards[0], *shards[1], *shards[2]}
+	if got := leasedNetworks(children); !reflect.DeepEqual(got, []string{"vlan-10-1", "vlan-10-3"}) {
+		t.Errorf("expected vlan-10-1 and vlan-10-3 to be leased, got %v", got)
+	}
+}
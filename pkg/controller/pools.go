@@ -4,11 +4,12 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"strconv"
+	"path"
 	"strings"
 
 	generator "github.com/docker/docker/pkg/namesgenerator"
 	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/tools/record"
@@ -16,6 +17,8 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	v1 "github.com/openshift-splat-team/vsphere-capacity-manager/pkg/apis/vspherecapacitymanager.splat.io/v1"
+	"github.com/openshift-splat-team/vsphere-capacity-manager/pkg/utils"
+	"github.com/openshift-splat-team/vsphere-capacity-manager/pkg/utils/conditions"
 )
 
 type PoolReconciler struct {
@@ -117,9 +120,15 @@ func (l *PoolReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.
 		}
 	}
 
+	if _, _, _, err := utils.PoolOverCommitRatios(pool); err != nil {
+		log.Printf("pool %s has invalid overcommit ratios, they are treated as 1: %v", pool.Name, err)
+		if l.Recorder != nil {
+			l.Recorder.Eventf(pool, corev1.EventTypeWarning, v1.ReasonInvalidOverCommit, "invalid overcommit ratios are treated as 1: %v", err)
+		}
+	}
+
 	if !pool.Status.Initialized {
-		pool.Status.VCpusAvailable = pool.Spec.VCpus
-		pool.Status.MemoryAvailable = pool.Spec.Memory
+		pool.Status.VCpusAvailable, pool.Status.MemoryAvailable, pool.Status.DatastoreAvailable = utils.PoolCapacity(pool)
 		pool.Status.Initialized = true
 	}
 
@@ -129,6 +138,15 @@ func (l *PoolReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.
 	for _, reconciledPool := range reconciledPools {
 		if reconciledPool.Name == req.Name {
 			reconciledPool.Status.DeepCopyInto(&pool.Status)
+			previous := conditions.Get(pool, v1.PoolConditionTypeNetworksResolved)
+			setPoolNetworksResolved(pool)
+			if resolved := conditions.Get(pool, v1.PoolConditionTypeNetworksResolved); resolved.Status == v1.ConditionFalse &&
+				(previous == nil || previous.Message != resolved.Message) {
+				log.Printf("pool %s has unresolved networks: %s", pool.Name, resolved.Message)
+				if l.Recorder != nil {
+					l.Recorder.Event(pool, corev1.EventTypeWarning, v1.ReasonDanglingNetworks, resolved.Message)
+				}
+			}
 			err := l.Client.Status().Update(ctx, pool)
 			if err != nil {
 				return ctrl.Result{}, fmt.Errorf("error updating pool status: %w", err)
@@ -144,23 +162,32 @@ func (l *PoolReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.
 	PoolMemoryAvailable.With(promLabels).Set(float64(pool.Status.MemoryAvailable))
 	PoolMemoryTotal.With(promLabels).Set(float64(pool.Spec.Memory))
 	PoolNetworksAvailable.With(promLabels).Set(float64(pool.Status.NetworkAvailable))
-	PoolNetworksTotal.With(promLabels).Set(float64(len(pool.Spec.Topology.Networks)))
+	networksTotal := float64(len(getNetworksForPool(pool)))
+	PoolNetworksTotal.With(promLabels).Set(networksTotal)
 	PoolCpusAvailable.With(promLabels).Set(float64(pool.Status.VCpusAvailable))
 	PoolCpusTotal.With(promLabels).Set(float64(pool.Spec.VCpus))
+	PoolStorageAvailable.With(promLabels).Set(float64(pool.Status.DatastoreAvailable))
+	PoolStorageTotal.With(promLabels).Set(float64(pool.Spec.Storage))
 	LeasesInUse.With(promLabels).Set(float64(pool.Status.LeaseCount))
 
-	overCommitRatio, err := strconv.ParseFloat(pool.Spec.OverCommitRatio, 64)
-	if err != nil {
-		overCommitRatio = 1.0
+	PoolExtendedResourceAvailable.DeletePartialMatch(promLabels)
+	PoolExtendedResourceTotal.DeletePartialMatch(promLabels)
+	for name, available := range pool.Status.ExtendedResourcesAvailable {
+		resourceLabels := prometheus.Labels{"namespace": req.Namespace, "pool": req.Name, "resource": name}
+		PoolExtendedResourceAvailable.With(resourceLabels).Set(float64(available))
+		PoolExtendedResourceTotal.With(resourceLabels).Set(float64(pool.Spec.ExtendedResources[name]))
+	}
+
+	vcpusCapacity, memoryCapacity, storageCapacity := utils.PoolCapacity(pool)
+	if vcpusCapacity > 0 {
+		PoolVcpusUtilizationRatio.With(promLabels).Set(float64(vcpusCapacity-pool.Status.VCpusAvailable) / float64(vcpusCapacity))
 	}
-	effectiveCpus := float64(pool.Spec.VCpus) * overCommitRatio
-	if effectiveCpus > 0 {
-		PoolVcpusUtilizationRatio.With(promLabels).Set((effectiveCpus - float64(pool.Status.VCpusAvailable)) / effectiveCpus)
+	if memoryCapacity > 0 {
+		PoolMemoryUtilizationRatio.With(promLabels).Set(float64(memoryCapacity-pool.Status.MemoryAvailable) / float64(memoryCapacity))
 	}
-	if pool.Spec.Memory > 0 {
-		PoolMemoryUtilizationRatio.With(promLabels).Set(float64(pool.Spec.Memory-pool.Status.MemoryAvailable) / float64(pool.Spec.Memory))
+	if storageCapacity > 0 {
+		PoolStorageUtilizationRatio.With(promLabels).Set(float64(storageCapacity-pool.Status.DatastoreAvailable) / float64(storageCapacity))
 	}
-	networksTotal := float64(len(pool.Spec.Topology.Networks))
 	if networksTotal > 0 {
 		PoolNetworksUtilizationRatio.With(promLabels).Set((networksTotal - float64(pool.Status.NetworkAvailable)) / networksTotal)
 	}
@@ -179,3 +206,47 @@ func (l *PoolReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.
 
 	return ctrl.Result{}, nil
 }
+
+// poolNetworkProblems returns the topology networks and networkRefs of the pool which match no
+// network. Leases are never assigned these networks.
+func poolNetworkProblems(pool *v1.Pool) []string {
+	portGroups := make(map[string]bool)
+	for _, network := range getNetworksForPool(pool) {
+		portGroups[network.Spec.PortGroupName] = true
+	}
+
+	var problems []string
+	for _, networkPath := range pool.Spec.Topology.Networks {
+		if _, portGroup := path.Split(networkPath); !portGroups[portGroup] {
+			problems = append(problems, fmt.Sprintf("topology network %s matches no network", networkPath))
+		}
+	}
+	for _, networkName := range pool.Spec.NetworkRefs {
+		if _, exists := networks[fmt.Sprintf("%s/%s", pool.Namespace, networkName)]; !exists {
+			problems = append(problems, fmt.Sprintf("networkRef %s does not exist", networkName))
+		}
+	}
+	return problems
+}
+
+// setPoolNetworksResolved sets the NetworksResolved condition of the pool from its network problems.
+func setPoolNetworksResolved(pool *v1.Pool) {
+	problems := poolNetworkProblems(pool)
+	if len(problems) == 0 {
+		conditions.Set(pool, conditions.TrueCondition(v1.PoolConditionTypeNetworksResolved))
+		return
+	}
+	conditions.Set(pool, conditions.FalseConditionWithReason(
+		v1.PoolConditionTypeNetworksResolved, v1.ReasonDanglingNetworks, v1.ConditionSeverityWarning,
+		"%s", strings.Join(problems, "; ")))
+}
+
+// poolNetworksResolvedStale returns whether the NetworksResolved condition of the pool no longer
+// matches the networks in the cache.
+func poolNetworksResolvedStale(pool *v1.Pool) bool {
+	updated := pool.DeepCopy()
+	setPoolNetworksResolved(updated)
+	previous := conditions.Get(pool, v1.PoolConditionTypeNetworksResolved)
+	resolved := conditions.Get(updated, v1.PoolConditionTypeNetworksResolved)
+	return previous == nil || previous.Status != resolved.Status || previous.Message != resolved.Message
+}
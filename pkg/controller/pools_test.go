@@ -0,0 +1,136 @@
+package controller
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	v1 "github.com/openshift-splat-team/vsphere-capacity-manager/pkg/apis/vspherecapacitymanager.splat.io/v1"
+	"github.com/openshift-splat-team/vsphere-capacity-manager/pkg/utils/conditions"
+)
+
+func poolNetworkNames(pool *v1.Pool) []string {
+	var names []string
+	for name := range getNetworksForPool(pool) {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestGetNetworksForPool(t *testing.T) {
+	podless := testGangNetwork("net-podless", "pod1", "13")
+	podless.Spec.PodName = nil
+	elsewhere := testGangNetwork("net-12", "pod1", "12")
+	elsewhere.Namespace = "elsewhere"
+	networkList := []*v1.Network{
+		testGangNetwork("net-10", "pod1", "10"),
+		testGangNetwork("net-11", "pod2", "11"),
+		podless,
+	}
+
+	t.Run("matches topology port groups in the pod of the pool", func(t *testing.T) {
+		pool := testGangPool("pool-a", "vc1", "pod1", 100, "pg-10", "pg-11", "pg-13")
+		defer setupGangInventory([]*v1.Pool{pool}, networkList, &v1.Lease{})()
+
+		if got := poolNetworkNames(pool); !reflect.DeepEqual(got, []string{"net-10"}) {
+			t.Errorf("expected only net-10, got %v", got)
+		}
+	})
+
+	t.Run("networkRefs replace topology matching", func(t *testing.T) {
+		pool := testGangPool("pool-a", "vc1", "pod1", 100, "pg-10")
+		pool.Spec.NetworkRefs = []string{"net-11", "net-12", "missing"}
+		defer setupGangInventory([]*v1.Pool{pool}, networkList, &v1.Lease{})()
+		networks["elsewhere/net-12"] = elsewhere
+
+		if got := poolNetworkNames(pool); !reflect.DeepEqual(got, []string{"net-11"}) {
+			t.Errorf("expected only the referenced network in the namespace of the pool, got %v", got)
+		}
+	})
+}
+
+func TestSetPoolNetworksResolved(t *testing.T) {
+	pool := testGangPool("pool-a", "vc1", "pod1", 100, "pg-10", "pg-typo")
+	defer setupGangInventory([]*v1.Pool{pool}, []*v1.Network{testGangNetwork("net-10", "pod1", "10")}, &v1.Lease{})()
+
+	setPoolNetworksResolved(pool)
+	resolved := conditions.Get(pool, v1.PoolConditionTypeNetworksResolved)
+	if resolved == nil || resolved.Status != v1.ConditionFalse || resolved.Reason != v1.ReasonDanglingNetworks ||
+		resolved.Message != "topology network /dc1/network/pg-typo matches no network" {
+		t.Errorf("expected the dangling topology network to be reported, got %+v", resolved)
+	}
+
+	pool.Spec.NetworkRefs = []string{"net-10", "net-typo"}
+	expected := []string{
+		"topology network /dc1/network/pg-typo matches no network",
+		"networkRef net-typo does not exist",
+	}
+	if got := poolNetworkProblems(pool); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+
+	pool.Spec.NetworkRefs = nil
+	pool.Spec.Topology.Networks = pool.Spec.Topology.Networks[:1]
+	setPoolNetworksResolved(pool)
+	if !conditions.IsTrue(pool, v1.PoolConditionTypeNetworksResolved) {
+		t.Errorf("expected every network to be resolved, got %+v", pool.Status.Conditions)
+	}
+}
+
+func TestPoolNetworksResolvedStale(t *testing.T) {
+	pool := testGangPool("pool-a", "vc1", "pod1", 100, "pg-10", "pg-11")
+	networkList := []*v1.Network{testGangNetwork("net-10", "pod1", "10")}
+	defer setupGangInventory([]*v1.Pool{pool}, networkList, &v1.Lease{})()
+
+	if !poolNetworksResolvedStale(pool) {
+		t.Errorf("expected a pool without the condition to be stale")
+	}
+
+	setPoolNetworksResolved(pool)
+	if poolNetworksResolvedStale(pool) {
+		t.Errorf("expected the condition to match the cache, got %+v", pool.Status.Conditions)
+	}
+
+	networks["default/net-11"] = testGangNetwork("net-11", "pod1", "11")
+	if !poolNetworksResolvedStale(pool) {
+		t.Errorf("expected the added network to make the condition stale")
+	}
+
+	setPoolNetworksResolved(pool)
+	delete(networks, "default/net-10")
+	if !poolNetworksResolvedStale(pool) {
+		t.Errorf("expected the deleted network to make the condition stale")
+	}
+}
+
+func TestDanglingTopologyNetworksAreNotAvailable(t *testing.T) {
+	pool := testGangPool("pool-a", "vc1", "pod1", 100, "pg-10", "pg-11", "pg-typo")
+	networkList := []*v1.Network{testGangNetwork("net-10", "pod1", "10"), testGangNetwork("net-11", "pod1", "11")}
+	defer setupGangInventory([]*v1.Pool{pool}, networkList, &v1.Lease{})()
+
+	reconcilePoolStates()
+	if pool.Status.NetworkAvailable != 2 {
+		t.Errorf("expected only the 2 networks which exist to be available, got %d", pool.Status.NetworkAvailable)
+	}
+}
+
+func TestNetworkBoundCondition(t *testing.T) {
+	pool := testGangPool("pool-a", "vc1", "pod1", 100, "pg-10")
+	bound := testIPNetwork("net-10", "10", 29)
+	bound.Spec.PortGroupName = "pg-10"
+	unbound := testIPNetwork("net-11", "11", 29)
+	defer setupGangInventory([]*v1.Pool{pool}, []*v1.Network{bound, unbound}, &v1.Lease{})()
+
+	setNetworkStatus(bound, time.Now())
+	if !reflect.DeepEqual(bound.Status.Pools, []string{"pool-a"}) || !conditions.IsTrue(bound, v1.NetworkConditionTypeBound) {
+		t.Errorf("expected net-10 to be bound to pool-a, got %v and %+v", bound.Status.Pools, bound.Status.Conditions)
+	}
+
+	setNetworkStatus(unbound, time.Now())
+	condition := conditions.Get(unbound, v1.NetworkConditionTypeBound)
+	if len(unbound.Status.Pools) != 0 || condition == nil || condition.Status != v1.ConditionFalse || condition.Reason != v1.ReasonNetworkNotInPool {
+		t.Errorf("expected net-11 to belong to no pool, got %v and %+v", unbound.Status.Pools, condition)
+	}
+}
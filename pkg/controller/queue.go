@@ -0,0 +1,173 @@
+package controller
+
+import (
+	"sort"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	v1 "github.com/openshift-splat-team/vsphere-capacity-manager/pkg/apis/vspherecapacitymanager.splat.io/v1"
+	"github.com/openshift-splat-team/vsphere-capacity-manager/pkg/utils"
+	"github.com/openshift-splat-team/vsphere-capacity-manager/pkg/utils/conditions"
+)
+
+// MAX_LEASE_HOLD_TIME_SAMPLES is the number of recent hold times kept per network type to
+// estimate how long pending leases wait.
+const MAX_LEASE_HOLD_TIME_SAMPLES = 100
+
+// recordLeaseHoldTime remembers how long a fulfilled lease held its resources before it was
+// released.
+func recordLeaseHoldTime(lease *v1.Lease, now time.Time) {
+	if lease.Status.Phase != v1.PHASE_FULFILLED {
+		return
+	}
+	fulfilled := conditions.Get(lease, v1.LeaseConditionTypeFulfilled)
+	if fulfilled == nil || fulfilled.Status != v1.ConditionTrue || fulfilled.LastTransitionTime.IsZero() {
+		return
+	}
+
+	holdTime := now.Sub(fulfilled.LastTransitionTime.Time)
+	LeaseHoldSeconds.With(prometheus.Labels{
+		"networkType": string(lease.Spec.NetworkType),
+	}).Observe(holdTime.Seconds())
+
+	samples := append(leaseHoldTimes[lease.Spec.NetworkType], holdTime)
+	if len(samples) > MAX_LEASE_HOLD_TIME_SAMPLES {
+		samples = samples[len(samples)-MAX_LEASE_HOLD_TIME_SAMPLES:]
+	}
+	leaseHoldTimes[lease.Spec.NetworkType] = samples
+}
+
+// seedLeaseHoldTimes fills the hold times of a network type that has none yet, as after a restart,
+// with how long the fulfilled leases in the cache have held their resources so far. These are
+// lower bounds of their hold times, and are pushed out as released leases are recorded.
+func seedLeaseHoldTimes(networkType v1.NetworkType, now time.Time) {
+	if len(leaseHoldTimes[networkType]) > 0 {
+		return
+	}
+	var samples []time.Duration
+	for _, lease := range leases {
+		if lease.Status.Phase != v1.PHASE_FULFILLED || lease.Spec.NetworkType != networkType {
+			continue
+		}
+		fulfilled := conditions.Get(lease, v1.LeaseConditionTypeFulfilled)
+		if fulfilled == nil || fulfilled.Status != v1.ConditionTrue || fulfilled.LastTransitionTime.IsZero() {
+			continue
+		}
+		samples = append(samples, now.Sub(fulfilled.LastTransitionTime.Time))
+	}
+	if len(samples) > MAX_LEASE_HOLD_TIME_SAMPLES {
+		samples = samples[len(samples)-MAX_LEASE_HOLD_TIME_SAMPLES:]
+	}
+	leaseHoldTimes[networkType] = samples
+}
+
+// averageHoldTime returns the mean of the hold times, or 0 when there are none.
+func averageHoldTime(holdTimes []time.Duration) time.Duration {
+	if len(holdTimes) == 0 {
+		return 0
+	}
+	var total time.Duration
+	for _, holdTime := range holdTimes {
+		total += holdTime
+	}
+	return total / time.Duration(len(holdTimes))
+}
+
+// estimateLeaseWait estimates how long a lease at the given queue position waits. Each held
+// lease is expected to be released once it reaches the average hold time, after which its
+// capacity goes to the next lease in the queue and is held for the average hold time again.
+// The lease at position n starts when the n-th release happens.
+func estimateLeaseWait(position int, averageHold time.Duration, heldAges []time.Duration) time.Duration {
+	if position < 1 || averageHold <= 0 {
+		return 0
+	}
+	if len(heldAges) == 0 {
+		return time.Duration(position-1) * averageHold
+	}
+
+	remaining := make([]time.Duration, 0, len(heldAges))
+	for _, age := range heldAges {
+		remaining = append(remaining, max(averageHold-age, 0))
+	}
+	sort.Slice(remaining, func(i, j int) bool { return remaining[i] < remaining[j] })
+
+	cycles := (position - 1) / len(remaining)
+	return remaining[(position-1)%len(remaining)] + time.Duration(cycles)*averageHold
+}
+
+// contendedLeaseAges returns how long the fulfilled leases holding capacity the lease could use
+// have held it: leases of the same network type, on the lease's required pool if it has one.
+func contendedLeaseAges(lease *v1.Lease, now time.Time) []time.Duration {
+	var ages []time.Duration
+	for _, heldLease := range leases {
+		if heldLease.Status.Phase != v1.PHASE_FULFILLED || heldLease.Spec.NetworkType != lease.Spec.NetworkType {
+			continue
+		}
+		if len(lease.Spec.RequiredPool) > 0 {
+			holdsPool := false
+			for _, poolRef := range utils.GetLeasePoolRefs(heldLease) {
+				if poolRef.Name == lease.Spec.RequiredPool {
+					holdsPool = true
+					break
+				}
+			}
+			if !holdsPool {
+				continue
+			}
+		}
+		fulfilled := conditions.Get(heldLease, v1.LeaseConditionTypeFulfilled)
+		if fulfilled == nil || fulfilled.LastTransitionTime.IsZero() {
+			continue
+		}
+		ages = append(ages, now.Sub(fulfilled.LastTransitionTime.Time))
+	}
+	return ages
+}
+
+// setLeaseQueueStatus records the queue position of a pending lease behind the leases blocking
+// it, and estimates its wait once hold times of released leases are known.
+func setLeaseQueueStatus(lease *v1.Lease, blockers []*v1.Lease, now time.Time) {
+	position := len(blockers) + 1
+	lease.Status.QueuePosition = int32(position)
+	lease.Status.EstimatedWaitSeconds = nil
+
+	seedLeaseHoldTimes(lease.Spec.NetworkType, now)
+	averageHold := averageHoldTime(leaseHoldTimes[lease.Spec.NetworkType])
+	if averageHold <= 0 {
+		return
+	}
+	wait := int64(estimateLeaseWait(position, averageHold, contendedLeaseAges(lease, now)).Seconds())
+	lease.Status.EstimatedWaitSeconds = &wait
+}
+
+// clearLeaseQueueStatus removes the queue position of a lease that no longer waits in the queue.
+func clearLeaseQueueStatus(lease *v1.Lease) {
+	lease.Status.QueuePosition = 0
+	lease.Status.EstimatedWaitSeconds = nil
+}
+
+// updateLeaseQueueMetrics exports the number of pending leases in the queue and the longest
+// estimated wait among them, per namespace and network type.
+func updateLeaseQueueMetrics() {
+	LeaseQueueLength.Reset()
+	LeaseEstimatedWaitSeconds.Reset()
+	longestWaits := make(map[[2]string]int64)
+	for _, lease := range leases {
+		if lease.Status.QueuePosition == 0 || (lease.Status.Phase != v1.PHASE_PENDING && lease.Status.Phase != v1.PHASE_PARTIAL) {
+			continue
+		}
+		promLabels := prometheus.Labels{
+			"namespace":   lease.Namespace,
+			"networkType": string(lease.Spec.NetworkType),
+		}
+		LeaseQueueLength.With(promLabels).Inc()
+		if lease.Status.EstimatedWaitSeconds != nil {
+			key := [2]string{lease.Namespace, string(lease.Spec.NetworkType)}
+			if wait, exists := longestWaits[key]; !exists || *lease.Status.EstimatedWaitSeconds > wait {
+				longestWaits[key] = *lease.Status.EstimatedWaitSeconds
+				LeaseEstimatedWaitSeconds.With(promLabels).Set(float64(*lease.Status.EstimatedWaitSeconds))
+			}
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/openshift-splat-team/vsphere-capacity-manager/pkg/apis/vspherecapacitymanager.splat.io/v1"
+)
+
+func setupTestLeaseHoldTimes(holdTimes map[v1.NetworkType][]time.Duration) func() {
+	old := leaseHoldTimes
+	leaseHoldTimes = holdTimes
+	return func() { leaseHoldTimes = old }
+}
+
+func fulfilledQueueLease(name string, fulfilledAt time.Time) *v1.Lease {
+	return &v1.Lease{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec:       v1.LeaseSpec{NetworkType: v1.NetworkTypeSingleTenant},
+		Status: v1.LeaseStatus{
+			Phase: v1.PHASE_FULFILLED,
+			Conditions: []v1.Condition{{
+				Type:               v1.LeaseConditionTypeFulfilled,
+				Status:             v1.ConditionTrue,
+				LastTransitionTime: metav1.NewTime(fulfilledAt),
+			}},
+		},
+	}
+}
+
+func TestEstimateLeaseWait(t *testing.T) {
+	tests := []struct {
+		name        string
+		position    int
+		averageHold time.Duration
+		heldAges    []time.Duration
+		expected    time.Duration
+	}{
+		{name: "no hold times", position: 3, heldAges: []time.Duration{time.Hour}},
+		{name: "first in queue, nothing held", position: 1, averageHold: time.Hour},
+		{name: "nothing held waits for leases ahead", position: 3, averageHold: time.Hour, expected: 2 * time.Hour},
+		{name: "first in queue waits for the oldest lease", position: 1, averageHold: 4 * time.Hour, heldAges: []time.Duration{time.Hour, 3 * time.Hour}, expected: time.Hour},
+		{name: "second in queue waits for the next release", position: 2, averageHold: 4 * time.Hour, heldAges: []time.Duration{time.Hour, 3 * time.Hour}, expected: 3 * time.Hour},
+		{name: "later positions wait for another hold cycle", position: 3, averageHold: 4 * time.Hour, heldAges: []time.Duration{time.Hour, 3 * time.Hour}, expected: 5 * time.Hour},
+		{name: "overdue leases are expected to be released now", position: 1, averageHold: time.Hour, heldAges: []time.Duration{2 * time.Hour}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := estimateLeaseWait(tt.position, tt.averageHold, tt.heldAges); got != tt.expected {
+				t.Errorf("expected wait %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestRecordLeaseHoldTime(t *testing.T) {
+	defer setupTestLeaseHoldTimes(make(map[v1.NetworkType][]time.Duration))()
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	recordLeaseHoldTime(fulfilledQueueLease("held", now.Add(-2*time.Hour)), now)
+
+	pending := &v1.Lease{Spec: v1.LeaseSpec{NetworkType: v1.NetworkTypeSingleTenant}}
+	pending.Status.Phase = v1.PHASE_PENDING
+	recordLeaseHoldTime(pending, now)
+
+	samples := leaseHoldTimes[v1.NetworkTypeSingleTenant]
+	if len(samples) != 1 || samples[0] != 2*time.Hour {
+		t.Fatalf("expected one hold time of 2h, got %v", samples)
+	}
+
+	for i := 0; i < MAX_LEASE_HOLD_TIME_SAMPLES; i++ {
+		recordLeaseHoldTime(fulfilledQueueLease("held", now.Add(-time.Hour)), now)
+	}
+	if samples := leaseHoldTimes[v1.NetworkTypeSingleTenant]; len(samples) != MAX_LEASE_HOLD_TIME_SAMPLES || samples[0] != time.Hour {
+		t.Errorf("expected the oldest hold times to be dropped, got %d samples starting at %v", len(samples), samples[0])
+	}
+}
+
+func TestSetLeaseQueueStatus(t *testing.T) {
+	now := time.Now()
+	held := fulfilledQueueLease("held", now.Add(-time.Hour))
+	waiting := &v1.Lease{
+		ObjectMeta: metav1.ObjectMeta{Name: "waiting", Namespace: "default"},
+		Spec:       v1.LeaseSpec{NetworkType: v1.NetworkTypeSingleTenant},
+		Status:     v1.LeaseStatus{Phase: v1.PHASE_PENDING},
+	}
+	defer setupTestLeases(map[string]*v1.Lease{
+		"default/held":    held,
+		"default/waiting": waiting,
+	})()
+
+	t.Run("no estimate without hold times", func(t *testing.T) {
+		defer setupTestLeaseHoldTimes(make(map[v1.NetworkType][]time.Duration))()
+		defer setupTestLeases(map[string]*v1.Lease{"default/waiting": waiting})()
+
+		setLeaseQueueStatus(waiting, nil, now)
+		if waiting.Status.QueuePosition != 1 {
+			t.Errorf("expected queue position 1, got %d", waiting.Status.QueuePosition)
+		}
+		if waiting.Status.EstimatedWaitSeconds != nil {
+			t.Errorf("expected no estimated wait, got %d", *waiting.Status.EstimatedWaitSeconds)
+		}
+	})
+
+	t.Run("hold times seeded from fulfilled leases", func(t *testing.T) {
+		holdTimes := make(map[v1.NetworkType][]time.Duration)
+		defer setupTestLeaseHoldTimes(holdTimes)()
+
+		setLeaseQueueStatus(waiting, nil, now)
+		if samples := holdTimes[v1.NetworkTypeSingleTenant]; len(samples) != 1 || samples[0] != time.Hour {
+			t.Errorf("expected the held lease to seed a hold time of 1h, got %v", samples)
+		}
+		// the held lease is expected to be released now, having reached the average hold time
+		if waiting.Status.EstimatedWaitSeconds == nil || *waiting.Status.EstimatedWaitSeconds != 0 {
+			t.Errorf("expected an estimated wait of 0s, got %v", waiting.Status.EstimatedWaitSeconds)
+		}
+	})
+
+	t.Run("estimate from hold times and held leases", func(t *testing.T) {
+		defer setupTestLeaseHoldTimes(map[v1.NetworkType][]time.Duration{
+			v1.NetworkTypeSingleTenant: {2 * time.Hour, 4 * time.Hour},
+		})()
+
+		blocker := &v1.Lease{ObjectMeta: metav1.ObjectMeta{Name: "blocker"}}
+		setLeaseQueueStatus(waiting, []*v1.Lease{blocker}, now)
+		if waiting.Status.QueuePosition != 2 {
+			t.Errorf("expected queue position 2, got %d", waiting.Status.QueuePosition)
+		}
+		// the held lease is released in 2h, then the blocker holds it for 3h
+		expected := int64((5 * time.Hour).Seconds())
+		if waiting.Status.EstimatedWaitSeconds == nil || *waiting.Status.EstimatedWaitSeconds != expected {
+			t.Errorf("expected an estimated wait of %ds, got %v", expected, waiting.Status.EstimatedWaitSeconds)
+		}
+
+		clearLeaseQueueStatus(waiting)
+		if waiting.Status.QueuePosition != 0 || waiting.Status.EstimatedWaitSeconds != nil {
+			t.Errorf("expected the queue status to be cleared, got %+v", waiting.Status)
+		}
+	})
+}
+
+func TestUpdateLeaseQueueMetrics(t *testing.T) {
+	queued := func(name string, position int32, wait int64) *v1.Lease {
+		return &v1.Lease{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec:       v1.LeaseSpec{NetworkType: v1.NetworkTypeSingleTenant},
+			Status:     v1.LeaseStatus{Phase: v1.PHASE_PENDING, QueuePosition: position, EstimatedWaitSeconds: &wait},
+		}
+	}
+	defer setupTestLeases(map[string]*v1.Lease{
+		"default/first":  queued("first", 1, 600),
+		"default/second": queued("second", 2, 4200),
+		"default/held":   fulfilledQueueLease("held", time.Now()),
+	})()
+
+	updateLeaseQueueMetrics()
+	if length := testutil.ToFloat64(LeaseQueueLength.WithLabelValues("default", "single-tenant")); length != 2 {
+		t.Errorf("expected a queue length of 2, got %v", length)
+	}
+	if wait := testutil.ToFloat64(LeaseEstimatedWaitSeconds.WithLabelValues("default", "single-tenant")); wait != 4200 {
+		t.Errorf("expected the longest estimated wait of 4200s, got %v", wait)
+	}
+}
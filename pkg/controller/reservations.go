@@ -0,0 +1,422 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"reflect"
+	"sort"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1 "github.com/openshift-splat-team/vsphere-capacity-manager/pkg/apis/vspherecapacitymanager.splat.io/v1"
+	"github.com/openshift-splat-team/vsphere-capacity-manager/pkg/utils"
+)
+
+type ReservationReconciler struct {
+	client.Client
+	Scheme     *runtime.Scheme
+	Recorder   record.EventRecorder
+	RESTMapper meta.RESTMapper
+}
+
+func (r *ReservationReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if err := ctrl.NewControllerManagedBy(mgr).
+		For(&v1.Reservation{}).
+		Complete(r); err != nil {
+		return fmt.Errorf("error setting up controller: %w", err)
+	}
+
+	// Set up API helpers from the manager.
+	r.Client = mgr.GetClient()
+	r.Scheme = mgr.GetScheme()
+	r.Recorder = mgr.GetEventRecorderFor("reservations-controller")
+	r.RESTMapper = mgr.GetRESTMapper()
+
+	return nil
+}
+
+func (r *ReservationReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log.Print("Reconciling reservation")
+	defer log.Print("Finished reconciling reservation")
+
+	reconcileLock.Lock()
+	defer reconcileLock.Unlock()
+
+	reservationKey := fmt.Sprintf("%s/%s", req.Namespace, req.Name)
+
+	reservation := &v1.Reservation{}
+	if err := r.Get(ctx, req.NamespacedName, reservation); err != nil {
+		if client.IgnoreNotFound(err) == nil {
+			delete(reservations, reservationKey)
+		}
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if reservation.DeletionTimestamp != nil {
+		delete(reservations, reservationKey)
+		return ctrl.Result{}, nil
+	}
+
+	reservations[reservationKey] = reservation
+
+	now := time.Now()
+	previous := reservation.Status.DeepCopy()
+	status := &reservation.Status
+	result := ctrl.Result{}
+
+	switch {
+	case now.Before(reservation.Spec.Start.Time):
+		status.Phase = v1.ReservationPhasePending
+		status.Message = ""
+		result.RequeueAfter = reservation.Spec.Start.Sub(now)
+	case now.Before(reservation.Spec.End.Time):
+		status.Phase = v1.ReservationPhaseActive
+		status.Message = ""
+		result.RequeueAfter = reservation.Spec.End.Sub(now)
+
+		if len(status.Pool) == 0 {
+			status.Pool = reservation.Spec.Pool
+		}
+		if len(status.Pool) == 0 {
+			status.Pool = selectReservationPool(reservation)
+		}
+		if len(status.Pool) == 0 {
+			status.Message = fmt.Sprintf("no pool matching the selector has %d vCPUs and %dGB of memory free", reservation.Spec.VCpus, reservation.Spec.Memory)
+		} else {
+			status.Networks = holdReservationNetworks(reservation, status.Pool, status.Networks)
+			if len(status.Networks) < reservation.Spec.Networks {
+				status.Message = fmt.Sprintf("holding %d of %d networks, the rest are still owned by leases", len(status.Networks), reservation.Spec.Networks)
+			}
+		}
+		if len(status.Message) > 0 && result.RequeueAfter > LEASE_PENDING_RETRY_INTERVAL {
+			result.RequeueAfter = LEASE_PENDING_RETRY_INTERVAL
+		}
+	default:
+		status.Phase = v1.ReservationPhaseExpired
+		status.Message = ""
+		status.Networks = nil
+	}
+
+	status.Used = reservationUsage(reservation)
+
+	if !reflect.DeepEqual(status, previous) {
+		log.Printf("reservation %s is %s on pool '%s'", reservation.Name, reservation.Status.Phase, reservation.Status.Pool)
+		if err := r.Client.Status().Update(ctx, reservation); err != nil {
+			return ctrl.Result{}, fmt.Errorf("error updating reservation status: %w", err)
+		}
+	}
+
+	return result, nil
+}
+
+// reservationActive reports whether the reservation holds capacity at the given time.
+func reservationActive(reservation *v1.Reservation, now time.Time) bool {
+	return !now.Before(reservation.Spec.Start.Time) && now.Before(reservation.Spec.End.Time)
+}
+
+// reservationPool returns the name of the pool the reservation holds capacity on, or an empty
+// string when the pool selector has not been resolved yet.
+func reservationPool(reservation *v1.Reservation) string {
+	if len(reservation.Spec.Pool) > 0 {
+		return reservation.Spec.Pool
+	}
+	return reservation.Status.Pool
+}
+
+// selectReservationPool returns the schedulable pool matching the reservation's pool selector
+// with the most free vCPUs, among the pools with room for the reservation.
+func selectReservationPool(reservation *v1.Reservation) string {
+	if reservation.Spec.PoolSelector == nil {
+		return ""
+	}
+	selector, err := metav1.LabelSelectorAsSelector(reservation.Spec.PoolSelector)
+	if err != nil {
+		log.Printf("reservation %s has an invalid pool selector: %v", reservation.Name, err)
+		return ""
+	}
+
+	var selected *v1.Pool
+	for _, pool := range reconcilePoolStates() {
+		if pool.Spec.NoSchedule || pool.Spec.Exclude || !selector.Matches(labels.Set(pool.Labels)) {
+			continue
+		}
+		if pool.Status.VCpusAvailable < reservation.Spec.VCpus || pool.Status.MemoryAvailable < reservation.Spec.Memory {
+			continue
+		}
+		if selected == nil || pool.Status.VCpusAvailable > selected.Status.VCpusAvailable ||
+			(pool.Status.VCpusAvailable == selected.Status.VCpusAvailable && pool.Name < selected.Name) {
+			selected = pool
+		}
+	}
+	if selected == nil {
+		return ""
+	}
+	return selected.Name
+}
+
+// holdReservationNetworks tops up the networks held by the reservation with networks of its
+// network type that are neither owned by a lease nor held by another reservation.
+func holdReservationNetworks(reservation *v1.Reservation, poolName string, held []string) []string {
+	if len(held) >= reservation.Spec.Networks {
+		return held
+	}
+
+	var pool *v1.Pool
+	for _, p := range pools {
+		if p.Name == poolName {
+			pool = p
+			break
+		}
+	}
+	if pool == nil {
+		return held
+	}
+
+	networkType := reservation.Spec.NetworkType
+	if len(networkType) == 0 {
+		networkType = v1.NetworkTypeSingleTenant
+	}
+
+	heldByOthers := heldNetworks(time.Now())
+	poolNetworks := getNetworksForPool(pool)
+	names := make([]string, 0, len(poolNetworks))
+	for name := range poolNetworks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if len(held) >= reservation.Spec.Networks {
+			break
+		}
+		network := poolNetworks[name]
+		if getNetworkType(network) != string(networkType) || networkOwnedByLease(network) {
+			continue
+		}
+		if _, ok := heldByOthers[network.Name]; ok {
+			continue
+		}
+		held = append(held, network.Name)
+	}
+	return held
+}
+
+// networkOwnedByLease reports whether any lease holds the network.
+func networkOwnedByLease(network *v1.Network) bool {
+	for _, lease := range leases {
+		for _, ownerRef := range lease.OwnerReferences {
+			if ownerRef.Name == network.Name && ownerRef.Kind == network.Kind {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// heldNetworks returns the networks held by active reservations, keyed by network name.
+func heldNetworks(now time.Time) map[string]*v1.Reservation {
+	held := make(map[string]*v1.Reservation)
+	for _, reservation := range reservations {
+		if !reservationActive(reservation, now) {
+			continue
+		}
+		for _, name := range reservation.Status.Networks {
+			held[name] = reservation
+		}
+	}
+	return held
+}
+
+// leaseConsumesReservation reports whether the lease references the reservation.
+func leaseConsumesReservation(lease *v1.Lease, reservation *v1.Reservation) bool {
+	return lease.Namespace == reservation.Namespace && lease.Spec.ReservationName == reservation.Name
+}
+
+// reservationUsage sums the capacity consumed by the leases holding the reservation's pool.
+func reservationUsage(reservation *v1.Reservation) v1.ReservationUsage {
+	var used v1.ReservationUsage
+	poolName := reservationPool(reservation)
+	if len(poolName) == 0 {
+		return used
+	}
+
+	for _, lease := range leases {
+		if !leaseConsumesReservation(lease, reservation) {
+			continue
+		}
+
+		holdsPool := false
+		for _, ref := range utils.GetLeasePoolRefs(lease) {
+			if ref.Name == poolName {
+				holdsPool = true
+				break
+			}
+		}
+		if !holdsPool {
+			continue
+		}
+
+		allocated := leaseAllocatedResources(lease)
+		used.VCpus += allocated.VCpus
+		used.Memory += allocated.Memory
+		used.Leases++
+		for _, name := range reservation.Status.Networks {
+			for _, ref := range lease.OwnerReferences {
+				if ref.Kind == "Network" && ref.Name == name {
+					used.Networks++
+					break
+				}
+			}
+		}
+	}
+	return used
+}
+
+// reservationRemaining returns the vCPUs, memory and held networks of the reservation that are
+// not consumed by leases yet.
+func reservationRemaining(reservation *v1.Reservation) (int, int, []*v1.Network) {
+	used := reservationUsage(reservation)
+
+	var freeNetworks []*v1.Network
+	for _, name := range reservation.Status.Networks {
+		for _, network := range networks {
+			if network.Name == name && !networkOwnedByLease(network) {
+				freeNetworks = append(freeNetworks, network)
+				break
+			}
+		}
+	}
+
+	return max(reservation.Spec.VCpus-used.VCpus, 0), max(reservation.Spec.Memory-used.Memory, 0), freeNetworks
+}
+
+// reservationHolds returns the vCPUs, memory and networks held on the pool by active
+// reservations and not consumed yet. reconcilePoolStates counts them like a lease.
+func reservationHolds(pool *v1.Pool, now time.Time) (int, int, int) {
+	vcpus, memory, networkCount := 0, 0, 0
+	for _, reservation := range reservations {
+		if !reservationActive(reservation, now) || reservationPool(reservation) != pool.Name {
+			continue
+		}
+		remainingVCpus, remainingMemory, freeNetworks := reservationRemaining(reservation)
+		vcpus += remainingVCpus
+		memory += remainingMemory
+		networkCount += len(freeNetworks)
+	}
+	return vcpus, memory, networkCount
+}
+
+// leaseReservation returns the reservation the lease consumes, or a message explaining why it
+// can not be used yet.
+func leaseReservation(lease *v1.Lease) (*v1.Reservation, string) {
+	reservation, ok := reservations[fmt.Sprintf("%s/%s", lease.Namespace, lease.Spec.ReservationName)]
+	if !ok {
+		return nil, fmt.Sprintf("reservation %s does not exist", lease.Spec.ReservationName)
+	}
+
+	now := time.Now()
+	if now.Before(reservation.Spec.Start.Time) {
+		return nil, fmt.Sprintf("reservation %s starts at %s", reservation.Name, reservation.Spec.Start.UTC().Format(time.RFC3339))
+	}
+	if !now.Before(reservation.Spec.End.Time) {
+		return nil, fmt.Sprintf("reservation %s ended at %s", reservation.Name, reservation.Spec.End.UTC().Format(time.RFC3339))
+	}
+	if len(reservationPool(reservation)) == 0 {
+		return nil, fmt.Sprintf("reservation %s has not selected a pool yet", reservation.Name)
+	}
+	return reservation, ""
+}
+
+// leaseFitsReservation reports whether the lease consumes an active reservation with enough
+// vCPUs, memory and held networks remaining for it. Leases that do not fit need shared capacity,
+// and queue for it like any other lease.
+func leaseFitsReservation(lease *v1.Lease) bool {
+	if len(lease.Spec.ReservationName) == 0 {
+		return false
+	}
+	reservation, msg := leaseReservation(lease)
+	if len(msg) > 0 {
+		return false
+	}
+	vcpus, memory, freeNetworks := reservationRemaining(reservation)
+	return lease.Spec.VCpus <= vcpus && lease.Spec.Memory <= memory && lease.Spec.Networks <= len(freeNetworks)
+}
+
+// poolsForLease returns the pools the lease may be scheduled on. Leases consuming a reservation
+// only see the reservation's pool, with the capacity the reservation still holds added back.
+func poolsForLease(lease *v1.Lease, allPools []*v1.Pool) []*v1.Pool {
+	if len(lease.Spec.ReservationName) == 0 {
+		return allPools
+	}
+
+	reservation, msg := leaseReservation(lease)
+	if len(msg) > 0 {
+		return nil
+	}
+
+	poolName := reservationPool(reservation)
+	for _, pool := range allPools {
+		if pool.Name != poolName {
+			continue
+		}
+		vcpus, memory, freeNetworks := reservationRemaining(reservation)
+		reserved := pool.DeepCopy()
+		reserved.Status.VCpusAvailable += vcpus
+		reserved.Status.MemoryAvailable += memory
+		reserved.Status.NetworkAvailable += len(freeNetworks)
+		return []*v1.Pool{reserved}
+	}
+	return nil
+}
+
+// getReservedNetworks returns the networks held for the lease's reservation on the pool that
+// are not owned by a lease yet.
+func (l *LeaseReconciler) getReservedNetworks(lease *v1.Lease, pool *v1.Pool) []*v1.Network {
+	if len(lease.Spec.ReservationName) == 0 {
+		return nil
+	}
+	reservation, msg := leaseReservation(lease)
+	if len(msg) > 0 || reservationPool(reservation) != pool.Name {
+		return nil
+	}
+
+	_, _, freeNetworks := reservationRemaining(reservation)
+	var reserved []*v1.Network
+	for _, network := range freeNetworks {
+		if getNetworkType(network) == string(lease.Spec.NetworkType) && networkHasAddressesFor(lease, network) {
+			reserved = append(reserved, network)
+		}
+	}
+	return reserved
+}
+
+// triggerReservationUpdates touches every reservation so that its usage is recomputed after
+// leases are fulfilled or released.
+func (l *LeaseReconciler) triggerReservationUpdates(ctx context.Context) {
+	for _, reservation := range reservations {
+		err := l.Client.Get(ctx, types.NamespacedName{Name: reservation.Name, Namespace: reservation.Namespace}, reservation)
+		if err != nil {
+			log.Printf("error getting reservation %s: %v", reservation.Name, err)
+			continue
+		}
+
+		if reservation.Annotations == nil {
+			reservation.Annotations = make(map[string]string)
+		}
+
+		reservation.Annotations["last-updated"] = time.Now().Format(time.RFC3339)
+		err = l.Client.Update(ctx, reservation)
+		if err != nil {
+			log.Printf("error updating reservation %s annotations: %v", reservation.Name, err)
+		}
+	}
+}
@@ -0,0 +1,263 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/openshift-splat-team/vsphere-capacity-manager/pkg/apis/vspherecapacitymanager.splat.io/v1"
+)
+
+func setupTestReservations(reservationList ...*v1.Reservation) func() {
+	old := reservations
+	reservations = make(map[string]*v1.Reservation)
+	for _, reservation := range reservationList {
+		reservations[reservation.Namespace+"/"+reservation.Name] = reservation
+	}
+	return func() { reservations = old }
+}
+
+func testReservation(name, pool string, vcpus int, start, end time.Time, heldNetworks ...string) *v1.Reservation {
+	return &v1.Reservation{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec: v1.ReservationSpec{
+			Pool:     pool,
+			VCpus:    vcpus,
+			Memory:   vcpus * 4,
+			Networks: len(heldNetworks),
+			Start:    metav1.NewTime(start),
+			End:      metav1.NewTime(end),
+		},
+		Status: v1.ReservationStatus{Networks: heldNetworks},
+	}
+}
+
+func TestReconcilePoolStatesReservations(t *testing.T) {
+	now := time.Now()
+
+	consumer := testBackfillLease("consumer", 8, "")
+	consumer.Spec.ReservationName = "workshop"
+	consumer.OwnerReferences = []metav1.OwnerReference{
+		{Kind: "Pool", Name: "pool-a"},
+		{Kind: "Network", Name: "pod1-101"},
+	}
+
+	tests := []struct {
+		name             string
+		reservation      *v1.Reservation
+		leases           []*v1.Lease
+		vcpusAvailable   int
+		memoryAvailable  int
+		networkAvailable int
+	}{
+		{
+			name:             "pending reservation holds nothing",
+			reservation:      testReservation("workshop", "pool-a", 40, now.Add(time.Hour), now.Add(2*time.Hour), "pod1-100"),
+			vcpusAvailable:   100,
+			memoryAvailable:  400,
+			networkAvailable: 2,
+		},
+		{
+			name:             "active reservation holds its capacity",
+			reservation:      testReservation("workshop", "pool-a", 40, now.Add(-time.Hour), now.Add(time.Hour), "pod1-100"),
+			vcpusAvailable:   60,
+			memoryAvailable:  240,
+			networkAvailable: 1,
+		},
+		{
+			name:             "expired reservation holds nothing",
+			reservation:      testReservation("workshop", "pool-a", 40, now.Add(-2*time.Hour), now.Add(-time.Hour), "pod1-100"),
+			vcpusAvailable:   100,
+			memoryAvailable:  400,
+			networkAvailable: 2,
+		},
+		{
+			name:             "consuming leases use the held capacity",
+			reservation:      testReservation("workshop", "pool-a", 40, now.Add(-time.Hour), now.Add(time.Hour), "pod1-100", "pod1-101"),
+			leases:           []*v1.Lease{consumer},
+			vcpusAvailable:   60,
+			memoryAvailable:  240,
+			networkAvailable: 1,
+		},
+		{
+			name:             "consumption beyond the reservation uses the pool",
+			reservation:      testReservation("workshop", "pool-a", 4, now.Add(-time.Hour), now.Add(time.Hour)),
+			leases:           []*v1.Lease{consumer},
+			vcpusAvailable:   92,
+			memoryAvailable:  384,
+			networkAvailable: 2,
+		},
+		{
+			name:             "reservations on other pools are ignored",
+			reservation:      testReservation("workshop", "pool-b", 40, now.Add(-time.Hour), now.Add(time.Hour)),
+			vcpusAvailable:   100,
+			memoryAvailable:  400,
+			networkAvailable: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pool := testGangPool("pool-a", "vc1", "pod1", 100, "pg-100", "pg-101")
+			restore := setupGangInventory(
+				[]*v1.Pool{pool},
+				[]*v1.Network{testGangNetwork("pod1-100", "pod1", "100"), testGangNetwork("pod1-101", "pod1", "101")},
+				testBackfillLease("unrelated", 0, ""),
+			)
+			defer restore()
+			defer setupTestReservations(tt.reservation)()
+			for _, lease := range tt.leases {
+				leases["default/"+lease.Name] = lease
+			}
+
+			reconcilePoolStates()
+
+			if pool.Status.VCpusAvailable != tt.vcpusAvailable {
+				t.Errorf("expected %d vCPUs available, got %d", tt.vcpusAvailable, pool.Status.VCpusAvailable)
+			}
+			if pool.Status.MemoryAvailable != tt.memoryAvailable {
+				t.Errorf("expected %dGB memory available, got %d", tt.memoryAvailable, pool.Status.MemoryAvailable)
+			}
+			if pool.Status.NetworkAvailable != tt.networkAvailable {
+				t.Errorf("expected %d networks available, got %d", tt.networkAvailable, pool.Status.NetworkAvailable)
+			}
+		})
+	}
+}
+
+func TestPoolsForLease(t *testing.T) {
+	now := time.Now()
+	poolA := testGangPool("pool-a", "vc1", "pod1", 100, "pg-100")
+	poolB := testGangPool("pool-b", "vc1", "pod2", 100)
+	poolA.Status.VCpusAvailable = 60
+	poolB.Status.VCpusAvailable = 100
+	allPools := []*v1.Pool{poolA, poolB}
+
+	restore := setupGangInventory(allPools, []*v1.Network{testGangNetwork("pod1-100", "pod1", "100")}, testBackfillLease("unrelated", 0, ""))
+	defer restore()
+	defer setupTestReservations(
+		testReservation("active", "pool-a", 40, now.Add(-time.Hour), now.Add(time.Hour), "pod1-100"),
+		testReservation("upcoming", "pool-a", 40, now.Add(time.Hour), now.Add(2*time.Hour)),
+	)()
+
+	tests := []struct {
+		name            string
+		reservationName string
+		expectedPools   []string
+		vcpusAvailable  int
+	}{
+		{
+			name:           "lease without a reservation sees every pool",
+			expectedPools:  []string{"pool-a", "pool-b"},
+			vcpusAvailable: 60,
+		},
+		{
+			name:            "lease with an active reservation sees its pool with the held capacity",
+			reservationName: "active",
+			expectedPools:   []string{"pool-a"},
+			vcpusAvailable:  100,
+		},
+		{
+			name:            "lease with a reservation that has not started sees nothing",
+			reservationName: "upcoming",
+		},
+		{
+			name:            "lease with an unknown reservation sees nothing",
+			reservationName: "missing",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lease := testBackfillLease("lease", 8, "")
+			lease.Spec.ReservationName = tt.reservationName
+
+			result := poolsForLease(lease, allPools)
+			if len(result) != len(tt.expectedPools) {
+				t.Fatalf("expected pools %v, got %d pools", tt.expectedPools, len(result))
+			}
+			for i, pool := range result {
+				if pool.Name != tt.expectedPools[i] {
+					t.Errorf("expected pool %s, got %s", tt.expectedPools[i], pool.Name)
+				}
+			}
+			if len(result) > 0 && result[0].Status.VCpusAvailable != tt.vcpusAvailable {
+				t.Errorf("expected %d vCPUs available, got %d", tt.vcpusAvailable, result[0].Status.VCpusAvailable)
+			}
+		})
+	}
+
+	if poolA.Status.VCpusAvailable != 60 {
+		t.Errorf("expected the shared pool state to be left alone, got %d vCPUs available", poolA.Status.VCpusAvailable)
+	}
+}
+
+func TestGetAvailableNetworksReservations(t *testing.T) {
+	now := time.Now()
+	pool := testGangPool("pool-a", "vc1", "pod1", 100, "pg-100", "pg-101")
+	restore := setupGangInventory(
+		[]*v1.Pool{pool},
+		[]*v1.Network{testGangNetwork("pod1-100", "pod1", "100"), testGangNetwork("pod1-101", "pod1", "101")},
+		testBackfillLease("unrelated", 0, ""),
+	)
+	defer restore()
+	defer setupTestReservations(testReservation("workshop", "pool-a", 0, now.Add(-time.Hour), now.Add(time.Hour), "pod1-100"))()
+
+	reconciler := &LeaseReconciler{}
+
+	shared := reconciler.getAvailableNetworks(&v1.Lease{}, pool, v1.NetworkTypeSingleTenant)
+	if len(shared) != 1 || shared[0].Name != "pod1-101" {
+		t.Errorf("expected only the unreserved network to be available, got %v", shared)
+	}
+
+	consumer := testBackfillLease("consumer", 8, "")
+	consumer.Spec.ReservationName = "workshop"
+	consumer.Spec.NetworkType = v1.NetworkTypeSingleTenant
+	if reserved := reconciler.getReservedNetworks(consumer, pool); len(reserved) != 1 || reserved[0].Name != "pod1-100" {
+		t.Errorf("expected the held network to be reserved for the consuming lease, got %v", reserved)
+	}
+	if reserved := reconciler.getReservedNetworks(testBackfillLease("other", 8, ""), pool); len(reserved) != 0 {
+		t.Errorf("expected no reserved networks for other leases, got %v", reserved)
+	}
+}
+
+func TestGetBlockingLeasesReservations(t *testing.T) {
+	now := time.Now()
+	defer setupTestReservations(testReservation("workshop", "pool-a", 16, now.Add(-time.Hour), now.Add(time.Hour)))()
+
+	queued := func(name string, vcpus int, reservationName string, age time.Duration) *v1.Lease {
+		lease := testBackfillLease(name, vcpus, "")
+		lease.CreationTimestamp = metav1.NewTime(now.Add(-age))
+		lease.Spec.ReservationName = reservationName
+		return lease
+	}
+	older := queued("older", 8, "", 3*time.Hour)
+	fitting := queued("fitting", 8, "workshop", 2*time.Hour)
+	oversized := queued("oversized", 24, "workshop", 2*time.Hour)
+	newer := queued("newer", 8, "", time.Hour)
+	defer setupTestLeases(map[string]*v1.Lease{
+		"default/older":     older,
+		"default/fitting":   fitting,
+		"default/oversized": oversized,
+		"default/newer":     newer,
+	})()
+
+	if !leaseFitsReservation(fitting) || leaseFitsReservation(oversized) || leaseFitsReservation(older) {
+		t.Errorf("expected only the lease within the remaining 16 vCPUs of the reservation to fit")
+	}
+	if blockers := getBlockingLeases(fitting); len(blockers) != 0 {
+		t.Errorf("expected a lease fitting its reservation to skip the queue, got %d blockers", len(blockers))
+	}
+	if blockers := getBlockingLeases(oversized); len(blockers) != 1 || blockers[0].Name != "older" {
+		t.Errorf("expected a lease exceeding its reservation to queue behind older leases, got %v", blockers)
+	}
+	if blockers := getBlockingLeases(newer); len(blockers) != 2 {
+		t.Errorf("expected the older and oversized leases to block, got %v", blockers)
+	}
+	for _, blocker := range getBlockingLeases(newer) {
+		if blocker.Name == "fitting" {
+			t.Errorf("expected a lease fitting its reservation not to block others")
+		}
+	}
+}
@@ -0,0 +1,354 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"maps"
+	"sort"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	v1 "github.com/openshift-splat-team/vsphere-capacity-manager/pkg/apis/vspherecapacitymanager.splat.io/v1"
+	"github.com/openshift-splat-team/vsphere-capacity-manager/pkg/utils"
+	"github.com/openshift-splat-team/vsphere-capacity-manager/pkg/utils/conditions"
+)
+
+// leaseRequestedResources returns the per-pool resources in the spec of the lease.
+func leaseRequestedResources(lease *v1.Lease) v1.LeaseResources {
+	return v1.LeaseResources{
+		VCpus:    lease.Spec.VCpus,
+		Memory:   lease.Spec.Memory,
+		Storage:  lease.Spec.Storage,
+		Networks: lease.Spec.Networks,
+		// copied so the allocation recorded from it does not change with the spec
+		ExtendedResources: maps.Clone(lease.Spec.ExtendedResources),
+	}
+}
+
+// leaseAllocatedResources returns the per-pool resources held by the lease. Leases that were
+// never fulfilled, or were fulfilled before allocations were recorded, hold what they request.
+func leaseAllocatedResources(lease *v1.Lease) v1.LeaseResources {
+	if lease.Status.Allocated != nil {
+		return *lease.Status.Allocated
+	}
+	return leaseRequestedResources(lease)
+}
+
+// leaseResizeRequested reports whether the spec of a fulfilled lease differs from what it holds.
+func leaseResizeRequested(lease *v1.Lease) bool {
+	return lease.Status.Allocated == nil || !leaseResourcesEqual(*lease.Status.Allocated, leaseRequestedResources(lease))
+}
+
+// leaseResourcesEqual reports whether a and b hold the same amount of every resource. Extended
+// resources with an amount of zero are the same as missing ones.
+func leaseResourcesEqual(a, b v1.LeaseResources) bool {
+	if a.VCpus != b.VCpus || a.Memory != b.Memory || a.Storage != b.Storage || a.Networks != b.Networks {
+		return false
+	}
+	for _, name := range extendedResourceNames(a, b) {
+		if a.ExtendedResources[name] != b.ExtendedResources[name] {
+			return false
+		}
+	}
+	return true
+}
+
+// extendedResourceNames returns the names of the extended resources in either a or b, sorted.
+func extendedResourceNames(a, b v1.LeaseResources) []string {
+	names := make([]string, 0, len(a.ExtendedResources)+len(b.ExtendedResources))
+	for name := range a.ExtendedResources {
+		names = append(names, name)
+	}
+	for name := range b.ExtendedResources {
+		if _, exists := a.ExtendedResources[name]; !exists {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// resizeGrows reports whether requested needs more of any resource than allocated.
+func resizeGrows(requested, allocated v1.LeaseResources) bool {
+	if requested.VCpus > allocated.VCpus || requested.Memory > allocated.Memory ||
+		requested.Storage > allocated.Storage || requested.Networks > allocated.Networks {
+		return true
+	}
+	for name, amount := range requested.ExtendedResources {
+		if amount > allocated.ExtendedResources[name] {
+			return true
+		}
+	}
+	return false
+}
+
+// shrinkResources returns allocated with every resource that requested lowers set to the
+// requested amount.
+func shrinkResources(requested, allocated v1.LeaseResources) v1.LeaseResources {
+	shrunk := v1.LeaseResources{
+		VCpus:    min(requested.VCpus, allocated.VCpus),
+		Memory:   min(requested.Memory, allocated.Memory),
+		Storage:  min(requested.Storage, allocated.Storage),
+		Networks: min(requested.Networks, allocated.Networks),
+	}
+	for name, amount := range allocated.ExtendedResources {
+		if amount = min(requested.ExtendedResources[name], amount); amount > 0 {
+			if shrunk.ExtendedResources == nil {
+				shrunk.ExtendedResources = make(map[string]int)
+			}
+			shrunk.ExtendedResources[name] = amount
+		}
+	}
+	return shrunk
+}
+
+// resizeHeadroom checks that every assigned pool has room for the vCPUs, memory, storage and
+// extended resources a resize adds. Pool states must account for the lease's current allocation. Returns a message
+// describing the first shortfall, or an empty string when the growth fits.
+func resizeHeadroom(requested, allocated v1.LeaseResources, assignedPools []*v1.Pool) string {
+	for _, pool := range assignedPools {
+		if growth := requested.VCpus - allocated.VCpus; growth > 0 && pool.Status.VCpusAvailable < growth {
+			return fmt.Sprintf("pool %s has %d vCPUs available, resize needs %d more", pool.Name, pool.Status.VCpusAvailable, growth)
+		}
+		if growth := requested.Memory - allocated.Memory; growth > 0 && pool.Status.MemoryAvailable < growth {
+			return fmt.Sprintf("pool %s has %dGB of memory available, resize needs %dGB more", pool.Name, pool.Status.MemoryAvailable, growth)
+		}
+		if growth := requested.Storage - allocated.Storage; growth > 0 && pool.Status.DatastoreAvailable < growth {
+			return fmt.Sprintf("pool %s has %dGB of storage available, resize needs %dGB more", pool.Name, pool.Status.DatastoreAvailable, growth)
+		}
+		for _, name := range extendedResourceNames(requested, allocated) {
+			available := pool.Status.ExtendedResourcesAvailable[name]
+			if growth := requested.ExtendedResources[name] - allocated.ExtendedResources[name]; growth > 0 && available < growth {
+				return fmt.Sprintf("pool %s has %d %s available, resize needs %d more", pool.Name, available, name, growth)
+			}
+		}
+	}
+	return ""
+}
+
+// selectResizeNetworks picks count additional networks for every assigned pool of the lease. As
+// when the lease was fulfilled, the networks added to each pool are on the same VLANs, and on
+// port groups the lease does not hold yet. Returns an error when the pools do not have enough
+// VLAN-matched networks available.
+func (l *LeaseReconciler) selectResizeNetworks(lease *v1.Lease, assignedPools []*v1.Pool, count int) (map[string][]*v1.Network, error) {
+	poolCandidates := make(map[string][]*v1.Network)
+	for _, pool := range assignedPools {
+		candidates := append(l.getReservedNetworks(lease, pool), l.getAvailableNetworks(lease, pool, lease.Spec.NetworkType)...)
+		if l.AllowMultiToUseSingle && lease.Spec.NetworkType == v1.NetworkTypeMultiTenant {
+			candidates = append(candidates, l.getAvailableNetworks(lease, pool, v1.NetworkTypeSingleTenant)...)
+		}
+		for _, network := range candidates {
+			if !doesLeaseContainPortGroup(lease, pool, network) {
+				poolCandidates[pool.Name] = append(poolCandidates[pool.Name], network)
+			}
+		}
+	}
+
+	selected := make(map[string][]*v1.Network)
+	vlans := make(map[string]bool)
+	for _, network := range poolCandidates[assignedPools[0].Name] {
+		if len(vlans) == count {
+			break
+		}
+		if vlans[network.Spec.VlanId] {
+			continue
+		}
+
+		// the VLAN is only used if every pool has a network on it
+		matched := make(map[string]*v1.Network)
+		for _, pool := range assignedPools {
+			for _, candidate := range poolCandidates[pool.Name] {
+				if candidate.Spec.VlanId == network.Spec.VlanId {
+					matched[pool.Name] = candidate
+					break
+				}
+			}
+		}
+		if len(matched) != len(assignedPools) {
+			continue
+		}
+
+		vlans[network.Spec.VlanId] = true
+		for poolName, candidate := range matched {
+			selected[poolName] = append(selected[poolName], candidate)
+		}
+	}
+
+	if len(vlans) < count {
+		return nil, fmt.Errorf("resize needs %d more networks on every pool, only %d VLAN-matched networks are available", count, len(vlans))
+	}
+	return selected, nil
+}
+
+// releaseResizeNetworks drops count networks from every assigned pool of the lease. The VLANs
+// assigned last are released first, on all pools at once, so the remaining networks stay
+// VLAN-matched.
+func releaseResizeNetworks(lease *v1.Lease, assignedPools []*v1.Pool, count int) {
+	firstPoolNetworks := getNetworksForPool(assignedPools[0])
+
+	var vlans []string
+	for _, ownerRef := range lease.OwnerReferences {
+		if network, exists := firstPoolNetworks[ownerRef.Name]; exists && ownerRef.Kind == "Network" {
+			vlans = append(vlans, network.Spec.VlanId)
+		}
+	}
+	if count > len(vlans) {
+		count = len(vlans)
+	}
+
+	released := make(map[string]bool)
+	for _, vlanId := range vlans[len(vlans)-count:] {
+		released[vlanId] = true
+	}
+
+	poolNetworks := make(map[string]*v1.Network)
+	for _, pool := range assignedPools {
+		for name, network := range getNetworksForPool(pool) {
+			poolNetworks[name] = network
+		}
+	}
+
+	ownerRefs := []metav1.OwnerReference{}
+	for _, ownerRef := range lease.OwnerReferences {
+		if network, exists := poolNetworks[ownerRef.Name]; exists && ownerRef.Kind == "Network" && released[network.Spec.VlanId] {
+			log.Printf("releasing network %s (VLAN %s) from lease %s", network.Name, network.Spec.VlanId, lease.Name)
+			continue
+		}
+		ownerRefs = append(ownerRefs, ownerRef)
+	}
+	lease.OwnerReferences = ownerRefs
+}
+
+// resizeLease applies a change to the vcpus, memory, storage, networks or extended resources of a
+// fulfilled lease.
+// Shrinks are always applied and release the resources immediately. Growth is admitted as a
+// whole, only when every assigned pool has room for it and VLAN-matched networks for any added
+// networks. A rejected growth leaves the lease holding its previous allocation with
+// Resized=False, and is retried.
+func (l *LeaseReconciler) resizeLease(ctx context.Context, lease *v1.Lease) (ctrl.Result, error) {
+	requested := leaseRequestedResources(lease)
+	if lease.Status.Allocated == nil {
+		// fulfilled before allocations were recorded, adopt what the lease holds now
+		log.Printf("recording allocation of lease %s", lease.Name)
+		lease.Status.Allocated = &requested
+		if err := l.Client.Status().Update(ctx, lease); err != nil {
+			return ctrl.Result{}, fmt.Errorf("error recording lease allocation: %w", err)
+		}
+		return ctrl.Result{}, nil
+	}
+	allocated := *lease.Status.Allocated
+
+	reconcilePoolStates()
+	var assignedPools []*v1.Pool
+	for _, poolRef := range utils.GetLeasePoolRefs(lease) {
+		if pool, exists := pools[fmt.Sprintf("%s/%s", lease.Namespace, poolRef.Name)]; exists {
+			assignedPools = append(assignedPools, pool)
+		}
+	}
+	if len(assignedPools) == 0 {
+		return ctrl.Result{}, fmt.Errorf("unable to resize lease %s, its pools are not known", lease.Name)
+	}
+
+	log.Printf("resizing lease %s from %+v to %+v", lease.Name, allocated, requested)
+	resized := shrinkResources(requested, allocated)
+
+	var rejection string
+	var addedNetworks map[string][]*v1.Network
+	if resizeGrows(requested, allocated) {
+		rejection = resizeHeadroom(requested, allocated, assignedPools)
+		if rejection == "" && requested.Networks > allocated.Networks {
+			var err error
+			if addedNetworks, err = l.selectResizeNetworks(lease, assignedPools, requested.Networks-allocated.Networks); err != nil {
+				rejection = err.Error()
+			}
+		}
+		if rejection == "" {
+			resized = requested
+		}
+	}
+
+	if requested.Networks < allocated.Networks {
+		releaseResizeNetworks(lease, assignedPools, allocated.Networks-requested.Networks)
+	}
+	for _, pool := range assignedPools {
+		for _, network := range addedNetworks[pool.Name] {
+			// pools in the same pod share networks, only reference them once
+			if !leaseOwnsNetwork(lease, network) {
+				lease.OwnerReferences = append(lease.OwnerReferences, metav1.OwnerReference{
+					APIVersion: network.APIVersion,
+					Kind:       network.Kind,
+					Name:       network.Name,
+					UID:        network.UID,
+				})
+				log.Printf("assigned network %s (VLAN %s) to pool %s for resize of lease %s", network.Name, network.Spec.VlanId, pool.Name, lease.Name)
+			}
+		}
+	}
+	if resized.Networks != allocated.Networks {
+		setLeaseNetworkStatus(lease, assignedPools)
+	}
+	lease.Status.Allocated = &resized
+
+	if rejection != "" {
+		log.Printf("resize of lease %s rejected: %s", lease.Name, rejection)
+		conditions.Set(lease, conditions.FalseConditionWithReason(
+			v1.LeaseConditionTypeResized,
+			v1.ReasonResizeRejected,
+			v1.ConditionSeverityWarning,
+			"%s",
+			rejection,
+		))
+	} else {
+		conditions.Set(lease, conditions.TrueConditionWithReason(
+			v1.LeaseConditionTypeResized,
+			v1.ReasonLeaseResized,
+			"lease holds %s per pool",
+			describeLeaseResources(resized),
+		))
+	}
+
+	leaseStatus := lease.Status.DeepCopy()
+	if err := l.Client.Update(ctx, lease); err != nil {
+		return ctrl.Result{}, fmt.Errorf("error updating resized lease owner references: %w", err)
+	}
+	leaseStatus.DeepCopyInto(&lease.Status)
+	if err := l.Client.Status().Update(ctx, lease); err != nil {
+		return ctrl.Result{}, fmt.Errorf("error updating resized lease status: %w", err)
+	}
+
+	reconcilePoolStates()
+	l.triggerPoolUpdates(ctx)
+	l.triggerQuotaUpdates(ctx)
+	l.triggerReservationUpdates(ctx)
+	l.triggerNetworkUpdates(ctx)
+	if !leaseResourcesEqual(shrinkResources(requested, allocated), allocated) {
+		// released resources may let waiting leases through
+		l.triggerLeaseUpdates(ctx, lease.Spec.NetworkType)
+	}
+	updateLeaseMetrics()
+
+	if rejection != "" {
+		log.Printf("lease %s resize is pending - requeuing in %v", lease.Name, LEASE_PENDING_RETRY_INTERVAL)
+		return ctrl.Result{RequeueAfter: LEASE_PENDING_RETRY_INTERVAL}, nil
+	}
+	return ctrl.Result{}, nil
+}
+
+// describeLeaseResources formats lease resources for condition messages.
+func describeLeaseResources(resources v1.LeaseResources) string {
+	parts := []string{
+		fmt.Sprintf("%d vCPUs", resources.VCpus),
+		fmt.Sprintf("%dGB memory", resources.Memory),
+	}
+	if resources.Storage > 0 {
+		parts = append(parts, fmt.Sprintf("%dGB storage", resources.Storage))
+	}
+	parts = append(parts, fmt.Sprintf("%d networks", resources.Networks))
+	for _, name := range extendedResourceNames(resources, v1.LeaseResources{}) {
+		parts = append(parts, fmt.Sprintf("%d %s", resources.ExtendedResources[name], name))
+	}
+	return strings.Join(parts, ", ")
+}
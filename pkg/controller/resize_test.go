@@ -0,0 +1,235 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1 "github.com/openshift-splat-team/vsphere-capacity-manager/pkg/apis/vspherecapacitymanager.splat.io/v1"
+	"github.com/openshift-splat-team/vsphere-capacity-manager/pkg/utils/conditions"
+)
+
+// resizeClient is a statusRecordingClient that also serves the Get calls made when pool updates
+// are triggered.
+type resizeClient struct {
+	statusRecordingClient
+}
+
+func (r *resizeClient) Get(_ context.Context, _ types.NamespacedName, _ client.Object, _ ...client.GetOption) error {
+	return nil
+}
+
+func (r *resizeClient) Status() client.SubResourceWriter {
+	return &statusRecordingWriter{parent: &r.statusRecordingClient}
+}
+
+// testResizeNetwork is a testGangNetwork with the gateway needed to generate env vars.
+func testResizeNetwork(name, pod, vlan string) *v1.Network {
+	network := testGangNetwork(name, pod, vlan)
+	gateway := "192.168." + vlan + ".1"
+	network.Spec.Gateway = &gateway
+	return network
+}
+
+func fulfilledResizeLease(poolNames []string, networkNames []string, spec v1.LeaseSpec, allocated v1.LeaseResources) *v1.Lease {
+	lease := &v1.Lease{
+		ObjectMeta: metav1.ObjectMeta{Name: "resize", Namespace: "default"},
+		Spec:       spec,
+		Status:     v1.LeaseStatus{Phase: v1.PHASE_FULFILLED, Allocated: &allocated},
+	}
+	lease.Spec.NetworkType = v1.NetworkTypeSingleTenant
+	for _, name := range poolNames {
+		lease.OwnerReferences = append(lease.OwnerReferences, metav1.OwnerReference{Kind: "Pool", Name: name})
+	}
+	for _, name := range networkNames {
+		lease.OwnerReferences = append(lease.OwnerReferences, metav1.OwnerReference{Kind: "Network", Name: name})
+	}
+	return lease
+}
+
+func TestResizeHeadroom(t *testing.T) {
+	pool := &v1.Pool{ObjectMeta: metav1.ObjectMeta{Name: "pool"}}
+	pool.Status.VCpusAvailable = 8
+	pool.Status.MemoryAvailable = 32
+	pool.Status.DatastoreAvailable = 100
+	pool.Status.ExtendedResourcesAvailable = map[string]int{"licenses/vgpu": 2}
+
+	allocated := v1.LeaseResources{VCpus: 16, Memory: 64, Storage: 200, Networks: 1, ExtendedResources: map[string]int{"licenses/vgpu": 1}}
+	tests := []struct {
+		name      string
+		requested v1.LeaseResources
+		fits      bool
+	}{
+		{name: "growth within headroom", requested: v1.LeaseResources{VCpus: 24, Memory: 96, Storage: 300, Networks: 1}, fits: true},
+		{name: "vcpu growth beyond headroom", requested: v1.LeaseResources{VCpus: 25, Memory: 64, Storage: 200, Networks: 1}},
+		{name: "memory growth beyond headroom", requested: v1.LeaseResources{VCpus: 16, Memory: 97, Storage: 200, Networks: 1}},
+		{name: "storage growth beyond headroom", requested: v1.LeaseResources{VCpus: 16, Memory: 64, Storage: 301, Networks: 1}},
+		{name: "shrink always fits", requested: v1.LeaseResources{VCpus: 8, Memory: 32, Networks: 1}, fits: true},
+		{
+			name:      "extended resource growth within headroom",
+			requested: v1.LeaseResources{VCpus: 16, Memory: 64, Storage: 200, Networks: 1, ExtendedResources: map[string]int{"licenses/vgpu": 3}},
+			fits:      true,
+		},
+		{
+			name:      "extended resource growth beyond headroom",
+			requested: v1.LeaseResources{VCpus: 16, Memory: 64, Storage: 200, Networks: 1, ExtendedResources: map[string]int{"licenses/vgpu": 4}},
+		},
+		{
+			name:      "new extended resource beyond headroom",
+			requested: v1.LeaseResources{VCpus: 16, Memory: 64, Storage: 200, Networks: 1, ExtendedResources: map[string]int{"licenses/vgpu": 1, "vsphere.io/hosts": 1}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := resizeHeadroom(tt.requested, allocated, []*v1.Pool{pool})
+			if (msg == "") != tt.fits {
+				t.Errorf("expected fits=%v, got message %q", tt.fits, msg)
+			}
+		})
+	}
+}
+
+func TestResizeLease(t *testing.T) {
+	t.Run("growth within headroom is admitted with VLAN-matched networks on every pool", func(t *testing.T) {
+		lease := fulfilledResizeLease(
+			[]string{"vc1-a", "vc2-a"},
+			[]string{"pod1-100", "pod2-100"},
+			v1.LeaseSpec{VCpus: 32, Memory: 128, Pools: 2, Networks: 2},
+			v1.LeaseResources{VCpus: 16, Memory: 64, Networks: 1},
+		)
+		restore := setupGangInventory(
+			[]*v1.Pool{
+				testGangPool("vc1-a", "vc1", "pod1", 100, "pg-100", "pg-101", "pg-102"),
+				testGangPool("vc2-a", "vc2", "pod2", 100, "pg-100", "pg-102"),
+			},
+			[]*v1.Network{
+				testResizeNetwork("pod1-100", "pod1", "100"),
+				testResizeNetwork("pod1-101", "pod1", "101"),
+				testResizeNetwork("pod1-102", "pod1", "102"),
+				testResizeNetwork("pod2-100", "pod2", "100"),
+				testResizeNetwork("pod2-102", "pod2", "102"),
+			},
+			lease,
+		)
+		defer restore()
+
+		stub := &resizeClient{}
+		reconciler := &LeaseReconciler{Client: stub}
+		if _, err := reconciler.resizeLease(context.Background(), lease); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if !leaseResourcesEqual(*lease.Status.Allocated, leaseRequestedResources(lease)) {
+			t.Errorf("expected allocation %+v, got %+v", leaseRequestedResources(lease), *lease.Status.Allocated)
+		}
+		if !leaseOwnsNetwork(lease, networks["default/pod1-102"]) || !leaseOwnsNetwork(lease, networks["default/pod2-102"]) {
+			t.Errorf("expected VLAN 102 to be added on both pools, got %v", lease.OwnerReferences)
+		}
+		if leaseOwnsNetwork(lease, networks["default/pod1-101"]) {
+			t.Errorf("expected VLAN 101 to be skipped since vc2-a has no network on it")
+		}
+		if !conditions.IsTrue(lease, v1.LeaseConditionTypeResized) {
+			t.Errorf("expected Resized=True, got %v", lease.Status.Conditions)
+		}
+		if pools["default/vc1-a"].Status.VCpusAvailable != 68 {
+			t.Errorf("expected pool to account for the resized lease, got %d vCPUs available", pools["default/vc1-a"].Status.VCpusAvailable)
+		}
+	})
+
+	t.Run("growth beyond headroom is rejected and shrinks still apply", func(t *testing.T) {
+		lease := fulfilledResizeLease(
+			[]string{"vc1-a"},
+			[]string{"pod1-100"},
+			v1.LeaseSpec{VCpus: 120, Memory: 32, Networks: 1},
+			v1.LeaseResources{VCpus: 16, Memory: 64, Networks: 1},
+		)
+		restore := setupGangInventory(
+			[]*v1.Pool{testGangPool("vc1-a", "vc1", "pod1", 100, "pg-100")},
+			[]*v1.Network{testResizeNetwork("pod1-100", "pod1", "100")},
+			lease,
+		)
+		defer restore()
+
+		stub := &resizeClient{}
+		reconciler := &LeaseReconciler{Client: stub}
+		result, err := reconciler.resizeLease(context.Background(), lease)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		expected := v1.LeaseResources{VCpus: 16, Memory: 32, Networks: 1}
+		if !leaseResourcesEqual(*lease.Status.Allocated, expected) {
+			t.Errorf("expected allocation %+v, got %+v", expected, *lease.Status.Allocated)
+		}
+		condition := conditions.Get(lease, v1.LeaseConditionTypeResized)
+		if condition == nil || condition.Status != v1.ConditionFalse || condition.Reason != v1.ReasonResizeRejected {
+			t.Errorf("expected Resized=False with reason %s, got %v", v1.ReasonResizeRejected, condition)
+		}
+		if result.RequeueAfter != LEASE_PENDING_RETRY_INTERVAL {
+			t.Errorf("expected a rejected resize to be retried in %v, got %v", LEASE_PENDING_RETRY_INTERVAL, result.RequeueAfter)
+		}
+		if stub.statusUpdates != 1 {
+			t.Errorf("expected the rejection to be persisted, got %d status updates", stub.statusUpdates)
+		}
+	})
+
+	t.Run("network shrink releases the same VLAN on every pool", func(t *testing.T) {
+		lease := fulfilledResizeLease(
+			[]string{"vc1-a", "vc2-a"},
+			[]string{"pod1-100", "pod2-100", "pod1-102", "pod2-102"},
+			v1.LeaseSpec{VCpus: 16, Memory: 64, Pools: 2, Networks: 1},
+			v1.LeaseResources{VCpus: 16, Memory: 64, Networks: 2},
+		)
+		restore := setupGangInventory(
+			[]*v1.Pool{
+				testGangPool("vc1-a", "vc1", "pod1", 100, "pg-100", "pg-102"),
+				testGangPool("vc2-a", "vc2", "pod2", 100, "pg-100", "pg-102"),
+			},
+			[]*v1.Network{
+				testResizeNetwork("pod1-100", "pod1", "100"),
+				testResizeNetwork("pod1-102", "pod1", "102"),
+				testResizeNetwork("pod2-100", "pod2", "100"),
+				testResizeNetwork("pod2-102", "pod2", "102"),
+			},
+			lease,
+		)
+		defer restore()
+
+		stub := &resizeClient{}
+		reconciler := &LeaseReconciler{Client: stub}
+		if _, err := reconciler.resizeLease(context.Background(), lease); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if countOwnerRefs(lease, "Network") != 2 {
+			t.Fatalf("expected one network per pool to remain, got %v", lease.OwnerReferences)
+		}
+		if !leaseOwnsNetwork(lease, networks["default/pod1-100"]) || !leaseOwnsNetwork(lease, networks["default/pod2-100"]) {
+			t.Errorf("expected VLAN 102, assigned last, to be released, got %v", lease.OwnerReferences)
+		}
+		if lease.Status.Allocated.Networks != 1 {
+			t.Errorf("expected 1 allocated network, got %d", lease.Status.Allocated.Networks)
+		}
+	})
+}
+
+func TestLeaseResourcesEqual(t *testing.T) {
+	allocated := v1.LeaseResources{VCpus: 16, Memory: 64, Networks: 1, ExtendedResources: map[string]int{"licenses/vgpu": 1}}
+	if !leaseResourcesEqual(allocated, *allocated.DeepCopy()) {
+		t.Errorf("expected a copy to be equal")
+	}
+	if leaseResourcesEqual(allocated, v1.LeaseResources{VCpus: 16, Memory: 64, Networks: 1}) {
+		t.Errorf("expected a missing extended resource to differ")
+	}
+	allocated.ExtendedResources["licenses/vgpu"] = 0
+	if !leaseResourcesEqual(allocated, v1.LeaseResources{VCpus: 16, Memory: 64, Networks: 1}) {
+		t.Errorf("expected an extended resource of zero to equal a missing one")
+	}
+	if shrunk := shrinkResources(v1.LeaseResources{VCpus: 16}, allocated); shrunk.ExtendedResources != nil {
+		t.Errorf("expected the extended resources to be released, got %v", shrunk.ExtendedResources)
+	}
+}
@@ -0,0 +1,409 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1 "github.com/openshift-splat-team/vsphere-capacity-manager/pkg/apis/vspherecapacitymanager.splat.io/v1"
+	"github.com/openshift-splat-team/vsphere-capacity-manager/pkg/utils"
+	"github.com/openshift-splat-team/vsphere-capacity-manager/pkg/utils/conditions"
+)
+
+// SimulationInput is the inventory a simulation runs against, typically loaded from an
+// `oc get -o yaml` dump.
+type SimulationInput struct {
+	Pools           []*v1.Pool
+	Networks        []*v1.Network
+	Leases          []*v1.Lease
+	PriorityClasses []*v1.LeasePriorityClass
+	Quotas          []*v1.LeaseQuota
+	Reservations    []*v1.Reservation
+
+	// AllowMultiToUseSingle is passed to the LeaseReconciler.
+	AllowMultiToUseSingle bool
+}
+
+// SimulationResult is the outcome of a simulation.
+type SimulationResult struct {
+	// Assignments are the leases that were waiting and would be fulfilled.
+	Assignments []SimulatedAssignment `json:"assignments"`
+	// Pending are the leases that would keep waiting.
+	Pending []SimulatedPendingLease `json:"pending"`
+	// Utilization is the state of every pool once the assignments are made.
+	Utilization []SimulatedPoolUtilization `json:"utilization"`
+}
+
+// SimulatedAssignment is the pools and networks a lease would get.
+type SimulatedAssignment struct {
+	Lease    string   `json:"lease"`
+	Pools    []string `json:"pools"`
+	Networks []string `json:"networks"`
+}
+
+// SimulatedPendingLease is a lease that would not be fulfilled and why.
+type SimulatedPendingLease struct {
+	Lease                string                   `json:"lease"`
+	Phase                v1.Phase                 `json:"phase"`
+	Reason               string                   `json:"reason,omitempty"`
+	Message              string                   `json:"message,omitempty"`
+	UnschedulableReasons *v1.UnschedulableReasons `json:"unschedulableReasons,omitempty"`
+}
+
+// SimulatedPoolUtilization is the capacity of a pool, after its overcommit ratios, and how much
+// of it is free.
+type SimulatedPoolUtilization struct {
+	Pool              string `json:"pool"`
+	VCpus             int    `json:"vcpus"`
+	VCpusAvailable    int    `json:"vcpusAvailable"`
+	Memory            int    `json:"memory"`
+	MemoryAvailable   int    `json:"memoryAvailable"`
+	Storage           int    `json:"storage"`
+	StorageAvailable  int    `json:"storageAvailable"`
+	Networks          int    `json:"networks"`
+	NetworksAvailable int    `json:"networksAvailable"`
+	Leases            int    `json:"leases"`
+}
+
+// Simulate runs the lease reconciler against the input inventory with an in-memory client and
+// reports which waiting leases would be fulfilled. Leases are reconciled in priority order until
+// no lease makes progress, the same order the controller retries them in.
+//
+// Simulate replaces the package caches while it runs, so it must not be used in a process that
+// also runs the reconcilers.
+func Simulate(ctx context.Context, input *SimulationInput) (*SimulationResult, error) {
+	oldPools, oldLeases, oldNetworks := pools, leases, networks
+	oldPriorityClasses, oldQuotas, oldReservations := leasePriorityClasses, leaseQuotas, reservations
+	defer func() {
+		pools, leases, networks = oldPools, oldLeases, oldNetworks
+		leasePriorityClasses, leaseQuotas, reservations = oldPriorityClasses, oldQuotas, oldReservations
+	}()
+
+	pools = make(map[string]*v1.Pool)
+	leases = make(map[string]*v1.Lease)
+	networks = make(map[string]*v1.Network)
+	leasePriorityClasses = make(map[string]*v1.LeasePriorityClass)
+	leaseQuotas = make(map[string]*v1.LeaseQuota)
+	reservations = make(map[string]*v1.Reservation)
+
+	simClient := &simulationClient{objects: make(map[string]client.Object)}
+	for _, pool := range input.Pools {
+		pool = pool.DeepCopy()
+		pool.SetGroupVersionKind(v1.GroupVersion.WithKind(v1.PoolKind))
+		pools[fmt.Sprintf("%s/%s", pool.Namespace, pool.Name)] = pool
+		simClient.add(pool.DeepCopy())
+	}
+	for _, network := range input.Networks {
+		network = network.DeepCopy()
+		network.SetGroupVersionKind(v1.GroupVersion.WithKind(v1.NetworkKind))
+		networks[fmt.Sprintf("%s/%s", network.Namespace, network.Name)] = network
+		simClient.add(network.DeepCopy())
+	}
+	for _, class := range input.PriorityClasses {
+		leasePriorityClasses[class.Name] = class.DeepCopy()
+	}
+	for _, quota := range input.Quotas {
+		quota = quota.DeepCopy()
+		leaseQuotas[fmt.Sprintf("%s/%s", quota.Namespace, quota.Name)] = quota
+		simClient.add(quota.DeepCopy())
+	}
+	for _, reservation := range input.Reservations {
+		reservation = reservation.DeepCopy()
+		reservations[fmt.Sprintf("%s/%s", reservation.Namespace, reservation.Name)] = reservation
+		simClient.add(reservation.DeepCopy())
+	}
+
+	var waiting []*v1.Lease
+	for _, lease := range input.Leases {
+		lease = lease.DeepCopy()
+		lease.SetGroupVersionKind(v1.GroupVersion.WithKind(v1.LeaseKind))
+		leases[fmt.Sprintf("%s/%s", lease.Namespace, lease.Name)] = lease
+		simClient.add(lease.DeepCopy())
+		if isLeaseWaiting(lease) {
+			waiting = append(waiting, lease)
+		}
+	}
+
+	reconciler := &LeaseReconciler{
+		Client:                simClient,
+		AllowMultiToUseSingle: input.AllowMultiToUseSingle,
+	}
+
+	now := time.Now()
+	for pass := 0; pass <= len(waiting); pass++ {
+		var queue []*v1.Lease
+		for _, lease := range waiting {
+			if current := simClient.lease(lease); isLeaseWaiting(current) {
+				queue = append(queue, current)
+			}
+		}
+		sort.Slice(queue, func(i, j int) bool {
+			return leaseHasPrecedence(queue[i], queue[j], now)
+		})
+
+		progressed := false
+		for _, lease := range queue {
+			before := len(utils.GetLeasePoolRefs(lease))
+			req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: lease.Namespace, Name: lease.Name}}
+			if _, err := reconciler.Reconcile(ctx, req); err != nil {
+				return nil, fmt.Errorf("error simulating lease %s: %w", lease.Name, err)
+			}
+			current := simClient.lease(lease)
+			if current.Status.Phase != lease.Status.Phase || len(utils.GetLeasePoolRefs(current)) != before {
+				progressed = true
+			}
+		}
+		if !progressed {
+			break
+		}
+	}
+
+	result := &SimulationResult{}
+	for _, lease := range waiting {
+		current := simClient.lease(lease)
+		name := fmt.Sprintf("%s/%s", current.Namespace, current.Name)
+		if current.Status.Phase == v1.PHASE_FULFILLED {
+			assignment := SimulatedAssignment{Lease: name}
+			for _, ref := range current.OwnerReferences {
+				switch ref.Kind {
+				case v1.PoolKind:
+					assignment.Pools = append(assignment.Pools, ref.Name)
+				case v1.NetworkKind:
+					assignment.Networks = append(assignment.Networks, ref.Name)
+				}
+			}
+			result.Assignments = append(result.Assignments, assignment)
+			continue
+		}
+
+		pending := SimulatedPendingLease{
+			Lease:                name,
+			Phase:                current.Status.Phase,
+			UnschedulableReasons: current.Status.UnschedulableReasons,
+		}
+		if condition := conditions.Get(current, v1.LeaseConditionTypeFulfilled); condition != nil {
+			pending.Reason = condition.Reason
+			pending.Message = condition.Message
+		}
+		result.Pending = append(result.Pending, pending)
+	}
+
+	for _, pool := range reconcilePoolStates() {
+		vcpus, memory, storage := utils.PoolCapacity(pool)
+		result.Utilization = append(result.Utilization, SimulatedPoolUtilization{
+			Pool:              pool.Name,
+			VCpus:             vcpus,
+			VCpusAvailable:    pool.Status.VCpusAvailable,
+			Memory:            memory,
+			MemoryAvailable:   pool.Status.MemoryAvailable,
+			Storage:           storage,
+			StorageAvailable:  pool.Status.DatastoreAvailable,
+			Networks:          len(getNetworksForPool(pool)),
+			NetworksAvailable: pool.Status.NetworkAvailable,
+			Leases:            pool.Status.LeaseCount,
+		})
+	}
+	sort.Slice(result.Utilization, func(i, j int) bool {
+		return result.Utilization[i].Pool < result.Utilization[j].Pool
+	})
+	return result, nil
+}
+
+// isLeaseWaiting reports whether the lease is still waiting to be fulfilled.
+func isLeaseWaiting(lease *v1.Lease) bool {
+	if lease.DeletionTimestamp != nil {
+		return false
+	}
+	return lease.Status.Phase != v1.PHASE_FULFILLED && lease.Status.Phase != v1.PHASE_FAILED
+}
+
+// simulationClient is an in-memory client.Client used by Simulate. Like the API server, Update
+// leaves the status of an object unchanged and Status().Update changes only the status. Patches
+// are not supported and return an error.
+type simulationClient struct {
+	client.Client
+	objects map[string]client.Object
+}
+
+func simulationKey(obj client.Object, namespace, name string) string {
+	return fmt.Sprintf("%T/%s/%s", obj, namespace, name)
+}
+
+func (c *simulationClient) add(obj client.Object) {
+	c.objects[simulationKey(obj, obj.GetNamespace(), obj.GetName())] = obj
+}
+
+// lease returns a copy of the stored lease.
+func (c *simulationClient) lease(lease *v1.Lease) *v1.Lease {
+	stored := c.objects[simulationKey(lease, lease.Namespace, lease.Name)].(*v1.Lease)
+	return stored.DeepCopy()
+}
+
+func (c *simulationClient) Get(_ context.Context, key client.ObjectKey, obj client.Object, _ ...client.GetOption) error {
+	stored, ok := c.objects[simulationKey(obj, key.Namespace, key.Name)]
+	if !ok {
+		return apierrors.NewNotFound(schema.GroupResource{Group: v1.GroupName, Resource: fmt.Sprintf("%T", obj)}, key.Name)
+	}
+	return copySimulationObject(stored, obj)
+}
+
+func (c *simulationClient) Update(_ context.Context, obj client.Object, _ ...client.UpdateOption) error {
+	key := simulationKey(obj, obj.GetNamespace(), obj.GetName())
+	stored, ok := c.objects[key]
+	if !ok {
+		return apierrors.NewNotFound(schema.GroupResource{Group: v1.GroupName, Resource: fmt.Sprintf("%T", obj)}, obj.GetName())
+	}
+
+	updated := obj.DeepCopyObject().(client.Object)
+	copySimulationStatus(stored, updated)
+	c.objects[key] = updated
+	return copySimulationObject(updated, obj)
+}
+
+func (c *simulationClient) Create(_ context.Context, obj client.Object, _ ...client.CreateOption) error {
+	key := simulationKey(obj, obj.GetNamespace(), obj.GetName())
+	if _, exists := c.objects[key]; exists {
+		return apierrors.NewAlreadyExists(schema.GroupResource{Group: v1.GroupName, Resource: fmt.Sprintf("%T", obj)}, obj.GetName())
+	}
+	c.objects[key] = obj.DeepCopyObject().(client.Object)
+	return nil
+}
+
+func (c *simulationClient) Delete(_ context.Context, obj client.Object, _ ...client.DeleteOption) error {
+	key := simulationKey(obj, obj.GetNamespace(), obj.GetName())
+	if _, exists := c.objects[key]; !exists {
+		return apierrors.NewNotFound(schema.GroupResource{Group: v1.GroupName, Resource: fmt.Sprintf("%T", obj)}, obj.GetName())
+	}
+	delete(c.objects, key)
+	return nil
+}
+
+func (c *simulationClient) DeleteAllOf(_ context.Context, obj client.Object, _ ...client.DeleteAllOfOption) error {
+	return fmt.Errorf("simulation does not support deleting all of %T", obj)
+}
+
+func (c *simulationClient) Patch(_ context.Context, obj client.Object, _ client.Patch, _ ...client.PatchOption) error {
+	return fmt.Errorf("simulation does not support patching %T", obj)
+}
+
+func (c *simulationClient) List(_ context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	listOpts := &client.ListOptions{}
+	listOpts.ApplyOptions(opts)
+	if listOpts.FieldSelector != nil {
+		return fmt.Errorf("simulation does not support field selectors")
+	}
+
+	switch out := list.(type) {
+	case *v1.LeaseList:
+		out.Items = simulationItems[v1.Lease](c, listOpts)
+	case *v1.PoolList:
+		out.Items = simulationItems[v1.Pool](c, listOpts)
+	case *v1.NetworkList:
+		out.Items = simulationItems[v1.Network](c, listOpts)
+	case *v1.LeaseQuotaList:
+		out.Items = simulationItems[v1.LeaseQuota](c, listOpts)
+	case *v1.ReservationList:
+		out.Items = simulationItems[v1.Reservation](c, listOpts)
+	default:
+		return fmt.Errorf("simulation does not support listing %T", list)
+	}
+	return nil
+}
+
+// simulationItems returns copies of the stored objects of type T matching the list options,
+// sorted by namespace and name.
+func simulationItems[T any, PT interface {
+	*T
+	client.Object
+}](c *simulationClient, opts *client.ListOptions) []T {
+	keys := make([]string, 0, len(c.objects))
+	for key := range c.objects {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var items []T
+	for _, key := range keys {
+		obj, ok := c.objects[key].(PT)
+		if !ok || (len(opts.Namespace) > 0 && obj.GetNamespace() != opts.Namespace) {
+			continue
+		}
+		if opts.LabelSelector != nil && !opts.LabelSelector.Matches(labels.Set(obj.GetLabels())) {
+			continue
+		}
+		items = append(items, *obj.DeepCopyObject().(PT))
+	}
+	return items
+}
+
+func (c *simulationClient) Status() client.SubResourceWriter {
+	return &simulationStatusWriter{parent: c}
+}
+
+type simulationStatusWriter struct {
+	parent *simulationClient
+}
+
+func (w *simulationStatusWriter) Update(_ context.Context, obj client.Object, _ ...client.SubResourceUpdateOption) error {
+	key := simulationKey(obj, obj.GetNamespace(), obj.GetName())
+	stored, ok := w.parent.objects[key]
+	if !ok {
+		return apierrors.NewNotFound(schema.GroupResource{Group: v1.GroupName, Resource: fmt.Sprintf("%T", obj)}, obj.GetName())
+	}
+
+	updated := stored.DeepCopyObject().(client.Object)
+	copySimulationStatus(obj.DeepCopyObject().(client.Object), updated)
+	w.parent.objects[key] = updated
+	return nil
+}
+
+func (w *simulationStatusWriter) Create(_ context.Context, obj client.Object, _ client.Object, _ ...client.SubResourceCreateOption) error {
+	return fmt.Errorf("simulation does not support creating a subresource of %T", obj)
+}
+
+func (w *simulationStatusWriter) Patch(_ context.Context, obj client.Object, _ client.Patch, _ ...client.SubResourcePatchOption) error {
+	return fmt.Errorf("simulation does not support patching the status of %T", obj)
+}
+
+// copySimulationObject copies the stored object into obj.
+func copySimulationObject(stored, obj client.Object) error {
+	switch out := obj.(type) {
+	case *v1.Lease:
+		stored.(*v1.Lease).DeepCopyInto(out)
+	case *v1.Pool:
+		stored.(*v1.Pool).DeepCopyInto(out)
+	case *v1.Network:
+		stored.(*v1.Network).DeepCopyInto(out)
+	case *v1.LeaseQuota:
+		stored.(*v1.LeaseQuota).DeepCopyInto(out)
+	case *v1.Reservation:
+		stored.(*v1.Reservation).DeepCopyInto(out)
+	default:
+		return fmt.Errorf("simulation does not support %T", obj)
+	}
+	return nil
+}
+
+// copySimulationStatus copies the status of from into to for the kinds with a status subresource.
+func copySimulationStatus(from, to client.Object) {
+	switch out := to.(type) {
+	case *v1.Lease:
+		out.Status = from.(*v1.Lease).Status
+	case *v1.Pool:
+		out.Status = from.(*v1.Pool).Status
+	case *v1.Network:
+		out.Status = from.(*v1.Network).Status
+	case *v1.LeaseQuota:
+		out.Status = from.(*v1.LeaseQuota).Status
+	case *v1.Reservation:
+		out.Status = from.(*v1.Reservation).Status
+	}
+}
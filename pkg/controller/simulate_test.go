@@ -0,0 +1,146 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1 "github.com/openshift-splat-team/vsphere-capacity-manager/pkg/apis/vspherecapacitymanager.splat.io/v1"
+	"github.com/openshift-splat-team/vsphere-capacity-manager/pkg/utils"
+)
+
+func testSimulationLease(name string, vcpus int, created time.Time) *v1.Lease {
+	return &v1.Lease{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         "default",
+			CreationTimestamp: metav1.NewTime(created),
+		},
+		Spec: v1.LeaseSpec{
+			VCpus:       vcpus,
+			Memory:      16,
+			Networks:    1,
+			NetworkType: v1.NetworkTypeSingleTenant,
+		},
+	}
+}
+
+func TestSimulate(t *testing.T) {
+	now := time.Now()
+	held := testSimulationLease("held", 60, now.Add(-2*time.Hour))
+	held.Status.Phase = v1.PHASE_FULFILLED
+	held.OwnerReferences = []metav1.OwnerReference{
+		{Kind: v1.PoolKind, Name: "pool-a"},
+		{Kind: v1.NetworkKind, Name: "pod1-100"},
+	}
+	held.Status.Topology.Networks = []string{"/dc1/network/pg-100"}
+
+	gateway := "192.168.100.1"
+	simulationNetworks := []*v1.Network{
+		testGangNetwork("pod1-100", "pod1", "100"),
+		testGangNetwork("pod1-101", "pod1", "101"),
+	}
+	for _, network := range simulationNetworks {
+		network.Spec.Gateway = &gateway
+	}
+
+	input := &SimulationInput{
+		Pools: []*v1.Pool{
+			testGangPool("pool-a", "vc1", "pod1", 100, "pg-100", "pg-101"),
+		},
+		Networks: simulationNetworks,
+		Leases: []*v1.Lease{
+			held,
+			testSimulationLease("fits", 24, now.Add(-time.Hour)),
+			testSimulationLease("too-big", 64, now),
+		},
+	}
+
+	oldPools := pools
+	result, err := Simulate(context.Background(), input)
+	if err != nil {
+		t.Fatalf("Simulate() returned error: %v", err)
+	}
+	if len(pools) != len(oldPools) {
+		t.Errorf("expected the package caches to be restored")
+	}
+
+	if len(result.Assignments) != 1 {
+		t.Fatalf("expected 1 assignment, got %v", result.Assignments)
+	}
+	assignment := result.Assignments[0]
+	if assignment.Lease != "default/fits" || len(assignment.Pools) != 1 || assignment.Pools[0] != "pool-a" {
+		t.Errorf("expected default/fits on pool-a, got %+v", assignment)
+	}
+	if len(assignment.Networks) != 1 || assignment.Networks[0] != "pod1-101" {
+		t.Errorf("expected default/fits to get the free network pod1-101, got %v", assignment.Networks)
+	}
+
+	if len(result.Pending) != 1 || result.Pending[0].Lease != "default/too-big" {
+		t.Fatalf("expected default/too-big to stay pending, got %v", result.Pending)
+	}
+	pending := result.Pending[0]
+	if pending.Reason != v1.ReasonLeaseNoPool {
+		t.Errorf("expected reason %s, got %s", v1.ReasonLeaseNoPool, pending.Reason)
+	}
+	if pending.UnschedulableReasons == nil || pending.UnschedulableReasons.Summary[0].Reason != utils.ReasonPoolInsufficientVCPU {
+		t.Errorf("expected the lease to be pending on vCPUs, got %+v", pending.UnschedulableReasons)
+	}
+
+	if len(result.Utilization) != 1 {
+		t.Fatalf("expected 1 pool, got %d", len(result.Utilization))
+	}
+	if usage := result.Utilization[0]; usage.VCpusAvailable != 16 || usage.Leases != 2 {
+		t.Errorf("expected 16 vCPUs free and 2 leases on pool-a, got %+v", usage)
+	}
+}
+
+func TestSimulationClient(t *testing.T) {
+	ctx := context.Background()
+	simClient := &simulationClient{objects: make(map[string]client.Object)}
+	net10 := testGangNetwork("net-10", "pod1", "10")
+	net10.Labels = map[string]string{v1.NetworkSplitLabel: "vlan-10"}
+	if err := simClient.Create(ctx, net10); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := simClient.Create(ctx, net10); !apierrors.IsAlreadyExists(err) {
+		t.Errorf("expected creating a network twice to fail, got %v", err)
+	}
+	simClient.add(testGangNetwork("net-11", "pod1", "11"))
+	simClient.add(testGangPool("pool-a", "vc1", "pod1", 100))
+
+	networkList := &v1.NetworkList{}
+	if err := simClient.List(ctx, networkList, client.InNamespace("default"), client.MatchingLabels{v1.NetworkSplitLabel: "vlan-10"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(networkList.Items) != 1 || networkList.Items[0].Name != "net-10" {
+		t.Errorf("expected only the labeled network to be listed, got %v", networkList.Items)
+	}
+
+	status := net10.DeepCopy()
+	status.Status.Pools = []string{"pool-a"}
+	if err := simClient.Status().Update(ctx, status); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	stored := &v1.Network{}
+	if err := simClient.Get(ctx, client.ObjectKeyFromObject(net10), stored); err != nil || len(stored.Status.Pools) != 1 {
+		t.Errorf("expected the network status to be updated, got %+v (%v)", stored.Status, err)
+	}
+
+	if err := simClient.Delete(ctx, net10); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := simClient.Get(ctx, client.ObjectKeyFromObject(net10), stored); !apierrors.IsNotFound(err) {
+		t.Errorf("expected the deleted network to be gone, got %v", err)
+	}
+	if err := simClient.Patch(ctx, stored, client.MergeFrom(net10)); err == nil {
+		t.Errorf("expected patching to be rejected")
+	}
+	if err := simClient.List(ctx, &v1.LeasePriorityClassList{}); err == nil {
+		t.Errorf("expected listing an unsupported kind to be rejected")
+	}
+}
@@ -0,0 +1,139 @@
+package scheduler
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	v1 "github.com/openshift-splat-team/vsphere-capacity-manager/pkg/apis/vspherecapacitymanager.splat.io/v1"
+	"github.com/openshift-splat-team/vsphere-capacity-manager/pkg/utils"
+)
+
+const (
+	LeaseAffinityNotMet     = "Lease affinity not met"
+	LeaseAntiAffinityNotMet = "Lease anti-affinity not met"
+	LeaseAffinityInvalid    = "Lease affinity selector is invalid"
+)
+
+// LeaseAffinity enforces the lease's Required spec.leaseAffinity and spec.leaseAntiAffinity terms
+// and scores pools by the weight of the Preferred terms they meet. Required anti-affinity terms
+// of placed leases are enforced against the lease too, so that the constraint holds whichever
+// lease is placed first.
+type LeaseAffinity struct{}
+
+func (p *LeaseAffinity) Name() string { return LeaseAffinityName }
+
+func (p *LeaseAffinity) Filter(state *CycleState, lease *v1.Lease, pool *v1.Pool) string {
+	for i := range lease.Spec.LeaseAffinity {
+		term := &lease.Spec.LeaseAffinity[i]
+		if leaseAffinityMode(term) != v1.LeaseAffinityModeRequired {
+			continue
+		}
+		domains, err := matchingDomains(state, lease.Namespace, term)
+		if err != nil {
+			return LeaseAffinityInvalid
+		}
+		if len(domains) > 0 && !domains[poolTopologyValue(pool, term.TopologyKey)] {
+			return LeaseAffinityNotMet
+		}
+	}
+
+	for i := range lease.Spec.LeaseAntiAffinity {
+		term := &lease.Spec.LeaseAntiAffinity[i]
+		if leaseAffinityMode(term) != v1.LeaseAffinityModeRequired {
+			continue
+		}
+		domains, err := matchingDomains(state, lease.Namespace, term)
+		if err != nil {
+			return LeaseAffinityInvalid
+		}
+		if domains[poolTopologyValue(pool, term.TopologyKey)] {
+			return LeaseAntiAffinityNotMet
+		}
+	}
+
+	for _, placed := range state.PlacedLeases {
+		if placed.Lease.Namespace != lease.Namespace {
+			continue
+		}
+		for i := range placed.Lease.Spec.LeaseAntiAffinity {
+			term := &placed.Lease.Spec.LeaseAntiAffinity[i]
+			if leaseAffinityMode(term) != v1.LeaseAffinityModeRequired {
+				continue
+			}
+			selector, err := metav1.LabelSelectorAsSelector(&term.LabelSelector)
+			if err != nil || !selector.Matches(labels.Set(lease.Labels)) {
+				continue
+			}
+			value := poolTopologyValue(pool, term.TopologyKey)
+			for _, placedPool := range placed.Pools {
+				if value != "" && poolTopologyValue(placedPool, term.TopologyKey) == value {
+					return LeaseAntiAffinityNotMet
+				}
+			}
+		}
+	}
+	return ""
+}
+
+func (p *LeaseAffinity) Score(state *CycleState, lease *v1.Lease, pool *v1.Pool) int64 {
+	var met, total int64
+	score := func(terms []v1.LeaseAffinityTerm, anti bool) {
+		for i := range terms {
+			term := &terms[i]
+			if leaseAffinityMode(term) != v1.LeaseAffinityModePreferred {
+				continue
+			}
+			total += int64(term.Weight)
+
+			domains, err := matchingDomains(state, lease.Namespace, term)
+			if err != nil {
+				continue
+			}
+			if domains[poolTopologyValue(pool, term.TopologyKey)] != anti {
+				met += int64(term.Weight)
+			}
+		}
+	}
+	score(lease.Spec.LeaseAffinity, false)
+	score(lease.Spec.LeaseAntiAffinity, true)
+
+	if total <= 0 {
+		return 0
+	}
+	return met * MaxScore / total
+}
+
+func leaseAffinityMode(term *v1.LeaseAffinityTerm) v1.LeaseAffinityMode {
+	if term.Mode == "" {
+		return v1.LeaseAffinityModeRequired
+	}
+	return term.Mode
+}
+
+// matchingDomains returns the topology domains of the pools assigned to placed leases in the
+// namespace that match the term's selector.
+func matchingDomains(state *CycleState, namespace string, term *v1.LeaseAffinityTerm) (map[string]bool, error) {
+	selector, err := metav1.LabelSelectorAsSelector(&term.LabelSelector)
+	if err != nil {
+		return nil, err
+	}
+
+	domains := make(map[string]bool)
+	for _, placed := range state.PlacedLeases {
+		if placed.Lease.Namespace != namespace || !selector.Matches(labels.Set(placed.Lease.Labels)) {
+			continue
+		}
+		for _, pool := range placed.Pools {
+			if value := poolTopologyValue(pool, term.TopologyKey); value != "" {
+				domains[value] = true
+			}
+		}
+	}
+	return domains, nil
+}
+
+// poolTopologyValue returns the pool's topology domain for the key. An empty string means the
+// pool is in no domain for that key.
+func poolTopologyValue(pool *v1.Pool, key v1.LeaseTopologyKey) string {
+	return utils.PoolTopologyDomain(pool, string(key))
+}
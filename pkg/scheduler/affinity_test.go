@@ -0,0 +1,143 @@
+package scheduler
+
+import (
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/openshift-splat-team/vsphere-capacity-manager/pkg/apis/vspherecapacitymanager.splat.io/v1"
+)
+
+func testAffinityLease(name, job string, affinity, antiAffinity []v1.LeaseAffinityTerm) *v1.Lease {
+	return &v1.Lease{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			Labels:    map[string]string{"boskos-lease-id": job},
+		},
+		Spec: v1.LeaseSpec{
+			VCpus:             24,
+			Memory:            96,
+			LeaseAffinity:     affinity,
+			LeaseAntiAffinity: antiAffinity,
+		},
+	}
+}
+
+func sameJobTerm(key v1.LeaseTopologyKey, mode v1.LeaseAffinityMode, weight int32) []v1.LeaseAffinityTerm {
+	return []v1.LeaseAffinityTerm{
+		{
+			LabelSelector: metav1.LabelSelector{MatchLabels: map[string]string{"boskos-lease-id": "job-1"}},
+			TopologyKey:   key,
+			Mode:          mode,
+			Weight:        weight,
+		},
+	}
+}
+
+func TestLeaseAffinity(t *testing.T) {
+	vc1 := testPool("vc1-pool", "vc1", 90, 360)
+	vc2 := testPool("vc2-pool", "vc2", 50, 200)
+	vc2b := testPool("vc2-pool-b", "vc2", 40, 160)
+	allPools := []*v1.Pool{vc1, vc2, vc2b}
+
+	placedOnVC2 := []PlacedLease{
+		{Lease: testAffinityLease("sibling", "job-1", nil, nil), Pools: []*v1.Pool{vc2}},
+	}
+
+	tests := []struct {
+		name          string
+		lease         *v1.Lease
+		placed        []PlacedLease
+		expectedOrder []string
+	}{
+		{
+			name:          "required affinity is met by any pool before a sibling is placed",
+			lease:         testAffinityLease("lease", "job-1", sameJobTerm(v1.LeaseTopologyKeyServer, "", 0), nil),
+			expectedOrder: []string{"vc1-pool", "vc2-pool", "vc2-pool-b"},
+		},
+		{
+			name:          "required affinity keeps the lease on the sibling's vCenter",
+			lease:         testAffinityLease("lease", "job-1", sameJobTerm(v1.LeaseTopologyKeyServer, v1.LeaseAffinityModeRequired, 0), nil),
+			placed:        placedOnVC2,
+			expectedOrder: []string{"vc2-pool", "vc2-pool-b"},
+		},
+		{
+			name:          "required anti-affinity keeps the lease off the sibling's vCenter",
+			lease:         testAffinityLease("lease", "job-1", nil, sameJobTerm(v1.LeaseTopologyKeyServer, v1.LeaseAffinityModeRequired, 0)),
+			placed:        placedOnVC2,
+			expectedOrder: []string{"vc1-pool"},
+		},
+		{
+			name:          "required anti-affinity by pool only excludes the sibling's pool",
+			lease:         testAffinityLease("lease", "job-1", nil, sameJobTerm(v1.LeaseTopologyKeyPool, v1.LeaseAffinityModeRequired, 0)),
+			placed:        placedOnVC2,
+			expectedOrder: []string{"vc1-pool", "vc2-pool-b"},
+		},
+		{
+			name:  "required anti-affinity of a placed lease applies to the lease",
+			lease: testAffinityLease("lease", "job-1", nil, nil),
+			placed: []PlacedLease{
+				{
+					Lease: testAffinityLease("sibling", "job-1", nil, sameJobTerm(v1.LeaseTopologyKeyServer, v1.LeaseAffinityModeRequired, 0)),
+					Pools: []*v1.Pool{vc2},
+				},
+			},
+			expectedOrder: []string{"vc1-pool"},
+		},
+		{
+			name:          "leases of other jobs are ignored",
+			lease:         testAffinityLease("lease", "job-2", nil, sameJobTerm(v1.LeaseTopologyKeyServer, v1.LeaseAffinityModeRequired, 0)),
+			placed:        []PlacedLease{{Lease: testAffinityLease("other", "job-2", nil, nil), Pools: []*v1.Pool{vc1}}},
+			expectedOrder: []string{"vc1-pool", "vc2-pool", "vc2-pool-b"},
+		},
+		{
+			name:          "preferred affinity ranks the sibling's vCenter first",
+			lease:         testAffinityLease("lease", "job-1", sameJobTerm(v1.LeaseTopologyKeyServer, v1.LeaseAffinityModePreferred, 50), nil),
+			placed:        placedOnVC2,
+			expectedOrder: []string{"vc2-pool", "vc2-pool-b", "vc1-pool"},
+		},
+		{
+			name:          "preferred anti-affinity ranks the sibling's pool last",
+			lease:         testAffinityLease("lease", "job-1", nil, sameJobTerm(v1.LeaseTopologyKeyPool, v1.LeaseAffinityModePreferred, 50)),
+			placed:        placedOnVC2,
+			expectedOrder: []string{"vc1-pool", "vc2-pool-b", "vc2-pool"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			framework := New()
+			ranked, err := framework.Schedule(&CycleState{PlacedLeases: tt.placed}, tt.lease, allPools)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if names := poolNames(ranked); !reflect.DeepEqual(names, tt.expectedOrder) {
+				t.Errorf("expected order %v, got %v", tt.expectedOrder, names)
+			}
+		})
+	}
+}
+
+func TestLeaseAffinityFilterReasons(t *testing.T) {
+	plugin := &LeaseAffinity{}
+	state := &CycleState{
+		PlacedLeases: []PlacedLease{
+			{Lease: testAffinityLease("sibling", "job-1", nil, nil), Pools: []*v1.Pool{testPool("vc2-pool", "vc2", 50, 200)}},
+		},
+	}
+	pool := testPool("vc1-pool", "vc1", 50, 200)
+
+	lease := testAffinityLease("lease", "job-1", sameJobTerm(v1.LeaseTopologyKeyServer, v1.LeaseAffinityModeRequired, 0), nil)
+	if reason := plugin.Filter(state, lease, pool); reason != LeaseAffinityNotMet {
+		t.Errorf("expected %q, got %q", LeaseAffinityNotMet, reason)
+	}
+
+	lease.Spec.LeaseAffinity[0].LabelSelector.MatchExpressions = []metav1.LabelSelectorRequirement{
+		{Key: "boskos-lease-id", Operator: "Sometimes"},
+	}
+	if reason := plugin.Filter(state, lease, pool); reason != LeaseAffinityInvalid {
+		t.Errorf("expected %q, got %q", LeaseAffinityInvalid, reason)
+	}
+}
@@ -0,0 +1,43 @@
+package scheduler
+
+import (
+	v1 "github.com/openshift-splat-team/vsphere-capacity-manager/pkg/apis/vspherecapacitymanager.splat.io/v1"
+)
+
+const (
+	BackfillReservedCapacity = "Pool capacity is reserved for an older lease"
+)
+
+// Backfill rejects pools where placing the lease would leave less free capacity than is reserved
+// for older leases in CycleState.Reservations. Pools without a reservation are not constrained.
+type Backfill struct{}
+
+func (p *Backfill) Name() string { return BackfillName }
+
+func (p *Backfill) Filter(state *CycleState, lease *v1.Lease, pool *v1.Pool) string {
+	reservation, ok := state.Reservations[pool.Name]
+	if !ok {
+		return ""
+	}
+
+	if !leavesReserved(pool.Status.VCpusAvailable, lease.Spec.VCpus, reservation.VCpus) ||
+		!leavesReserved(pool.Status.MemoryAvailable, lease.Spec.Memory, reservation.Memory) ||
+		!leavesReserved(pool.Status.NetworkAvailable, lease.Spec.Networks, reservation.Networks) {
+		return BackfillReservedCapacity
+	}
+	if pool.Spec.Storage > 0 && !leavesReserved(pool.Status.DatastoreAvailable, lease.Spec.Storage, reservation.Storage) {
+		return BackfillReservedCapacity
+	}
+	for name, requested := range lease.Spec.ExtendedResources {
+		if !leavesReserved(pool.Status.ExtendedResourcesAvailable[name], requested, reservation.ExtendedResources[name]) {
+			return BackfillReservedCapacity
+		}
+	}
+	return ""
+}
+
+// leavesReserved reports whether taking requested out of available leaves at least reserved.
+// A lease that does not use a resource never reduces it.
+func leavesReserved(available, requested, reserved int) bool {
+	return requested <= 0 || available-requested >= reserved
+}
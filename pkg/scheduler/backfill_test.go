@@ -0,0 +1,69 @@
+package scheduler
+
+import (
+	"testing"
+
+	v1 "github.com/openshift-splat-team/vsphere-capacity-manager/pkg/apis/vspherecapacitymanager.splat.io/v1"
+)
+
+func TestBackfillFilter(t *testing.T) {
+	tests := []struct {
+		name        string
+		reservation *Reservation
+		lease       v1.LeaseSpec
+		expected    string
+	}{
+		{
+			name:     "pool without a reservation",
+			lease:    v1.LeaseSpec{VCpus: 90, Memory: 360},
+			expected: "",
+		},
+		{
+			name:        "lease leaves the reserved capacity free",
+			reservation: &Reservation{VCpus: 24, Memory: 96},
+			lease:       v1.LeaseSpec{VCpus: 4, Memory: 16},
+			expected:    "",
+		},
+		{
+			name:        "lease would use reserved vCPUs",
+			reservation: &Reservation{VCpus: 48, Memory: 96},
+			lease:       v1.LeaseSpec{VCpus: 4, Memory: 16},
+			expected:    BackfillReservedCapacity,
+		},
+		{
+			name:        "lease would use reserved networks",
+			reservation: &Reservation{VCpus: 24, Memory: 96, Networks: 1},
+			lease:       v1.LeaseSpec{VCpus: 4, Memory: 16, Networks: 1},
+			expected:    BackfillReservedCapacity,
+		},
+		{
+			name:        "lease would use reserved extended resources",
+			reservation: &Reservation{ExtendedResources: map[string]int{"licenses/vgpu": 2}},
+			lease:       v1.LeaseSpec{VCpus: 4, Memory: 16, ExtendedResources: map[string]int{"licenses/vgpu": 1}},
+			expected:    BackfillReservedCapacity,
+		},
+		{
+			name:        "resources the lease does not use are not checked",
+			reservation: &Reservation{VCpus: 24, Memory: 96, Networks: 1},
+			lease:       v1.LeaseSpec{VCpus: 4, Memory: 16},
+			expected:    "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pool := testPool("pool", "vc1", 50, 200)
+			pool.Status.NetworkAvailable = 1
+			pool.Status.ExtendedResourcesAvailable = map[string]int{"licenses/vgpu": 2}
+			state := &CycleState{}
+			if tt.reservation != nil {
+				state.Reservations = map[string]*Reservation{"pool": tt.reservation}
+			}
+
+			lease := &v1.Lease{Spec: tt.lease}
+			if reason := (&Backfill{}).Filter(state, lease, pool); reason != tt.expected {
+				t.Errorf("Filter() = %q, expected %q", reason, tt.expected)
+			}
+		})
+	}
+}
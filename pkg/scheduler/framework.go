@@ -0,0 +1,234 @@
+package scheduler
+
+import (
+	"fmt"
+	"sort"
+
+	v1 "github.com/openshift-splat-team/vsphere-capacity-manager/pkg/apis/vspherecapacitymanager.splat.io/v1"
+	"github.com/openshift-splat-team/vsphere-capacity-manager/pkg/utils"
+)
+
+const (
+	// MaxScore is the highest score a ScorePlugin may return for a pool.
+	MaxScore int64 = 100
+
+	// DefaultProfileName is the profile used when a lease does not set spec.schedulingProfile.
+	DefaultProfileName = "default"
+)
+
+// CycleState carries the state of a single scheduling attempt that is shared by all plugins.
+type CycleState struct {
+	// PlacedLeases are the other leases that have been assigned pools. They are used by the
+	// LeaseAffinity plugin.
+	PlacedLeases []PlacedLease
+	// Reservations is the capacity held back in each pool, keyed by pool name, for older leases
+	// that the lease is backfilled past. It is used by the Backfill plugin.
+	Reservations map[string]*Reservation
+}
+
+// Reservation is capacity in a pool that a backfilled lease must leave free.
+type Reservation struct {
+	VCpus             int
+	Memory            int
+	Storage           int
+	Networks          int
+	ExtendedResources map[string]int
+}
+
+// PlacedLease is a lease together with the pools assigned to it.
+type PlacedLease struct {
+	Lease *v1.Lease
+	Pools []*v1.Pool
+}
+
+// Plugin is the parent type of all scheduler plugins.
+type Plugin interface {
+	Name() string
+}
+
+// FilterPlugin decides whether a pool can host a lease.
+type FilterPlugin interface {
+	Plugin
+	// Filter returns an empty string when the pool can host the lease, otherwise the reason
+	// the pool was rejected.
+	Filter(state *CycleState, lease *v1.Lease, pool *v1.Pool) string
+}
+
+// FilterResultPlugin is a FilterPlugin that can describe a rejection in more detail than a
+// reason string. The framework uses FilterResult in place of Filter when a plugin implements it.
+type FilterResultPlugin interface {
+	FilterPlugin
+	// FilterResult returns nil when the pool can host the lease, otherwise why the pool was
+	// rejected.
+	FilterResult(state *CycleState, lease *v1.Lease, pool *v1.Pool) *utils.PoolFittingInfo
+}
+
+// UnschedulableError is returned by Schedule when no pool passes the filters. It carries the
+// reason each pool was rejected.
+type UnschedulableError struct {
+	Results []*utils.PoolFittingInfo
+}
+
+func (e *UnschedulableError) Error() string {
+	return fmt.Sprintf("no pools available. %v", utils.GeneratePoolResults(e.Results))
+}
+
+// ScorePlugin ranks pools that passed all filters.
+type ScorePlugin interface {
+	Plugin
+	// Score returns a value between 0 and MaxScore. Higher scores are preferred.
+	Score(state *CycleState, lease *v1.Lease, pool *v1.Pool) int64
+}
+
+// WeightedPlugin references a registered score plugin and the weight applied to its score.
+type WeightedPlugin struct {
+	Name   string
+	Weight int64
+}
+
+// Profile is a named set of filter and score plugins.
+type Profile struct {
+	Name    string
+	Filters []string
+	Scores  []WeightedPlugin
+}
+
+// PoolScore is the total weighted score of a pool that passed all filters.
+type PoolScore struct {
+	Pool  *v1.Pool
+	Score int64
+}
+
+// Framework runs scheduling profiles built from registered plugins.
+type Framework struct {
+	plugins  map[string]Plugin
+	profiles map[string]*Profile
+}
+
+// New returns a framework with the built-in plugins and profiles registered.
+func New() *Framework {
+	f := &Framework{
+		plugins:  make(map[string]Plugin),
+		profiles: make(map[string]*Profile),
+	}
+	for _, plugin := range builtinPlugins() {
+		f.RegisterPlugin(plugin)
+	}
+	for _, profile := range builtinProfiles() {
+		if err := f.RegisterProfile(profile); err != nil {
+			panic(err)
+		}
+	}
+	return f
+}
+
+// RegisterPlugin adds or replaces a plugin.
+func (f *Framework) RegisterPlugin(plugin Plugin) {
+	f.plugins[plugin.Name()] = plugin
+}
+
+// RegisterProfile adds or replaces a profile. All plugins referenced by the profile must
+// already be registered with the matching extension point.
+func (f *Framework) RegisterProfile(profile *Profile) error {
+	for _, name := range profile.Filters {
+		if _, ok := f.plugins[name].(FilterPlugin); !ok {
+			return fmt.Errorf("profile %s: %s is not a registered filter plugin", profile.Name, name)
+		}
+	}
+	for _, weighted := range profile.Scores {
+		if _, ok := f.plugins[weighted.Name].(ScorePlugin); !ok {
+			return fmt.Errorf("profile %s: %s is not a registered score plugin", profile.Name, weighted.Name)
+		}
+	}
+	f.profiles[profile.Name] = profile
+	return nil
+}
+
+// Profile returns the profile requested by the lease, falling back to the default profile.
+func (f *Framework) Profile(lease *v1.Lease) (*Profile, error) {
+	name := lease.Spec.SchedulingProfile
+	if len(name) == 0 {
+		name = DefaultProfileName
+	}
+	profile, ok := f.profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown scheduling profile %q", name)
+	}
+	return profile, nil
+}
+
+// Filter returns the pools that pass every filter plugin of the profile and the reasons the
+// remaining pools were rejected. Rejections by plugins that do not implement FilterResultPlugin
+// use the plugin name as the reason code.
+func (f *Framework) Filter(profile *Profile, state *CycleState, lease *v1.Lease, pools []*v1.Pool) ([]*v1.Pool, []*utils.PoolFittingInfo) {
+	var feasible []*v1.Pool
+	results := []*utils.PoolFittingInfo{}
+
+	for _, pool := range pools {
+		var result *utils.PoolFittingInfo
+		for _, name := range profile.Filters {
+			if result = f.filterPool(name, state, lease, pool); result != nil {
+				break
+			}
+		}
+		if result != nil {
+			results = append(results, result)
+			continue
+		}
+		feasible = append(feasible, pool)
+	}
+	return feasible, results
+}
+
+func (f *Framework) filterPool(name string, state *CycleState, lease *v1.Lease, pool *v1.Pool) *utils.PoolFittingInfo {
+	if plugin, ok := f.plugins[name].(FilterResultPlugin); ok {
+		return plugin.FilterResult(state, lease, pool)
+	}
+	if reason := f.plugins[name].(FilterPlugin).Filter(state, lease, pool); len(reason) > 0 {
+		return &utils.PoolFittingInfo{Pool: pool, MatchResults: reason, Reason: name}
+	}
+	return nil
+}
+
+// Score returns the feasible pools ordered by their total weighted score, highest first.
+// Pools with equal scores keep their relative order.
+func (f *Framework) Score(profile *Profile, state *CycleState, lease *v1.Lease, pools []*v1.Pool) []PoolScore {
+	scores := make([]PoolScore, 0, len(pools))
+	for _, pool := range pools {
+		var total int64
+		for _, weighted := range profile.Scores {
+			score := f.plugins[weighted.Name].(ScorePlugin).Score(state, lease, pool)
+			if score < 0 {
+				score = 0
+			} else if score > MaxScore {
+				score = MaxScore
+			}
+			total += score * weighted.Weight
+		}
+		scores = append(scores, PoolScore{Pool: pool, Score: total})
+	}
+	sort.SliceStable(scores, func(i, j int) bool {
+		return scores[i].Score > scores[j].Score
+	})
+	return scores
+}
+
+// Schedule returns the pools that can host the lease, best first, using the lease's profile.
+// An error is returned when the profile is unknown or no pool passes the filters.
+func (f *Framework) Schedule(state *CycleState, lease *v1.Lease, pools []*v1.Pool) ([]*v1.Pool, error) {
+	profile, err := f.Profile(lease)
+	if err != nil {
+		return nil, err
+	}
+
+	feasible, results := f.Filter(profile, state, lease, pools)
+	if len(feasible) == 0 {
+		return nil, &UnschedulableError{Results: results}
+	}
+
+	ranked := make([]*v1.Pool, 0, len(feasible))
+	for _, scored := range f.Score(profile, state, lease, feasible) {
+		ranked = append(ranked, scored.Pool)
+	}
+	return ranked, nil
+}
@@ -0,0 +1,265 @@
+package scheduler
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/openshift-splat-team/vsphere-capacity-manager/pkg/apis/vspherecapacitymanager.splat.io/v1"
+	"github.com/openshift-splat-team/vsphere-capacity-manager/pkg/utils"
+)
+
+func testPool(name, server string, vcpusAvailable, memoryAvailable int) *v1.Pool {
+	return &v1.Pool{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: v1.PoolSpec{
+			FailureDomainSpec: v1.FailureDomainSpec{
+				VSpherePlatformFailureDomainSpec: configv1.VSpherePlatformFailureDomainSpec{
+					Server: server,
+				},
+			},
+			VCpus:  100,
+			Memory: 400,
+		},
+		Status: v1.PoolStatus{
+			VCpusAvailable:  vcpusAvailable,
+			MemoryAvailable: memoryAvailable,
+		},
+	}
+}
+
+func poolNames(pools []*v1.Pool) []string {
+	var names []string
+	for _, pool := range pools {
+		names = append(names, pool.Name)
+	}
+	return names
+}
+
+func TestScheduleProfiles(t *testing.T) {
+	tests := []struct {
+		name          string
+		profile       string
+		pools         []*v1.Pool
+		expectedOrder []string
+	}{
+		{
+			name:    "default profile spreads onto the least allocated pool",
+			profile: "",
+			pools: []*v1.Pool{
+				testPool("busy", "vc1", 20, 80),
+				testPool("idle", "vc1", 90, 360),
+				testPool("half", "vc1", 50, 200),
+			},
+			expectedOrder: []string{"idle", "half", "busy"},
+		},
+		{
+			name:    "bin-packing profile fills the most allocated pool",
+			profile: BinPackingProfileName,
+			pools: []*v1.Pool{
+				testPool("busy", "vc1", 20, 80),
+				testPool("idle", "vc1", 90, 360),
+				testPool("half", "vc1", 50, 200),
+			},
+			expectedOrder: []string{"busy", "half", "idle"},
+		},
+		{
+			name:    "bin-packing still prefers tolerated pools over PreferNoSchedule taints",
+			profile: BinPackingProfileName,
+			pools: []*v1.Pool{
+				func() *v1.Pool {
+					p := testPool("busy-retiring", "vc1", 20, 80)
+					p.Spec.Taints = []v1.Taint{{Key: "retiring", Effect: v1.TaintEffectPreferNoSchedule}}
+					return p
+				}(),
+				testPool("idle", "vc1", 90, 360),
+			},
+			expectedOrder: []string{"idle", "busy-retiring"},
+		},
+	}
+
+	f := New()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lease := &v1.Lease{
+				ObjectMeta: metav1.ObjectMeta{Name: "lease"},
+				Spec: v1.LeaseSpec{
+					VCpus:             8,
+					Memory:            16,
+					SchedulingProfile: tt.profile,
+				},
+			}
+
+			ranked, err := f.Schedule(&CycleState{}, lease, tt.pools)
+			if err != nil {
+				t.Fatalf("Schedule() returned error: %v", err)
+			}
+			if got := strings.Join(poolNames(ranked), ","); got != strings.Join(tt.expectedOrder, ",") {
+				t.Errorf("Schedule() order = %s, expected %s", got, strings.Join(tt.expectedOrder, ","))
+			}
+		})
+	}
+}
+
+func TestScheduleRandomProfile(t *testing.T) {
+	f := New()
+	lease := &v1.Lease{
+		Spec: v1.LeaseSpec{
+			VCpus:             8,
+			Memory:            16,
+			SchedulingProfile: RandomProfileName,
+		},
+	}
+	pools := []*v1.Pool{
+		testPool("pool-a", "vc1", 90, 360),
+		testPool("pool-b", "vc1", 20, 80),
+		testPool("pool-c", "vc1", 50, 200),
+	}
+
+	seen := make(map[string]bool)
+	for i := 0; i < 200; i++ {
+		ranked, err := f.Schedule(&CycleState{}, lease, pools)
+		if err != nil {
+			t.Fatalf("Schedule() returned error: %v", err)
+		}
+		if len(ranked) != len(pools) {
+			t.Fatalf("Schedule() returned %d pools, expected %d", len(ranked), len(pools))
+		}
+		seen[ranked[0].Name] = true
+	}
+	if len(seen) < 2 {
+		t.Errorf("random profile always picked %v first", seen)
+	}
+}
+
+func TestScheduleUnknownProfile(t *testing.T) {
+	f := New()
+	lease := &v1.Lease{Spec: v1.LeaseSpec{SchedulingProfile: "does-not-exist"}}
+
+	if _, err := f.Profile(lease); err == nil {
+		t.Fatal("Profile() expected error for unknown profile")
+	}
+	if _, err := f.Schedule(&CycleState{}, lease, []*v1.Pool{testPool("pool", "vc1", 90, 360)}); err == nil {
+		t.Fatal("Schedule() expected error for unknown profile")
+	}
+}
+
+func TestScheduleNoFittingPools(t *testing.T) {
+	f := New()
+	lease := &v1.Lease{Spec: v1.LeaseSpec{VCpus: 64, Memory: 16}}
+	pools := []*v1.Pool{
+		testPool("small", "vc1", 32, 360),
+		testPool("low-memory", "vc2", 90, 8),
+	}
+
+	_, err := f.Schedule(&CycleState{}, lease, pools)
+	if err == nil {
+		t.Fatal("Schedule() expected error when no pool fits")
+	}
+	for _, reason := range []string{utils.PoolInsufficientVCPU, utils.PoolInsufficientMemory} {
+		if !strings.Contains(err.Error(), reason) {
+			t.Errorf("Schedule() error %q does not mention %q", err.Error(), reason)
+		}
+	}
+}
+
+func TestScheduleUnschedulableResults(t *testing.T) {
+	f := New()
+	f.RegisterPlugin(&rejectPool{name: "pool-b"})
+	if err := f.RegisterProfile(&Profile{Name: "custom", Filters: []string{PoolFitName, "RejectPool"}}); err != nil {
+		t.Fatalf("RegisterProfile() returned error: %v", err)
+	}
+
+	lease := &v1.Lease{Spec: v1.LeaseSpec{VCpus: 64, Memory: 16, SchedulingProfile: "custom"}}
+	pools := []*v1.Pool{
+		testPool("pool-a", "vc1", 32, 360),
+		testPool("pool-b", "vc1", 90, 360),
+	}
+
+	_, err := f.Schedule(&CycleState{}, lease, pools)
+	var unschedulable *UnschedulableError
+	if !errors.As(err, &unschedulable) {
+		t.Fatalf("Schedule() error = %v, expected an UnschedulableError", err)
+	}
+	if len(unschedulable.Results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(unschedulable.Results))
+	}
+
+	capacity, plugin := unschedulable.Results[0], unschedulable.Results[1]
+	if capacity.Reason != utils.ReasonPoolInsufficientVCPU || capacity.Required != 64 || capacity.Available != 32 {
+		t.Errorf("expected InsufficientVCPU with 64 required and 32 available, got %+v", capacity)
+	}
+	if plugin.Reason != "RejectPool" || plugin.MatchResults != "rejected by test plugin" {
+		t.Errorf("expected the plugin name as reason code, got %+v", plugin)
+	}
+}
+
+type rejectPool struct {
+	name string
+}
+
+func (p *rejectPool) Name() string { return "RejectPool" }
+
+func (p *rejectPool) Filter(state *CycleState, lease *v1.Lease, pool *v1.Pool) string {
+	if pool.Name == p.name {
+		return "rejected by test plugin"
+	}
+	return ""
+}
+
+type preferPool struct {
+	name string
+}
+
+func (p *preferPool) Name() string { return "PreferPool" }
+
+func (p *preferPool) Score(state *CycleState, lease *v1.Lease, pool *v1.Pool) int64 {
+	if pool.Name == p.name {
+		return MaxScore
+	}
+	return 0
+}
+
+func TestRegisterCustomProfile(t *testing.T) {
+	f := New()
+	f.RegisterPlugin(&rejectPool{name: "pool-a"})
+	f.RegisterPlugin(&preferPool{name: "pool-c"})
+
+	if err := f.RegisterProfile(&Profile{
+		Name:    "custom",
+		Filters: []string{PoolFitName, "RejectPool"},
+		Scores:  []WeightedPlugin{{Name: "PreferPool", Weight: 1}},
+	}); err != nil {
+		t.Fatalf("RegisterProfile() returned error: %v", err)
+	}
+
+	lease := &v1.Lease{Spec: v1.LeaseSpec{VCpus: 8, Memory: 16, SchedulingProfile: "custom"}}
+	pools := []*v1.Pool{
+		testPool("pool-a", "vc1", 90, 360),
+		testPool("pool-b", "vc1", 90, 360),
+		testPool("pool-c", "vc1", 20, 80),
+	}
+
+	ranked, err := f.Schedule(&CycleState{}, lease, pools)
+	if err != nil {
+		t.Fatalf("Schedule() returned error: %v", err)
+	}
+	if got := strings.Join(poolNames(ranked), ","); got != "pool-c,pool-b" {
+		t.Errorf("Schedule() order = %s, expected pool-c,pool-b", got)
+	}
+}
+
+func TestRegisterProfileUnknownPlugin(t *testing.T) {
+	f := New()
+
+	if err := f.RegisterProfile(&Profile{Name: "bad-filter", Filters: []string{"Missing"}}); err == nil {
+		t.Error("RegisterProfile() expected error for unknown filter plugin")
+	}
+	// LeastAllocated is registered, but only as a score plugin.
+	if err := f.RegisterProfile(&Profile{Name: "bad-kind", Filters: []string{LeastAllocatedName}}); err == nil {
+		t.Error("RegisterProfile() expected error for plugin registered at another extension point")
+	}
+}
@@ -0,0 +1,307 @@
+package scheduler
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+
+	v1 "github.com/openshift-splat-team/vsphere-capacity-manager/pkg/apis/vspherecapacitymanager.splat.io/v1"
+)
+
+// DefaultGangSearchSteps bounds the number of pools SolveGang tries before giving up.
+const DefaultGangSearchSteps = 10000
+
+// GangRequest describes a multi-pool lease to be placed as a whole.
+type GangRequest struct {
+	// Pools is the number of distinct pools required.
+	Pools int
+	// VCenters is the maximum number of distinct vCenters the pools may span. Zero means no limit.
+	VCenters int
+	// Networks is the number of networks required in every pool. The networks of all pools
+	// must be on the same VLANs so that VMs in different pools can communicate.
+	Networks int
+	// Candidates are the pools able to host the lease, best first.
+	Candidates []*v1.Pool
+	// PoolNetworks maps a candidate pool name to the networks available to the lease in that pool.
+	PoolNetworks map[string][]*v1.Network
+	// PreferredNetworks are networks whose VLANs are chosen first, such as the networks already
+	// held by sibling leases of the same job.
+	PreferredNetworks []*v1.Network
+	// Spread are the lease's topology spread constraints. Candidates with no domain for the key
+	// of a constraint are not used.
+	Spread []v1.TopologySpreadConstraint
+	// MaxSteps bounds the search. Zero uses DefaultGangSearchSteps.
+	MaxSteps int
+}
+
+// GangAssignment is a complete placement of a multi-pool lease.
+type GangAssignment struct {
+	// Pools are the assigned pools in candidate order.
+	Pools []*v1.Pool
+	// VLANs are the VLAN IDs shared by the networks of every pool.
+	VLANs []string
+	// Networks maps each assigned pool name to its networks, one per VLAN in VLANs order.
+	Networks map[string][]*v1.Network
+}
+
+type gangSearch struct {
+	req        *GangRequest
+	poolVLANs  []map[string]bool
+	maxSteps   int
+	steps      int
+	chosen     []int
+	serverUses map[string]int
+	spread     []*spreadTracker
+}
+
+// SolveGang finds Pools distinct candidates spanning at most VCenters vCenters that share at least
+// Networks VLANs and meet the Spread constraints. Candidates are tried in order with backtracking, so the best ranked feasible
+// combination is returned. An error is returned when no combination exists or the search budget
+// is exhausted.
+func SolveGang(req *GangRequest) (*GangAssignment, error) {
+	if req.Pools <= 0 {
+		return nil, fmt.Errorf("gang request must require at least one pool")
+	}
+	if len(req.Candidates) < req.Pools {
+		return nil, fmt.Errorf("lease requires %d pools but only %d pools can host it", req.Pools, len(req.Candidates))
+	}
+
+	s := &gangSearch{
+		req:        req,
+		poolVLANs:  make([]map[string]bool, len(req.Candidates)),
+		maxSteps:   req.MaxSteps,
+		serverUses: make(map[string]int),
+	}
+	if s.maxSteps <= 0 {
+		s.maxSteps = DefaultGangSearchSteps
+	}
+	for i, pool := range req.Candidates {
+		s.poolVLANs[i] = networkVLANs(req.PoolNetworks[pool.Name])
+	}
+	for _, constraint := range req.Spread {
+		s.spread = append(s.spread, newSpreadTracker(constraint, req.Candidates, req.Pools))
+	}
+
+	vlans, found := s.search(0, nil)
+	if !found {
+		if s.steps > s.maxSteps {
+			return nil, fmt.Errorf("no complete assignment of %d pools found within %d search steps", req.Pools, s.maxSteps)
+		}
+		if len(req.Spread) > 0 {
+			return nil, fmt.Errorf("no combination of %d pools (vcenters cap %d) meets %d topology spread constraint(s) and shares %d common VLANs among %d candidate pools",
+				req.Pools, req.VCenters, len(req.Spread), req.Networks, len(req.Candidates))
+		}
+		return nil, fmt.Errorf("no combination of %d pools (vcenters cap %d) shares %d common VLANs among %d candidate pools",
+			req.Pools, req.VCenters, req.Networks, len(req.Candidates))
+	}
+
+	assignment := &GangAssignment{
+		VLANs:    chooseVLANs(vlans, req.Networks, req.PreferredNetworks),
+		Networks: make(map[string][]*v1.Network),
+	}
+	for _, idx := range s.chosen {
+		assignment.Pools = append(assignment.Pools, req.Candidates[idx])
+	}
+	assignNetworks(assignment, req)
+	return assignment, nil
+}
+
+// search extends the current partial combination with candidates at or after start. vlans is
+// the set of VLANs common to every chosen pool, or nil when no pool is chosen yet.
+func (s *gangSearch) search(start int, vlans map[string]bool) (map[string]bool, bool) {
+	if len(s.chosen) == s.req.Pools {
+		for _, tracker := range s.spread {
+			if !tracker.satisfied() {
+				return nil, false
+			}
+		}
+		return vlans, true
+	}
+
+	for i := start; i < len(s.req.Candidates); i++ {
+		if !s.canComplete(i) {
+			return nil, false
+		}
+		s.steps++
+		if s.steps > s.maxSteps {
+			return nil, false
+		}
+
+		server := s.req.Candidates[i].Spec.Server
+		if s.req.VCenters > 0 && s.serverUses[server] == 0 && len(s.serverUses) >= s.req.VCenters {
+			continue
+		}
+
+		if !s.spreadAllows(i) {
+			continue
+		}
+
+		next := intersectVLANs(vlans, s.poolVLANs[i])
+		if len(next) < s.req.Networks {
+			continue
+		}
+
+		s.chosen = append(s.chosen, i)
+		s.serverUses[server]++
+		for _, tracker := range s.spread {
+			tracker.add(i)
+		}
+		if result, found := s.search(i+1, next); found {
+			return result, true
+		}
+		s.chosen = s.chosen[:len(s.chosen)-1]
+		s.serverUses[server]--
+		if s.serverUses[server] == 0 {
+			delete(s.serverUses, server)
+		}
+		for _, tracker := range s.spread {
+			tracker.remove(i)
+		}
+		if s.steps > s.maxSteps {
+			return nil, false
+		}
+	}
+	return nil, false
+}
+
+// spreadAllows reports whether candidate i can be chosen next without breaking a topology spread
+// constraint.
+func (s *gangSearch) spreadAllows(i int) bool {
+	remaining := s.req.Pools - len(s.chosen) - 1
+	for _, tracker := range s.spread {
+		if !tracker.allows(i, remaining) {
+			return false
+		}
+	}
+	return true
+}
+
+// canComplete reports whether the candidates from start onward could still fill the remaining
+// slots without exceeding the vCenters cap.
+func (s *gangSearch) canComplete(start int) bool {
+	needed := s.req.Pools - len(s.chosen)
+	remaining := s.req.Candidates[start:]
+	if len(remaining) < needed {
+		return false
+	}
+	if s.req.VCenters <= 0 {
+		return true
+	}
+
+	available := 0
+	newServers := make(map[string]int)
+	for _, pool := range remaining {
+		if s.serverUses[pool.Spec.Server] > 0 {
+			available++
+		} else {
+			newServers[pool.Spec.Server]++
+		}
+	}
+
+	counts := make([]int, 0, len(newServers))
+	for _, count := range newServers {
+		counts = append(counts, count)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(counts)))
+	for i := 0; i < len(counts) && i < s.req.VCenters-len(s.serverUses); i++ {
+		available += counts[i]
+	}
+	return available >= needed
+}
+
+func networkVLANs(networks []*v1.Network) map[string]bool {
+	vlans := make(map[string]bool)
+	for _, network := range networks {
+		if len(network.Spec.VlanId) > 0 {
+			vlans[network.Spec.VlanId] = true
+		}
+	}
+	return vlans
+}
+
+func intersectVLANs(current, pool map[string]bool) map[string]bool {
+	if current == nil {
+		return pool
+	}
+	result := make(map[string]bool)
+	for vlan := range current {
+		if pool[vlan] {
+			result[vlan] = true
+		}
+	}
+	return result
+}
+
+// chooseVLANs picks count VLANs from the common set, preferred networks' VLANs first and the
+// rest in random order so that leases spread across the available VLANs.
+func chooseVLANs(common map[string]bool, count int, preferred []*v1.Network) []string {
+	var chosen []string
+	used := make(map[string]bool)
+	for _, network := range preferred {
+		vlan := network.Spec.VlanId
+		if len(chosen) < count && common[vlan] && !used[vlan] {
+			chosen = append(chosen, vlan)
+			used[vlan] = true
+		}
+	}
+
+	var rest []string
+	for vlan := range common {
+		if !used[vlan] {
+			rest = append(rest, vlan)
+		}
+	}
+	sort.Strings(rest)
+	rand.Shuffle(len(rest), func(i, j int) {
+		rest[i], rest[j] = rest[j], rest[i]
+	})
+	for _, vlan := range rest {
+		if len(chosen) >= count {
+			break
+		}
+		chosen = append(chosen, vlan)
+	}
+	return chosen
+}
+
+// assignNetworks picks a network on every chosen VLAN for every pool. Pools that see the same
+// network (pools in the same datacenter pod) share it, and preferred networks are used when
+// available.
+func assignNetworks(assignment *GangAssignment, req *GangRequest) {
+	preferred := make(map[string]bool)
+	for _, network := range req.PreferredNetworks {
+		preferred[network.Name] = true
+	}
+
+	picked := make(map[string][]*v1.Network)
+	for _, pool := range assignment.Pools {
+		for _, vlan := range assignment.VLANs {
+			var choice *v1.Network
+			for _, network := range req.PoolNetworks[pool.Name] {
+				if network.Spec.VlanId != vlan {
+					continue
+				}
+				if containsNetwork(picked[vlan], network) {
+					choice = network
+					break
+				}
+				if choice == nil || (preferred[network.Name] && !preferred[choice.Name]) {
+					choice = network
+				}
+			}
+			if !containsNetwork(picked[vlan], choice) {
+				picked[vlan] = append(picked[vlan], choice)
+			}
+			assignment.Networks[pool.Name] = append(assignment.Networks[pool.Name], choice)
+		}
+	}
+}
+
+func containsNetwork(networks []*v1.Network, network *v1.Network) bool {
+	for _, n := range networks {
+		if n.Name == network.Name && n.Namespace == network.Namespace {
+			return true
+		}
+	}
+	return false
+}
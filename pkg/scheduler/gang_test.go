@@ -0,0 +1,238 @@
+package scheduler
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/openshift-splat-team/vsphere-capacity-manager/pkg/apis/vspherecapacitymanager.splat.io/v1"
+)
+
+func testNetwork(name, vlan string) *v1.Network {
+	return &v1.Network{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec:       v1.NetworkSpec{VlanId: vlan},
+	}
+}
+
+// testPoolNetworks gives every pool its own network on each of the VLANs listed for it.
+func testPoolNetworks(vlans map[string][]string) map[string][]*v1.Network {
+	poolNetworks := make(map[string][]*v1.Network)
+	for pool, poolVLANs := range vlans {
+		for _, vlan := range poolVLANs {
+			poolNetworks[pool] = append(poolNetworks[pool], testNetwork(pool+"-"+vlan, vlan))
+		}
+	}
+	return poolNetworks
+}
+
+func TestSolveGang(t *testing.T) {
+	tests := []struct {
+		name          string
+		request       GangRequest
+		expectedPools []string
+		expectError   bool
+	}{
+		{
+			name: "best ranked pools are used when they share a VLAN",
+			request: GangRequest{
+				Pools:    2,
+				Networks: 1,
+				Candidates: []*v1.Pool{
+					testPool("a", "vc1", 50, 200),
+					testPool("b", "vc2", 50, 200),
+					testPool("c", "vc3", 50, 200),
+				},
+				PoolNetworks: testPoolNetworks(map[string][]string{
+					"a": {"100"},
+					"b": {"100"},
+					"c": {"100"},
+				}),
+			},
+			expectedPools: []string{"a", "b"},
+		},
+		{
+			name: "vcenter cap skips a greedy choice that can not be completed",
+			request: GangRequest{
+				Pools:    4,
+				VCenters: 2,
+				Networks: 1,
+				Candidates: []*v1.Pool{
+					testPool("vc1-a", "vc1", 90, 360),
+					testPool("vc2-a", "vc2", 80, 320),
+					testPool("vc3-a", "vc3", 70, 280),
+					testPool("vc3-b", "vc3", 60, 240),
+					testPool("vc3-c", "vc3", 50, 200),
+				},
+				PoolNetworks: testPoolNetworks(map[string][]string{
+					"vc1-a": {"100"},
+					"vc2-a": {"100"},
+					"vc3-a": {"100"},
+					"vc3-b": {"100"},
+					"vc3-c": {"100"},
+				}),
+			},
+			expectedPools: []string{"vc1-a", "vc3-a", "vc3-b", "vc3-c"},
+		},
+		{
+			name: "pools without a common VLAN are not combined",
+			request: GangRequest{
+				Pools:    2,
+				Networks: 1,
+				Candidates: []*v1.Pool{
+					testPool("a", "vc1", 50, 200),
+					testPool("b", "vc1", 50, 200),
+					testPool("c", "vc1", 50, 200),
+				},
+				PoolNetworks: testPoolNetworks(map[string][]string{
+					"a": {"100"},
+					"b": {"200"},
+					"c": {"100"},
+				}),
+			},
+			expectedPools: []string{"a", "c"},
+		},
+		{
+			name: "every pool needs the requested number of common VLANs",
+			request: GangRequest{
+				Pools:    2,
+				Networks: 2,
+				Candidates: []*v1.Pool{
+					testPool("a", "vc1", 50, 200),
+					testPool("b", "vc1", 50, 200),
+				},
+				PoolNetworks: testPoolNetworks(map[string][]string{
+					"a": {"100", "200"},
+					"b": {"100", "300"},
+				}),
+			},
+			expectError: true,
+		},
+		{
+			name: "too few candidates",
+			request: GangRequest{
+				Pools:      3,
+				Networks:   1,
+				Candidates: []*v1.Pool{testPool("a", "vc1", 50, 200)},
+			},
+			expectError: true,
+		},
+		{
+			name: "vcenter cap can not be met",
+			request: GangRequest{
+				Pools:    3,
+				VCenters: 1,
+				Networks: 1,
+				Candidates: []*v1.Pool{
+					testPool("a", "vc1", 50, 200),
+					testPool("b", "vc2", 50, 200),
+					testPool("c", "vc3", 50, 200),
+				},
+				PoolNetworks: testPoolNetworks(map[string][]string{
+					"a": {"100"},
+					"b": {"100"},
+					"c": {"100"},
+				}),
+			},
+			expectError: true,
+		},
+		{
+			name: "search budget exhausted",
+			request: GangRequest{
+				Pools:    2,
+				Networks: 1,
+				Candidates: []*v1.Pool{
+					testPool("a", "vc1", 50, 200),
+					testPool("b", "vc1", 50, 200),
+					testPool("c", "vc1", 50, 200),
+				},
+				PoolNetworks: testPoolNetworks(map[string][]string{
+					"a": {"100"},
+					"b": {"200"},
+					"c": {"300"},
+				}),
+				MaxSteps: 2,
+			},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assignment, err := SolveGang(&tt.request)
+			if tt.expectError {
+				if err == nil {
+					t.Fatalf("expected an error, got pools %v", poolNames(assignment.Pools))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if names := poolNames(assignment.Pools); !reflect.DeepEqual(names, tt.expectedPools) {
+				t.Errorf("expected pools %v, got %v", tt.expectedPools, names)
+			}
+			for _, pool := range assignment.Pools {
+				var vlans []string
+				for _, network := range assignment.Networks[pool.Name] {
+					vlans = append(vlans, network.Spec.VlanId)
+				}
+				if !reflect.DeepEqual(vlans, assignment.VLANs) {
+					t.Errorf("pool %s networks are on VLANs %v, expected %v", pool.Name, vlans, assignment.VLANs)
+				}
+			}
+		})
+	}
+}
+
+func TestSolveGangNetworks(t *testing.T) {
+	shared := testNetwork("shared-100", "100")
+	sibling := testNetwork("sibling-200", "200")
+	request := &GangRequest{
+		Pools:    2,
+		Networks: 1,
+		Candidates: []*v1.Pool{
+			testPool("a", "vc1", 50, 200),
+			testPool("b", "vc1", 50, 200),
+		},
+		PoolNetworks: map[string][]*v1.Network{
+			"a": {testNetwork("a-200", "200"), shared, sibling},
+			"b": {shared, testNetwork("b-200", "200")},
+		},
+		PreferredNetworks: []*v1.Network{sibling},
+	}
+
+	assignment, err := SolveGang(request)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(assignment.VLANs, []string{"200"}) {
+		t.Fatalf("expected the VLAN of the preferred network, got %v", assignment.VLANs)
+	}
+	if got := assignment.Networks["a"][0].Name; got != "sibling-200" {
+		t.Errorf("expected pool a to use the preferred network, got %s", got)
+	}
+	if got := assignment.Networks["b"][0].Name; got != "b-200" {
+		t.Errorf("expected pool b to use its own network on the preferred VLAN, got %s", got)
+	}
+
+	request.PreferredNetworks = nil
+	request.PoolNetworks = map[string][]*v1.Network{
+		"a": {shared},
+		"b": {testNetwork("b-100", "100"), shared},
+	}
+	assignment, err = SolveGang(request)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var names []string
+	for _, pool := range assignment.Pools {
+		names = append(names, assignment.Networks[pool.Name][0].Name)
+	}
+	sort.Strings(names)
+	if !reflect.DeepEqual(names, []string{"shared-100", "shared-100"}) {
+		t.Errorf("expected pools in the same pod to share a network, got %v", names)
+	}
+}
@@ -0,0 +1,153 @@
+package scheduler
+
+import (
+	"math/rand"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	v1 "github.com/openshift-splat-team/vsphere-capacity-manager/pkg/apis/vspherecapacitymanager.splat.io/v1"
+	"github.com/openshift-splat-team/vsphere-capacity-manager/pkg/utils"
+)
+
+const (
+	PoolFitName         = "PoolFit"
+	LeastAllocatedName  = "LeastAllocated"
+	MostAllocatedName   = "MostAllocated"
+	RandomName          = "Random"
+	TaintPreferenceName = "TaintPreference"
+	LabelAffinityName   = "LabelAffinity"
+	LeaseAffinityName   = "LeaseAffinity"
+	BackfillName        = "Backfill"
+)
+
+func builtinPlugins() []Plugin {
+	return []Plugin{
+		&PoolFit{},
+		&LeastAllocated{},
+		&MostAllocated{},
+		&Random{},
+		&TaintPreference{},
+		&LabelAffinity{},
+		&LeaseAffinity{},
+		&Backfill{},
+	}
+}
+
+// PoolFit rejects pools that fail the structural and capacity checks of utils.GetFittingPools:
+// scheduling flags, required pool, pool selectors, taints and free capacity. The vCenter cap
+// of the lease is enforced by SolveGang, not per pool.
+type PoolFit struct{}
+
+func (p *PoolFit) Name() string { return PoolFitName }
+
+func (p *PoolFit) Filter(state *CycleState, lease *v1.Lease, pool *v1.Pool) string {
+	if result := p.FilterResult(state, lease, pool); result != nil {
+		return result.MatchResults
+	}
+	return ""
+}
+
+func (p *PoolFit) FilterResult(state *CycleState, lease *v1.Lease, pool *v1.Pool) *utils.PoolFittingInfo {
+	fitting, results := utils.GetFittingPools(lease, []*v1.Pool{pool})
+	if len(fitting) > 0 {
+		return nil
+	}
+	if len(results) > 0 {
+		return results[0]
+	}
+	return &utils.PoolFittingInfo{Pool: pool, MatchResults: "Pool does not fit lease", Reason: PoolFitName}
+}
+
+// freeRatio returns the fraction of the overcommitted vCPUs and memory still free in the pool,
+// averaged and clamped to [0, 1]. Resources the pool does not define are ignored.
+func freeRatio(pool *v1.Pool) float64 {
+	var total float64
+	var count int
+	vcpus, memory, _ := utils.PoolCapacity(pool)
+	if vcpus > 0 {
+		total += clamp(float64(pool.Status.VCpusAvailable) / float64(vcpus))
+		count++
+	}
+	if memory > 0 {
+		total += clamp(float64(pool.Status.MemoryAvailable) / float64(memory))
+		count++
+	}
+	if count == 0 {
+		return 0
+	}
+	return total / float64(count)
+}
+
+func clamp(ratio float64) float64 {
+	if ratio < 0 {
+		return 0
+	}
+	if ratio > 1 {
+		return 1
+	}
+	return ratio
+}
+
+// LeastAllocated favors pools with the most free vCPU and memory, spreading leases across pools.
+type LeastAllocated struct{}
+
+func (p *LeastAllocated) Name() string { return LeastAllocatedName }
+
+func (p *LeastAllocated) Score(state *CycleState, lease *v1.Lease, pool *v1.Pool) int64 {
+	return int64(freeRatio(pool) * float64(MaxScore))
+}
+
+// MostAllocated favors pools with the least free vCPU and memory, packing leases onto fewer pools.
+type MostAllocated struct{}
+
+func (p *MostAllocated) Name() string { return MostAllocatedName }
+
+func (p *MostAllocated) Score(state *CycleState, lease *v1.Lease, pool *v1.Pool) int64 {
+	return int64((1 - freeRatio(pool)) * float64(MaxScore))
+}
+
+// Random gives every pool a random score.
+type Random struct{}
+
+func (p *Random) Name() string { return RandomName }
+
+func (p *Random) Score(state *CycleState, lease *v1.Lease, pool *v1.Pool) int64 {
+	return rand.Int63n(MaxScore + 1)
+}
+
+// TaintPreference favors pools without untolerated PreferNoSchedule taints. Each untolerated
+// taint lowers the score further.
+type TaintPreference struct{}
+
+func (p *TaintPreference) Name() string { return TaintPreferenceName }
+
+func (p *TaintPreference) Score(state *CycleState, lease *v1.Lease, pool *v1.Pool) int64 {
+	return MaxScore / int64(1+utils.CountUntoleratedPreferNoScheduleTaints(lease, pool))
+}
+
+// LabelAffinity favors pools matching the lease's spec.preferredPools terms in proportion to
+// the weights of the matching terms.
+type LabelAffinity struct{}
+
+func (p *LabelAffinity) Name() string { return LabelAffinityName }
+
+func (p *LabelAffinity) Score(state *CycleState, lease *v1.Lease, pool *v1.Pool) int64 {
+	var matched, total int64
+	for i := range lease.Spec.PreferredPools {
+		term := &lease.Spec.PreferredPools[i]
+		total += int64(term.Weight)
+
+		selector, err := metav1.LabelSelectorAsSelector(&term.Preference)
+		if err != nil {
+			continue
+		}
+		if selector.Matches(labels.Set(pool.Labels)) {
+			matched += int64(term.Weight)
+		}
+	}
+	if total <= 0 {
+		return 0
+	}
+	return matched * MaxScore / total
+}
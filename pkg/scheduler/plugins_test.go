@@ -0,0 +1,219 @@
+package scheduler
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/openshift-splat-team/vsphere-capacity-manager/pkg/apis/vspherecapacitymanager.splat.io/v1"
+	"github.com/openshift-splat-team/vsphere-capacity-manager/pkg/utils"
+)
+
+func TestPoolFitFilter(t *testing.T) {
+	tests := []struct {
+		name     string
+		lease    *v1.Lease
+		pool     *v1.Pool
+		state    *CycleState
+		expected string
+	}{
+		{
+			name:     "pool fits",
+			lease:    &v1.Lease{Spec: v1.LeaseSpec{VCpus: 8, Memory: 16}},
+			pool:     testPool("pool", "vc1", 90, 360),
+			state:    &CycleState{},
+			expected: "",
+		},
+		{
+			name:     "insufficient vcpus",
+			lease:    &v1.Lease{Spec: v1.LeaseSpec{VCpus: 95, Memory: 16}},
+			pool:     testPool("pool", "vc1", 90, 360),
+			state:    &CycleState{},
+			expected: utils.PoolInsufficientVCPU,
+		},
+		{
+			name:  "pool not schedulable",
+			lease: &v1.Lease{Spec: v1.LeaseSpec{VCpus: 8, Memory: 16}},
+			pool: func() *v1.Pool {
+				p := testPool("pool", "vc1", 90, 360)
+				p.Spec.NoSchedule = true
+				return p
+			}(),
+			state:    &CycleState{},
+			expected: utils.PoolNotSchedulable,
+		},
+	}
+
+	plugin := &PoolFit{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := plugin.Filter(tt.state, tt.lease, tt.pool); result != tt.expected {
+				t.Errorf("Filter() = %q, expected %q", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestAllocationScores(t *testing.T) {
+	tests := []struct {
+		name                   string
+		pool                   *v1.Pool
+		expectedLeastAllocated int64
+		expectedMostAllocated  int64
+	}{
+		{
+			name:                   "empty pool",
+			pool:                   testPool("pool", "vc1", 100, 400),
+			expectedLeastAllocated: 100,
+			expectedMostAllocated:  0,
+		},
+		{
+			name:                   "half allocated pool",
+			pool:                   testPool("pool", "vc1", 50, 200),
+			expectedLeastAllocated: 50,
+			expectedMostAllocated:  50,
+		},
+		{
+			name:                   "overcommitted pool is clamped",
+			pool:                   testPool("pool", "vc1", -20, 0),
+			expectedLeastAllocated: 0,
+			expectedMostAllocated:  100,
+		},
+		{
+			name: "pool without capacity defined",
+			pool: &v1.Pool{
+				ObjectMeta: metav1.ObjectMeta{Name: "pool"},
+			},
+			expectedLeastAllocated: 0,
+			expectedMostAllocated:  100,
+		},
+	}
+
+	lease := &v1.Lease{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if score := (&LeastAllocated{}).Score(&CycleState{}, lease, tt.pool); score != tt.expectedLeastAllocated {
+				t.Errorf("LeastAllocated.Score() = %d, expected %d", score, tt.expectedLeastAllocated)
+			}
+			if score := (&MostAllocated{}).Score(&CycleState{}, lease, tt.pool); score != tt.expectedMostAllocated {
+				t.Errorf("MostAllocated.Score() = %d, expected %d", score, tt.expectedMostAllocated)
+			}
+		})
+	}
+}
+
+func TestTaintPreferenceScore(t *testing.T) {
+	pool := testPool("pool", "vc1", 90, 360)
+	pool.Spec.Taints = []v1.Taint{
+		{Key: "retiring", Effect: v1.TaintEffectPreferNoSchedule},
+		{Key: "slow-storage", Effect: v1.TaintEffectPreferNoSchedule},
+	}
+
+	tests := []struct {
+		name     string
+		lease    *v1.Lease
+		expected int64
+	}{
+		{
+			name:     "no tolerations",
+			lease:    &v1.Lease{},
+			expected: 33,
+		},
+		{
+			name: "one taint tolerated",
+			lease: &v1.Lease{Spec: v1.LeaseSpec{Tolerations: []v1.Toleration{
+				{Key: "retiring", Operator: v1.TolerationOpExists},
+			}}},
+			expected: 50,
+		},
+		{
+			name: "all taints tolerated",
+			lease: &v1.Lease{Spec: v1.LeaseSpec{Tolerations: []v1.Toleration{
+				{Operator: v1.TolerationOpExists},
+			}}},
+			expected: MaxScore,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if score := (&TaintPreference{}).Score(&CycleState{}, tt.lease, pool); score != tt.expected {
+				t.Errorf("Score() = %d, expected %d", score, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLabelAffinityScore(t *testing.T) {
+	pool := testPool("pool", "vc1", 90, 360)
+	pool.Labels = map[string]string{"region": "us-east", "storage": "vsan"}
+
+	tests := []struct {
+		name     string
+		terms    []v1.PreferredPoolTerm
+		expected int64
+	}{
+		{
+			name:     "no preferences",
+			expected: 0,
+		},
+		{
+			name: "single matching term",
+			terms: []v1.PreferredPoolTerm{
+				{Weight: 10, Preference: metav1.LabelSelector{MatchLabels: map[string]string{"region": "us-east"}}},
+			},
+			expected: MaxScore,
+		},
+		{
+			name: "weighted partial match",
+			terms: []v1.PreferredPoolTerm{
+				{Weight: 30, Preference: metav1.LabelSelector{MatchLabels: map[string]string{"region": "us-east"}}},
+				{Weight: 70, Preference: metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+					{Key: "storage", Operator: metav1.LabelSelectorOpIn, Values: []string{"nfs"}},
+				}}},
+			},
+			expected: 30,
+		},
+		{
+			name: "invalid term never matches",
+			terms: []v1.PreferredPoolTerm{
+				{Weight: 50, Preference: metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+					{Key: "region", Operator: "Sometimes"},
+				}}},
+				{Weight: 50, Preference: metav1.LabelSelector{MatchLabels: map[string]string{"storage": "vsan"}}},
+			},
+			expected: 50,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lease := &v1.Lease{Spec: v1.LeaseSpec{PreferredPools: tt.terms}}
+			if score := (&LabelAffinity{}).Score(&CycleState{}, lease, pool); score != tt.expected {
+				t.Errorf("Score() = %d, expected %d", score, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLabelAffinityOutranksAllocation(t *testing.T) {
+	preferred := testPool("preferred", "vc1", 20, 80)
+	preferred.Labels = map[string]string{"region": "us-east"}
+	idle := testPool("idle", "vc1", 100, 400)
+
+	lease := &v1.Lease{Spec: v1.LeaseSpec{
+		VCpus:  8,
+		Memory: 16,
+		PreferredPools: []v1.PreferredPoolTerm{
+			{Weight: 1, Preference: metav1.LabelSelector{MatchLabels: map[string]string{"region": "us-east"}}},
+		},
+	}}
+
+	ranked, err := New().Schedule(&CycleState{}, lease, []*v1.Pool{idle, preferred})
+	if err != nil {
+		t.Fatalf("Schedule() returned error: %v", err)
+	}
+	if ranked[0].Name != "preferred" {
+		t.Errorf("expected preferred pool first, got %v", poolNames(ranked))
+	}
+}
@@ -0,0 +1,37 @@
+package scheduler
+
+const (
+	BinPackingProfileName = "bin-packing"
+	RandomProfileName     = "random"
+
+	// taintPreferenceWeight is large enough that a pool the lease fully tolerates always outranks
+	// one with untolerated PreferNoSchedule taints, whatever the other plugins score.
+	taintPreferenceWeight = 1000
+	// labelAffinityWeight lets spec.preferredPools outweigh the allocation plugins.
+	labelAffinityWeight = 10
+	// leaseAffinityWeight gives preferred lease affinity terms the same pull as spec.preferredPools.
+	leaseAffinityWeight = 10
+)
+
+// builtinProfiles returns the profiles every framework starts with. They share the same filters
+// and soft preferences and differ only in how they rank pools by utilization.
+func builtinProfiles() []*Profile {
+	profile := func(name, allocationPlugin string) *Profile {
+		return &Profile{
+			Name:    name,
+			Filters: []string{PoolFitName, LeaseAffinityName, BackfillName},
+			Scores: []WeightedPlugin{
+				{Name: TaintPreferenceName, Weight: taintPreferenceWeight},
+				{Name: LabelAffinityName, Weight: labelAffinityWeight},
+				{Name: LeaseAffinityName, Weight: leaseAffinityWeight},
+				{Name: allocationPlugin, Weight: 1},
+			},
+		}
+	}
+
+	return []*Profile{
+		profile(DefaultProfileName, LeastAllocatedName),
+		profile(BinPackingProfileName, MostAllocatedName),
+		profile(RandomProfileName, RandomName),
+	}
+}
@@ -0,0 +1,97 @@
+package scheduler
+
+import (
+	v1 "github.com/openshift-splat-team/vsphere-capacity-manager/pkg/apis/vspherecapacitymanager.splat.io/v1"
+	"github.com/openshift-splat-team/vsphere-capacity-manager/pkg/utils"
+)
+
+// spreadTracker counts the pools chosen by SolveGang per domain of one topology spread constraint.
+type spreadTracker struct {
+	constraint v1.TopologySpreadConstraint
+	// domains is the domain of each candidate, by candidate index.
+	domains []string
+	// eligible is the number of distinct domains among the candidates.
+	eligible int
+	// maxCount is the most pools a domain may hold without exceeding maxSkew. Zero means no limit.
+	maxCount int
+	counts   map[string]int
+}
+
+func newSpreadTracker(constraint v1.TopologySpreadConstraint, candidates []*v1.Pool, pools int) *spreadTracker {
+	t := &spreadTracker{
+		constraint: constraint,
+		domains:    make([]string, len(candidates)),
+		counts:     make(map[string]int),
+	}
+
+	eligible := make(map[string]bool)
+	for i, pool := range candidates {
+		t.domains[i] = utils.PoolTopologyDomain(pool, constraint.TopologyKey)
+		if len(t.domains[i]) > 0 {
+			eligible[t.domains[i]] = true
+		}
+	}
+	t.eligible = len(eligible)
+
+	// The least used eligible domain ends up with at most pools/eligible pools, so no domain may
+	// hold more than that plus maxSkew.
+	if constraint.MaxSkew > 0 && t.eligible > 0 {
+		t.maxCount = pools/t.eligible + int(constraint.MaxSkew)
+	}
+	return t
+}
+
+// allows reports whether candidate i may be chosen when remaining more pools are to be chosen
+// after it.
+func (t *spreadTracker) allows(i, remaining int) bool {
+	domain := t.domains[i]
+	if len(domain) == 0 {
+		return false
+	}
+	if t.maxCount > 0 && t.counts[domain] >= t.maxCount {
+		return false
+	}
+
+	distinct := len(t.counts)
+	if t.counts[domain] == 0 {
+		distinct++
+	}
+	return distinct+remaining >= int(t.constraint.MinDomains)
+}
+
+func (t *spreadTracker) add(i int) {
+	t.counts[t.domains[i]]++
+}
+
+func (t *spreadTracker) remove(i int) {
+	domain := t.domains[i]
+	t.counts[domain]--
+	if t.counts[domain] == 0 {
+		delete(t.counts, domain)
+	}
+}
+
+// satisfied reports whether the chosen pools meet the constraint. Eligible domains without a
+// chosen pool count as holding zero pools.
+func (t *spreadTracker) satisfied() bool {
+	if len(t.counts) < int(t.constraint.MinDomains) {
+		return false
+	}
+	if t.constraint.MaxSkew <= 0 {
+		return true
+	}
+
+	minCount, maxCount := -1, 0
+	for _, count := range t.counts {
+		if minCount < 0 || count < minCount {
+			minCount = count
+		}
+		if count > maxCount {
+			maxCount = count
+		}
+	}
+	if len(t.counts) < t.eligible {
+		minCount = 0
+	}
+	return maxCount-minCount <= int(t.constraint.MaxSkew)
+}
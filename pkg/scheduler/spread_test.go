@@ -0,0 +1,121 @@
+package scheduler
+
+import (
+	"reflect"
+	"testing"
+
+	v1 "github.com/openshift-splat-team/vsphere-capacity-manager/pkg/apis/vspherecapacitymanager.splat.io/v1"
+)
+
+func testZonePool(name, zone string) *v1.Pool {
+	pool := testPool(name, "vc1", 50, 200)
+	pool.Spec.Zone = zone
+	return pool
+}
+
+func TestSolveGangTopologySpread(t *testing.T) {
+	zonePools := []*v1.Pool{
+		testZonePool("a-1", "zone-a"),
+		testZonePool("a-2", "zone-a"),
+		testZonePool("a-3", "zone-a"),
+		testZonePool("b-1", "zone-b"),
+		testZonePool("c-1", "zone-c"),
+	}
+
+	tests := []struct {
+		name          string
+		pools         int
+		candidates    []*v1.Pool
+		spread        []v1.TopologySpreadConstraint
+		expectedPools []string
+		expectError   bool
+	}{
+		{
+			name:          "without constraints the best ranked pools are used",
+			pools:         3,
+			candidates:    zonePools,
+			expectedPools: []string{"a-1", "a-2", "a-3"},
+		},
+		{
+			name:          "minimum domains",
+			pools:         3,
+			candidates:    zonePools,
+			spread:        []v1.TopologySpreadConstraint{{TopologyKey: "zone", MinDomains: 3}},
+			expectedPools: []string{"a-1", "b-1", "c-1"},
+		},
+		{
+			name:          "max skew balances the pools across eligible zones",
+			pools:         3,
+			candidates:    zonePools,
+			spread:        []v1.TopologySpreadConstraint{{TopologyKey: "zone", MaxSkew: 1}},
+			expectedPools: []string{"a-1", "b-1", "c-1"},
+		},
+		{
+			name:          "max skew of two allows two pools in a zone",
+			pools:         3,
+			candidates:    zonePools,
+			spread:        []v1.TopologySpreadConstraint{{TopologyKey: "zone", MaxSkew: 2}},
+			expectedPools: []string{"a-1", "a-2", "b-1"},
+		},
+		{
+			name:  "pools without a value for the key are not used",
+			pools: 2,
+			candidates: []*v1.Pool{
+				testZonePool("no-zone", ""),
+				testZonePool("b-1", "zone-b"),
+				testZonePool("c-1", "zone-c"),
+			},
+			spread:        []v1.TopologySpreadConstraint{{TopologyKey: "zone", MinDomains: 2}},
+			expectedPools: []string{"b-1", "c-1"},
+		},
+		{
+			name:        "not enough domains",
+			pools:       3,
+			candidates:  zonePools[:4],
+			spread:      []v1.TopologySpreadConstraint{{TopologyKey: "zone", MinDomains: 3}},
+			expectError: true,
+		},
+		{
+			name:  "pool label as topology key",
+			pools: 2,
+			candidates: func() []*v1.Pool {
+				rack1, rack1b, rack2 := testZonePool("rack1", ""), testZonePool("rack1b", ""), testZonePool("rack2", "")
+				rack1.Labels = map[string]string{"rack": "1"}
+				rack1b.Labels = map[string]string{"rack": "1"}
+				rack2.Labels = map[string]string{"rack": "2"}
+				return []*v1.Pool{rack1, rack1b, rack2}
+			}(),
+			spread:        []v1.TopologySpreadConstraint{{TopologyKey: "rack", MinDomains: 2}},
+			expectedPools: []string{"rack1", "rack2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vlans := make(map[string][]string)
+			for _, pool := range tt.candidates {
+				vlans[pool.Name] = []string{"100"}
+			}
+
+			assignment, err := SolveGang(&GangRequest{
+				Pools:        tt.pools,
+				Networks:     1,
+				Candidates:   tt.candidates,
+				PoolNetworks: testPoolNetworks(vlans),
+				Spread:       tt.spread,
+			})
+			if tt.expectError {
+				if err == nil {
+					t.Fatalf("expected an error, got pools %v", poolNames(assignment.Pools))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if names := poolNames(assignment.Pools); !reflect.DeepEqual(names, tt.expectedPools) {
+				t.Errorf("expected pools %v, got %v", tt.expectedPools, names)
+			}
+		})
+	}
+}
@@ -25,6 +25,10 @@ func getWrapperObject(from interface{}) GetterSetter {
 	switch obj := from.(type) {
 	case *v1.Lease:
 		return &LeaseWrapper{obj}
+	case *v1.Network:
+		return &NetworkWrapper{obj}
+	case *v1.Pool:
+		return &PoolWrapper{obj}
 	default:
 		panic("type is not supported as conditions getter or setter")
 	}
@@ -91,6 +95,34 @@ func Set(to interface{}, condition *v1.Condition) {
 	obj.SetConditions(conditions)
 }
 
+// Get returns the condition with the given type, or nil if it is not present.
+func Get(from interface{}, t v1.ConditionType) *v1.Condition {
+	if from == nil {
+		return nil
+	}
+
+	for _, condition := range getWrapperObject(from).GetConditions() {
+		if condition.Type == t {
+			return &condition
+		}
+	}
+	return nil
+}
+
+// IsTrue returns true if the condition with the given type is present and has Status=True.
+func IsTrue(from interface{}, t v1.ConditionType) bool {
+	if from == nil {
+		return false
+	}
+
+	for _, condition := range getWrapperObject(from).GetConditions() {
+		if condition.Type == t {
+			return condition.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
+
 // TrueCondition returns a condition with Status=True and the given type.
 func TrueCondition(t v1.ConditionType) *v1.Condition {
 	return &v1.Condition{
@@ -1,29 +1,60 @@
 package utils
 
 import (
+	"errors"
 	"fmt"
-	"math/rand"
 	"sort"
 
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 
 	v1 "github.com/openshift-splat-team/vsphere-capacity-manager/pkg/apis/vspherecapacitymanager.splat.io/v1"
 )
 
 const (
-	PoolNotSchedulable      = "Pool not schedulable"
-	PoolExcluded            = "Pool marked as excluded"
-	PoolNotMatchRequired    = "Pool does not match required"
-	PoolInsufficientVCPU    = "Insufficient VCPU"
-	PoolInsufficientMemory  = "Insufficient memory"
-	PoolLabelMismatch       = "Pool labels do not match poolSelector"
-	PoolTaintNotTolerated   = "Pool has taints not tolerated by lease"
-	PoolVCenterLimitReached = "Pool vCenter limit reached"
+	PoolNotSchedulable       = "Pool not schedulable"
+	PoolExcluded             = "Pool marked as excluded"
+	PoolNotMatchRequired     = "Pool does not match required"
+	PoolInsufficientVCPU     = "Insufficient VCPU"
+	PoolInsufficientMemory   = "Insufficient memory"
+	PoolInsufficientStorage  = "Insufficient storage"
+	PoolInsufficientExtended = "Insufficient extended resource"
+	PoolLabelMismatch        = "Pool labels do not match poolSelector"
+	PoolTaintNotTolerated    = "Pool has taints not tolerated by lease"
+	PoolAlreadyAssigned      = "Pool already assigned to lease"
 )
 
+// Reason codes reported in Lease status.unschedulableReasons for the rejections above.
+const (
+	ReasonPoolAlreadyAssigned      = "AlreadyAssigned"
+	ReasonPoolNotSchedulable       = "NotSchedulable"
+	ReasonPoolExcluded             = "Excluded"
+	ReasonPoolNotMatchRequired     = "NotRequiredPool"
+	ReasonPoolLabelMismatch        = "LabelMismatch"
+	ReasonPoolTaintNotTolerated    = "TaintNotTolerated"
+	ReasonPoolInsufficientVCPU     = "InsufficientVCPU"
+	ReasonPoolInsufficientMemory   = "InsufficientMemory"
+	ReasonPoolInsufficientStorage  = "InsufficientStorage"
+	ReasonPoolInsufficientExtended = "InsufficientExtendedResource"
+)
+
+// Resources reported for capacity rejections. Extended resources are reported by their name.
+const (
+	ResourceVCpus   = "vcpus"
+	ResourceMemory  = "memory"
+	ResourceStorage = "storage"
+)
+
+// PoolFittingInfo is the reason a pool was rejected for a lease. For capacity rejections
+// Resource, Required and Available hold the measured values.
 type PoolFittingInfo struct {
 	Pool         *v1.Pool
 	MatchResults string
+	Reason       string
+	Resource     string
+	Required     int
+	Available    int
 }
 
 // tolerationMatchesTaint checks if a toleration matches a taint.
@@ -44,53 +75,89 @@ func tolerationMatchesTaint(toleration *v1.Toleration, taint *v1.Taint) bool {
 	return toleration.Key == taint.Key && toleration.Value == taint.Value
 }
 
-// LeaseToleratesPoolTaints checks if a lease has tolerations for all of a pool's taints.
-// Returns true if the lease can be scheduled on the pool, false otherwise.
+// isTaintTolerated reports whether any of the lease's tolerations match the taint.
+func isTaintTolerated(lease *v1.Lease, taint *v1.Taint) bool {
+	for i := range lease.Spec.Tolerations {
+		if tolerationMatchesTaint(&lease.Spec.Tolerations[i], taint) {
+			return true
+		}
+	}
+	return false
+}
+
+// LeaseToleratesPoolTaints checks if a lease has tolerations for all of a pool's NoSchedule taints.
+// Returns true if the lease can be scheduled on the pool, false otherwise. PreferNoSchedule taints
+// are soft and never prevent scheduling; see CountUntoleratedPreferNoScheduleTaints.
 func LeaseToleratesPoolTaints(lease *v1.Lease, pool *v1.Pool) bool {
 	// If pool has no taints, lease can always be scheduled
 	if len(pool.Spec.Taints) == 0 {
 		return true
 	}
 
-	// Check each taint to see if it's tolerated
-	for _, taint := range pool.Spec.Taints {
-		tolerated := false
-
-		// Check if any of the lease's tolerations match this taint
-		for i := range lease.Spec.Tolerations {
-			if tolerationMatchesTaint(&lease.Spec.Tolerations[i], &taint) {
-				tolerated = true
-				break
-			}
+	// Check each hard taint to see if it's tolerated
+	for i := range pool.Spec.Taints {
+		taint := &pool.Spec.Taints[i]
+		if taint.Effect == v1.TaintEffectPreferNoSchedule {
+			continue
 		}
 
 		// If this taint is not tolerated, the lease cannot be scheduled on this pool
-		if !tolerated {
+		if !isTaintTolerated(lease, taint) {
 			return false
 		}
 	}
 
-	// All taints are tolerated
+	// All hard taints are tolerated
 	return true
 }
 
-// PoolMatchesSelector checks if a pool's labels match the lease's poolSelector.
-// Returns true if all selector labels match the pool's labels.
+// CountUntoleratedPreferNoScheduleTaints returns the number of PreferNoSchedule taints on the pool
+// which the lease does not tolerate. Pools with a non-zero count remain schedulable, but are only
+// used as a fallback when no better pool fits.
+func CountUntoleratedPreferNoScheduleTaints(lease *v1.Lease, pool *v1.Pool) int {
+	count := 0
+	for i := range pool.Spec.Taints {
+		taint := &pool.Spec.Taints[i]
+		if taint.Effect != v1.TaintEffectPreferNoSchedule {
+			continue
+		}
+		if !isTaintTolerated(lease, taint) {
+			count++
+		}
+	}
+	return count
+}
+
+// LeasePoolSelector returns the label selector built from the lease's poolSelector and
+// poolSelectorExpressions. An error is returned if any of the expressions is invalid. The
+// poolSelector pairs are matched as exact strings and are not validated, so keys and values
+// which are not valid label keys and values keep selecting pools as they always have.
+func LeasePoolSelector(lease *v1.Lease) (labels.Selector, error) {
+	selector, err := metav1.LabelSelectorAsSelector(&metav1.LabelSelector{
+		MatchExpressions: lease.Spec.PoolSelectorExpressions,
+	})
+	if err != nil {
+		return nil, err
+	}
+	requirements, _ := labels.SelectorFromValidatedSet(lease.Spec.PoolSelector).Requirements()
+	return selector.Add(requirements...), nil
+}
+
+// PoolMatchesSelector checks if a pool's labels match the lease's poolSelector and poolSelectorExpressions.
+// Returns true if all selector labels and expressions match the pool's labels. A lease with an invalid
+// expression matches no pools.
 func PoolMatchesSelector(lease *v1.Lease, pool *v1.Pool) bool {
 	// If no selector is specified, pool matches
-	if len(lease.Spec.PoolSelector) == 0 {
+	if len(lease.Spec.PoolSelector) == 0 && len(lease.Spec.PoolSelectorExpressions) == 0 {
 		return true
 	}
 
-	// Check that all selector key-value pairs exist in the pool's labels
-	for key, value := range lease.Spec.PoolSelector {
-		poolValue, exists := pool.Labels[key]
-		if !exists || poolValue != value {
-			return false
-		}
+	selector, err := LeasePoolSelector(lease)
+	if err != nil {
+		return false
 	}
 
-	return true
+	return selector.Matches(labels.Set(pool.Labels))
 }
 
 // GetVCentersInUse returns the set of distinct vCenter Server FQDNs already used
@@ -109,8 +176,9 @@ func GetVCentersInUse(assignedPools []*v1.Pool) map[string]bool {
 // ignoring transient state such as current resource availability, current ownership, and
 // any vCenter exclusions computed for a particular reconcile pass. It captures only the
 // checks that depend on static configuration (RequiredPool/Exclude, PoolSelector,
-// Tolerations, NoSchedule), which is what determines whether a request could ever be
-// satisfied by this pool, as opposed to whether it can be satisfied right now.
+// Tolerations, NoSchedule, overcommitted capacity), which is what determines whether a
+// request could ever be satisfied by this pool, as opposed to whether it can be satisfied
+// right now.
 func poolMatchesStructural(lease *v1.Lease, pool *v1.Pool) bool {
 	if pool.Spec.NoSchedule {
 		return false
@@ -128,14 +196,29 @@ func poolMatchesStructural(lease *v1.Lease, pool *v1.Pool) bool {
 	if !LeaseToleratesPoolTaints(lease, pool) {
 		return false
 	}
+	if !poolCapacityFits(lease, pool) {
+		return false
+	}
 	return true
 }
 
+// StructurallyMatchingPools returns the pools that structurally match the lease, i.e. every pool
+// the lease could be placed on once enough capacity is free.
+func StructurallyMatchingPools(lease *v1.Lease, allPools []*v1.Pool) []*v1.Pool {
+	var matching []*v1.Pool
+	for _, pool := range allPools {
+		if poolMatchesStructural(lease, pool) {
+			matching = append(matching, pool)
+		}
+	}
+	return matching
+}
+
 // MaxAchievablePools returns the maximum number of pools a lease could ever be assigned,
 // given the full known pool inventory and the lease's structural constraints (RequiredPool,
-// PoolSelector, Tolerations, NoSchedule/Exclude) and its VCenters cap. It deliberately
-// ignores current resource availability and existing ownership, since those are transient:
-// this answers "can this request ever be satisfied," not "can it be satisfied right now."
+// PoolSelector, Tolerations, NoSchedule/Exclude, pool capacity) and its VCenters cap. It
+// deliberately ignores current resource availability and existing ownership, since those are
+// transient: this answers "can this request ever be satisfied," not "can it be satisfied right now."
 func MaxAchievablePools(lease *v1.Lease, allPools []*v1.Pool) int {
 	poolsPerVCenter := make(map[string]int)
 	for _, pool := range allPools {
@@ -180,6 +263,22 @@ func IsLeaseSatisfiable(lease *v1.Lease, allPools []*v1.Pool) (bool, string) {
 		requiredPools = 1
 	}
 
+	if _, err := LeasePoolSelector(lease); err != nil {
+		return false, fmt.Sprintf("lease has an invalid pool selector: %v", err)
+	}
+
+	for _, terms := range [][]v1.LeaseAffinityTerm{lease.Spec.LeaseAffinity, lease.Spec.LeaseAntiAffinity} {
+		for i := range terms {
+			if _, err := metav1.LabelSelectorAsSelector(&terms[i].LabelSelector); err != nil {
+				return false, fmt.Sprintf("lease has an invalid lease affinity selector: %v", err)
+			}
+		}
+	}
+
+	if ok, reason := topologySpreadSatisfiable(lease, requiredPools, allPools); !ok {
+		return false, reason
+	}
+
 	maxAchievable := MaxAchievablePools(lease, allPools)
 	if maxAchievable >= requiredPools {
 		return true, ""
@@ -191,12 +290,153 @@ func IsLeaseSatisfiable(lease *v1.Lease, allPools []*v1.Pool) (bool, string) {
 	)
 }
 
+// topologySpreadSatisfiable reports whether the lease's topology spread constraints could ever
+// be met: every constraint's minDomains must fit in the number of pools, the vCenters cap and the
+// domains of the pools the lease could be placed on.
+func topologySpreadSatisfiable(lease *v1.Lease, requiredPools int, allPools []*v1.Pool) (bool, string) {
+	if len(lease.Spec.TopologySpreadConstraints) == 0 {
+		return true, ""
+	}
+
+	matching := StructurallyMatchingPools(lease, allPools)
+	for _, constraint := range lease.Spec.TopologySpreadConstraints {
+		minDomains := int(constraint.MinDomains)
+		if minDomains > requiredPools {
+			return false, fmt.Sprintf("topology spread constraint on %s requires %d domains but the lease requires %d pool(s)",
+				constraint.TopologyKey, minDomains, requiredPools)
+		}
+		if v1.LeaseTopologyKey(constraint.TopologyKey) == v1.LeaseTopologyKeyServer && lease.Spec.VCenters > 0 && minDomains > lease.Spec.VCenters {
+			return false, fmt.Sprintf("topology spread constraint on %s requires %d domains but the lease is capped at %d vcenters",
+				constraint.TopologyKey, minDomains, lease.Spec.VCenters)
+		}
+
+		domains := make(map[string]bool)
+		for _, pool := range matching {
+			if domain := PoolTopologyDomain(pool, constraint.TopologyKey); len(domain) > 0 {
+				domains[domain] = true
+			}
+		}
+		if len(domains) < minDomains {
+			return false, fmt.Sprintf("topology spread constraint on %s requires %d domains but the known pool inventory has %d",
+				constraint.TopologyKey, minDomains, len(domains))
+		}
+	}
+	return true, ""
+}
+
+// PoolTopologyDomain returns the topology domain of the pool for the key: the pool name, the
+// vCenter for server, the failure domain region or zone, or otherwise the value of the pool label
+// with that key. An empty string means the pool is in no domain.
+func PoolTopologyDomain(pool *v1.Pool, key string) string {
+	switch v1.LeaseTopologyKey(key) {
+	case v1.LeaseTopologyKeyPool:
+		return pool.Name
+	case v1.LeaseTopologyKeyServer:
+		return pool.Spec.Server
+	case v1.LeaseTopologyKeyRegion:
+		return pool.Spec.Region
+	case v1.LeaseTopologyKeyZone:
+		return pool.Spec.Zone
+	}
+	return pool.Labels[key]
+}
+
+// parseOverCommitRatio returns the value of an overcommit ratio, or 1 when it is not set. Ratios
+// must be greater than zero.
+func parseOverCommitRatio(ratio *resource.Quantity) (float64, error) {
+	if ratio == nil {
+		return 1, nil
+	}
+	value := ratio.AsApproximateFloat64()
+	if value <= 0 {
+		return 1, fmt.Errorf("overcommit ratio %s must be greater than 0", ratio.String())
+	}
+	return value, nil
+}
+
+// PoolOverCommitRatios returns the vCPU, memory and storage overcommit ratios of the pool.
+// overCommit.cpu takes precedence over the deprecated overCommitRatio. Invalid ratios are
+// returned as 1 along with an error naming them.
+func PoolOverCommitRatios(pool *v1.Pool) (cpu, memory, storage float64, err error) {
+	var errs []error
+
+	cpuRatio := pool.Spec.OverCommit.CPU
+	if cpuRatio == nil && len(pool.Spec.OverCommitRatio) > 0 {
+		legacy, parseErr := resource.ParseQuantity(pool.Spec.OverCommitRatio)
+		if parseErr != nil {
+			errs = append(errs, fmt.Errorf("invalid overCommitRatio %q: %w", pool.Spec.OverCommitRatio, parseErr))
+		} else {
+			cpuRatio = &legacy
+		}
+	}
+
+	var ratioErr error
+	if cpu, ratioErr = parseOverCommitRatio(cpuRatio); ratioErr != nil {
+		errs = append(errs, fmt.Errorf("invalid cpu %w", ratioErr))
+	}
+	if memory, ratioErr = parseOverCommitRatio(pool.Spec.OverCommit.Memory); ratioErr != nil {
+		errs = append(errs, fmt.Errorf("invalid memory %w", ratioErr))
+	}
+	if storage, ratioErr = parseOverCommitRatio(pool.Spec.OverCommit.Storage); ratioErr != nil {
+		errs = append(errs, fmt.Errorf("invalid storage %w", ratioErr))
+	}
+	return cpu, memory, storage, errors.Join(errs...)
+}
+
+// PoolCapacity returns the vCPUs, memory in GB and storage in GB leases can be scheduled
+// against in the pool: its spec capacity multiplied by its overcommit ratios.
+func PoolCapacity(pool *v1.Pool) (vcpus, memory, storage int) {
+	cpuRatio, memoryRatio, storageRatio, _ := PoolOverCommitRatios(pool)
+	return int(float64(pool.Spec.VCpus) * cpuRatio),
+		int(float64(pool.Spec.Memory) * memoryRatio),
+		int(float64(pool.Spec.Storage) * storageRatio)
+}
+
+// poolCapacityFits reports whether the lease's per-pool request fits in the capacity of the pool
+// when nothing else is scheduled on it.
+func poolCapacityFits(lease *v1.Lease, pool *v1.Pool) bool {
+	vcpus, memory, storage := PoolCapacity(pool)
+	if lease.Spec.VCpus > vcpus || lease.Spec.Memory > memory {
+		return false
+	}
+	if name, _, _ := insufficientExtendedResource(lease, pool.Spec.ExtendedResources); len(name) > 0 {
+		return false
+	}
+	return lease.Spec.Storage <= 0 || pool.Spec.Storage <= 0 || lease.Spec.Storage <= storage
+}
+
+// insufficientExtendedResource returns the first extended resource, by name, the lease requests
+// more of than available holds, with the amounts required and available. It returns an empty
+// name when available holds enough of every extended resource the lease requests.
+func insufficientExtendedResource(lease *v1.Lease, available map[string]int) (string, int, int) {
+	names := make([]string, 0, len(lease.Spec.ExtendedResources))
+	for name := range lease.Spec.ExtendedResources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if required := lease.Spec.ExtendedResources[name]; required > 0 && available[name] < required {
+			return name, required, available[name]
+		}
+	}
+	return "", 0, 0
+}
+
+// PoolHasStorageFor reports whether the pool has enough datastore capacity left for the lease.
+// Pools with no Storage configured and leases that do not request storage are not constrained.
+func PoolHasStorageFor(lease *v1.Lease, pool *v1.Pool) bool {
+	if lease.Spec.Storage <= 0 || pool.Spec.Storage <= 0 {
+		return true
+	}
+	return pool.Status.DatastoreAvailable >= lease.Spec.Storage
+}
+
 // GetFittingPools returns a list of pools that have enough resources to satisfy the resource requirements and a list of
 // PoolFittingInfo specifying why pool is not a match.
-// The list is sorted by the sum of the resource usage of the pool. The pool with the least resource usage is first.
-// excludedVCenters is an optional set of vCenter Server FQDNs to exclude from consideration (used to enforce
-// the lease's VCenters cap). Pass nil or an empty map for no vcenter constraint.
-func GetFittingPools(lease *v1.Lease, pools []*v1.Pool, excludedVCenters map[string]bool) ([]*v1.Pool, []*PoolFittingInfo) {
+// The list is sorted by the sum of the resource usage of the pool. The pool with the least resource usage is first,
+// except that pools with untolerated PreferNoSchedule taints are always sorted after pools without them.
+func GetFittingPools(lease *v1.Lease, pools []*v1.Pool) ([]*v1.Pool, []*PoolFittingInfo) {
 	var fittingPools []*v1.Pool
 	poolResults := []*PoolFittingInfo{}
 
@@ -210,77 +450,86 @@ func GetFittingPools(lease *v1.Lease, pools []*v1.Pool, excludedVCenters map[str
 			}
 		}
 		if alreadyOwned {
-			poolResults = append(poolResults, &PoolFittingInfo{Pool: pool, MatchResults: "Pool already assigned to lease"})
+			poolResults = append(poolResults, &PoolFittingInfo{Pool: pool, MatchResults: PoolAlreadyAssigned, Reason: ReasonPoolAlreadyAssigned})
 			continue
 		}
 
 		if pool.Spec.NoSchedule {
-			poolResults = append(poolResults, &PoolFittingInfo{Pool: pool, MatchResults: PoolNotSchedulable})
+			poolResults = append(poolResults, &PoolFittingInfo{Pool: pool, MatchResults: PoolNotSchedulable, Reason: ReasonPoolNotSchedulable})
 			continue
 		}
 		nameMatch := len(lease.Spec.RequiredPool) > 0 && lease.Spec.RequiredPool == pool.ObjectMeta.Name
 		if !nameMatch && pool.Spec.Exclude {
-			poolResults = append(poolResults, &PoolFittingInfo{Pool: pool, MatchResults: PoolExcluded})
+			poolResults = append(poolResults, &PoolFittingInfo{Pool: pool, MatchResults: PoolExcluded, Reason: ReasonPoolExcluded})
 			continue
 		}
 		if len(lease.Spec.RequiredPool) > 0 && !nameMatch {
-			poolResults = append(poolResults, &PoolFittingInfo{Pool: pool, MatchResults: PoolNotMatchRequired})
+			poolResults = append(poolResults, &PoolFittingInfo{Pool: pool, MatchResults: PoolNotMatchRequired, Reason: ReasonPoolNotMatchRequired})
 			continue
 		}
 		// Check if pool labels match the lease's poolSelector
 		if !PoolMatchesSelector(lease, pool) {
-			poolResults = append(poolResults, &PoolFittingInfo{Pool: pool, MatchResults: PoolLabelMismatch})
+			poolResults = append(poolResults, &PoolFittingInfo{Pool: pool, MatchResults: PoolLabelMismatch, Reason: ReasonPoolLabelMismatch})
 			continue
 		}
 		// Check if lease tolerates all pool taints
 		if !LeaseToleratesPoolTaints(lease, pool) {
-			poolResults = append(poolResults, &PoolFittingInfo{Pool: pool, MatchResults: PoolTaintNotTolerated})
+			poolResults = append(poolResults, &PoolFittingInfo{Pool: pool, MatchResults: PoolTaintNotTolerated, Reason: ReasonPoolTaintNotTolerated})
 			continue
 		}
 
-		// If a vCenter cap is in effect, skip pools on excluded vCenters
-		// This check comes after fundamental pool properties and lease requirements
-		// to ensure more specific rejection reasons are reported first
-		if len(excludedVCenters) > 0 && excludedVCenters[pool.Spec.Server] {
-			poolResults = append(poolResults, &PoolFittingInfo{Pool: pool, MatchResults: PoolVCenterLimitReached})
-			continue
-		}
+		extendedResource, extendedRequired, extendedAvailable := insufficientExtendedResource(lease, pool.Status.ExtendedResourcesAvailable)
 		if int(pool.Status.VCpusAvailable) >= lease.Spec.VCpus &&
-			int(pool.Status.MemoryAvailable) >= lease.Spec.Memory {
+			int(pool.Status.MemoryAvailable) >= lease.Spec.Memory &&
+			PoolHasStorageFor(lease, pool) &&
+			len(extendedResource) == 0 {
 			fittingPools = append(fittingPools, pool)
 		} else {
-			var reason string
+			var result *PoolFittingInfo
 			if pool.Status.VCpusAvailable < lease.Spec.VCpus {
-				reason = PoolInsufficientVCPU
+				result = &PoolFittingInfo{MatchResults: PoolInsufficientVCPU, Reason: ReasonPoolInsufficientVCPU,
+					Resource: ResourceVCpus, Required: lease.Spec.VCpus, Available: pool.Status.VCpusAvailable}
 			} else if pool.Status.MemoryAvailable < lease.Spec.Memory {
-				reason = PoolInsufficientMemory
+				result = &PoolFittingInfo{MatchResults: PoolInsufficientMemory, Reason: ReasonPoolInsufficientMemory,
+					Resource: ResourceMemory, Required: lease.Spec.Memory, Available: pool.Status.MemoryAvailable}
+			} else if !PoolHasStorageFor(lease, pool) {
+				result = &PoolFittingInfo{MatchResults: PoolInsufficientStorage, Reason: ReasonPoolInsufficientStorage,
+					Resource: ResourceStorage, Required: lease.Spec.Storage, Available: pool.Status.DatastoreAvailable}
 			} else {
-				reason = fmt.Sprintf("[%v, %v]", PoolInsufficientVCPU, PoolInsufficientMemory)
+				result = &PoolFittingInfo{MatchResults: PoolInsufficientExtended, Reason: ReasonPoolInsufficientExtended,
+					Resource: extendedResource, Required: extendedRequired, Available: extendedAvailable}
 			}
 
-			poolResults = append(poolResults, &PoolFittingInfo{Pool: pool, MatchResults: reason})
+			result.Pool = pool
+			poolResults = append(poolResults, result)
 		}
 	}
 	sort.Slice(fittingPools, func(i, j int) bool {
 		iPool := fittingPools[i]
 		jPool := fittingPools[j]
-		cpuScoreI := float64(iPool.Status.VCpusAvailable) / float64(iPool.Spec.VCpus)
-		memoryScoreI := float64(iPool.Status.MemoryAvailable) / float64(iPool.Spec.Memory)
-		cpuScoreJ := float64(jPool.Status.VCpusAvailable) / float64(jPool.Spec.VCpus)
-		memoryScoreJ := float64(jPool.Status.MemoryAvailable) / float64(jPool.Spec.Memory)
+
+		// Pools with untolerated PreferNoSchedule taints are fallback candidates and always rank
+		// below pools the lease fully tolerates, regardless of free capacity.
+		iPreferNoSchedule := CountUntoleratedPreferNoScheduleTaints(lease, iPool)
+		jPreferNoSchedule := CountUntoleratedPreferNoScheduleTaints(lease, jPool)
+		if iPreferNoSchedule != jPreferNoSchedule {
+			return iPreferNoSchedule < jPreferNoSchedule
+		}
+
+		iVCpus, iMemory, _ := PoolCapacity(iPool)
+		jVCpus, jMemory, _ := PoolCapacity(jPool)
+		cpuScoreI := float64(iPool.Status.VCpusAvailable) / float64(iVCpus)
+		memoryScoreI := float64(iPool.Status.MemoryAvailable) / float64(iMemory)
+		cpuScoreJ := float64(jPool.Status.VCpusAvailable) / float64(jVCpus)
+		memoryScoreJ := float64(jPool.Status.MemoryAvailable) / float64(jMemory)
 
 		return cpuScoreI+memoryScoreI > cpuScoreJ+memoryScoreJ
 	})
 	return fittingPools, poolResults
 }
 
-func shuffleFittingPools(pools []*v1.Pool) {
-	rand.Shuffle(len(pools), func(i, j int) {
-		pools[i], pools[j] = pools[j], pools[i]
-	})
-}
-
-func generatePoolResults(results []*PoolFittingInfo) []string {
+// GeneratePoolResults formats the reasons pools were rejected for a lease.
+func GeneratePoolResults(results []*PoolFittingInfo) []string {
 	var poolResults []string
 
 	for _, result := range results {
@@ -289,42 +538,60 @@ func generatePoolResults(results []*PoolFittingInfo) []string {
 	return poolResults
 }
 
-// GetPoolWithStrategy returns a pool that has enough resources to satisfy the lease requirements.
-// excludedVCenters is an optional set of vCenter Server FQDNs to exclude (enforces the VCenters cap).
-// Pass nil for no vcenter constraint.
-func GetPoolWithStrategy(lease *v1.Lease, pools []*v1.Pool, strategy v1.AllocationStrategy, excludedVCenters map[string]bool) (*v1.Pool, error) {
-	fittingPools, results := GetFittingPools(lease, pools, excludedVCenters)
-
-	if len(fittingPools) == 0 {
-		return nil, fmt.Errorf("no pools available. %v", generatePoolResults(results))
-	}
-	switch strategy {
-	case v1.RESOURCE_ALLOCATION_STRATEGY_RANDOM:
-		shuffleFittingPools(fittingPools)
-		fallthrough
-	case v1.RESOURCE_ALLOCATION_STRATEGY_UNDERUTILIZED:
-		fallthrough
-	default:
-		pool := fittingPools[0]
-
-		// Check if this pool is already an owner reference
-		alreadyOwner := false
-		for _, ref := range lease.OwnerReferences {
-			if ref.Kind == "Pool" && ref.Name == pool.Name {
-				alreadyOwner = true
-				break
-			}
+// NewUnschedulableReasons summarizes the reasons pools were rejected for a lease for the lease's
+// status. At most v1.MaxUnschedulablePools pools are listed; the summary counts all of them.
+func NewUnschedulableReasons(results []*PoolFittingInfo, attemptTime metav1.Time) *v1.UnschedulableReasons {
+	reasons := &v1.UnschedulableReasons{LastAttemptTime: attemptTime}
+
+	counts := make(map[string]int)
+	for _, result := range results {
+		reason := result.Reason
+		if len(reason) == 0 {
+			reason = "Unknown"
 		}
+		counts[reason]++
+		reasons.Pools = append(reasons.Pools, v1.PoolUnschedulableReason{
+			Pool:      result.Pool.Name,
+			Reason:    reason,
+			Message:   result.MatchResults,
+			Resource:  result.Resource,
+			Required:  result.Required,
+			Available: result.Available,
+		})
+	}
 
-		if !alreadyOwner {
-			lease.OwnerReferences = append(lease.OwnerReferences, metav1.OwnerReference{
-				APIVersion: pool.APIVersion,
-				Kind:       pool.Kind,
-				Name:       pool.Name,
-				UID:        pool.UID,
-			})
+	for reason, count := range counts {
+		reasons.Summary = append(reasons.Summary, v1.UnschedulableReasonCount{Reason: reason, Count: count})
+	}
+	sort.Slice(reasons.Summary, func(i, j int) bool {
+		if reasons.Summary[i].Count != reasons.Summary[j].Count {
+			return reasons.Summary[i].Count > reasons.Summary[j].Count
 		}
+		return reasons.Summary[i].Reason < reasons.Summary[j].Reason
+	})
 
-		return pool, nil
+	sort.Slice(reasons.Pools, func(i, j int) bool {
+		return reasons.Pools[i].Pool < reasons.Pools[j].Pool
+	})
+	if len(reasons.Pools) > v1.MaxUnschedulablePools {
+		reasons.OmittedPools = len(reasons.Pools) - v1.MaxUnschedulablePools
+		reasons.Pools = reasons.Pools[:v1.MaxUnschedulablePools]
 	}
+	return reasons
+}
+
+// AddPoolOwnerReference adds the pool as an owner of the lease unless it already is one.
+func AddPoolOwnerReference(lease *v1.Lease, pool *v1.Pool) {
+	for _, ref := range lease.OwnerReferences {
+		if ref.Kind == "Pool" && ref.Name == pool.Name {
+			return
+		}
+	}
+
+	lease.OwnerReferences = append(lease.OwnerReferences, metav1.OwnerReference{
+		APIVersion: pool.APIVersion,
+		Kind:       pool.Kind,
+		Name:       pool.Name,
+		UID:        pool.UID,
+	})
 }
@@ -1,9 +1,12 @@
 package utils
 
 import (
+	"fmt"
+	"reflect"
 	"testing"
 
 	configv1 "github.com/openshift/api/config/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	v1 "github.com/openshift-splat-team/vsphere-capacity-manager/pkg/apis/vspherecapacitymanager.splat.io/v1"
@@ -323,6 +326,54 @@ func TestLeaseToleratesPoolTaints(t *testing.T) {
 			},
 			expected: true,
 		},
+		{
+			name: "untolerated PreferNoSchedule taint does not block scheduling",
+			lease: &v1.Lease{
+				Spec: v1.LeaseSpec{},
+			},
+			pool: &v1.Pool{
+				Spec: v1.PoolSpec{
+					Taints: []v1.Taint{
+						{
+							Key:    "retiring",
+							Value:  "true",
+							Effect: v1.TaintEffectPreferNoSchedule,
+						},
+					},
+				},
+			},
+			expected: true,
+		},
+		{
+			name: "untolerated NoSchedule taint blocks even when PreferNoSchedule taint is tolerated",
+			lease: &v1.Lease{
+				Spec: v1.LeaseSpec{
+					Tolerations: []v1.Toleration{
+						{
+							Key:      "retiring",
+							Operator: v1.TolerationOpExists,
+						},
+					},
+				},
+			},
+			pool: &v1.Pool{
+				Spec: v1.PoolSpec{
+					Taints: []v1.Taint{
+						{
+							Key:    "retiring",
+							Value:  "true",
+							Effect: v1.TaintEffectPreferNoSchedule,
+						},
+						{
+							Key:    "dedicated",
+							Value:  "gpu",
+							Effect: v1.TaintEffectNoSchedule,
+						},
+					},
+				},
+			},
+			expected: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -335,6 +386,118 @@ func TestLeaseToleratesPoolTaints(t *testing.T) {
 	}
 }
 
+func TestCountUntoleratedPreferNoScheduleTaints(t *testing.T) {
+	pool := &v1.Pool{
+		Spec: v1.PoolSpec{
+			Taints: []v1.Taint{
+				{Key: "retiring", Value: "true", Effect: v1.TaintEffectPreferNoSchedule},
+				{Key: "slow-storage", Effect: v1.TaintEffectPreferNoSchedule},
+				{Key: "dedicated", Value: "gpu", Effect: v1.TaintEffectNoSchedule},
+			},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		tolerations []v1.Toleration
+		expected    int
+	}{
+		{
+			name:     "no tolerations counts every PreferNoSchedule taint",
+			expected: 2,
+		},
+		{
+			name: "tolerated PreferNoSchedule taint is not counted",
+			tolerations: []v1.Toleration{
+				{Key: "retiring", Operator: v1.TolerationOpExists},
+			},
+			expected: 1,
+		},
+		{
+			name: "NoSchedule taints are never counted",
+			tolerations: []v1.Toleration{
+				{Key: "retiring", Operator: v1.TolerationOpExists},
+				{Key: "slow-storage", Operator: v1.TolerationOpExists},
+			},
+			expected: 0,
+		},
+		{
+			name: "toleration restricted to NoSchedule does not tolerate PreferNoSchedule",
+			tolerations: []v1.Toleration{
+				{Key: "retiring", Operator: v1.TolerationOpExists, Effect: string(v1.TaintEffectNoSchedule)},
+			},
+			expected: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lease := &v1.Lease{Spec: v1.LeaseSpec{Tolerations: tt.tolerations}}
+			result := CountUntoleratedPreferNoScheduleTaints(lease, pool)
+			if result != tt.expected {
+				t.Errorf("CountUntoleratedPreferNoScheduleTaints() = %d, expected %d", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestGetFittingPoolsPreferNoScheduleRanking(t *testing.T) {
+	lease := &v1.Lease{
+		Spec: v1.LeaseSpec{
+			VCpus:  16,
+			Memory: 32,
+		},
+	}
+
+	// The retiring pool has the most free capacity, so without the taint it would be picked first.
+	retiring := &v1.Pool{
+		ObjectMeta: metav1.ObjectMeta{Name: "pool-retiring"},
+		Spec: v1.PoolSpec{
+			VCpus:  100,
+			Memory: 100,
+			Taints: []v1.Taint{
+				{Key: "retiring", Value: "true", Effect: v1.TaintEffectPreferNoSchedule},
+			},
+		},
+		Status: v1.PoolStatus{VCpusAvailable: 100, MemoryAvailable: 100},
+	}
+	busy := &v1.Pool{
+		ObjectMeta: metav1.ObjectMeta{Name: "pool-busy"},
+		Spec:       v1.PoolSpec{VCpus: 100, Memory: 100},
+		Status:     v1.PoolStatus{VCpusAvailable: 20, MemoryAvailable: 40},
+	}
+
+	t.Run("untainted pool ranks ahead of PreferNoSchedule pool", func(t *testing.T) {
+		fittingPools, _ := GetFittingPools(lease, []*v1.Pool{retiring, busy})
+		if len(fittingPools) != 2 {
+			t.Fatalf("expected both pools to fit, got %d", len(fittingPools))
+		}
+		if fittingPools[0].Name != "pool-busy" {
+			t.Errorf("expected pool-busy to be ranked first, got %s", fittingPools[0].Name)
+		}
+	})
+
+	t.Run("PreferNoSchedule pool is used when nothing else fits", func(t *testing.T) {
+		full := busy.DeepCopy()
+		full.Status.VCpusAvailable = 0
+
+		fittingPools, _ := GetFittingPools(lease, []*v1.Pool{retiring, full})
+		if len(fittingPools) != 1 || fittingPools[0].Name != "pool-retiring" {
+			t.Errorf("expected the PreferNoSchedule pool to be used as a fallback, got %v", fittingPools)
+		}
+	})
+
+	t.Run("tolerating the taint removes the penalty", func(t *testing.T) {
+		tolerating := lease.DeepCopy()
+		tolerating.Spec.Tolerations = []v1.Toleration{{Key: "retiring", Operator: v1.TolerationOpExists}}
+
+		fittingPools, _ := GetFittingPools(tolerating, []*v1.Pool{busy, retiring})
+		if len(fittingPools) != 2 || fittingPools[0].Name != "pool-retiring" {
+			t.Errorf("expected pool-retiring to be ranked first once tolerated, got %v", fittingPools)
+		}
+	})
+}
+
 func TestPoolMatchesSelector(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -431,6 +594,137 @@ func TestPoolMatchesSelector(t *testing.T) {
 			},
 			expected: false,
 		},
+		{
+			name: "In expression matches one of several values",
+			lease: &v1.Lease{
+				Spec: v1.LeaseSpec{
+					PoolSelectorExpressions: []metav1.LabelSelectorRequirement{
+						{Key: "region", Operator: metav1.LabelSelectorOpIn, Values: []string{"us-east", "us-south"}},
+					},
+				},
+			},
+			pool: &v1.Pool{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{"region": "us-south"},
+				},
+			},
+			expected: true,
+		},
+		{
+			name: "In expression rejects value outside the set",
+			lease: &v1.Lease{
+				Spec: v1.LeaseSpec{
+					PoolSelectorExpressions: []metav1.LabelSelectorRequirement{
+						{Key: "region", Operator: metav1.LabelSelectorOpIn, Values: []string{"us-east", "us-south"}},
+					},
+				},
+			},
+			pool: &v1.Pool{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{"region": "us-west"},
+				},
+			},
+			expected: false,
+		},
+		{
+			name: "NotIn expression rejects excluded value",
+			lease: &v1.Lease{
+				Spec: v1.LeaseSpec{
+					PoolSelectorExpressions: []metav1.LabelSelectorRequirement{
+						{Key: "vsphere-version", Operator: metav1.LabelSelectorOpNotIn, Values: []string{"7"}},
+					},
+				},
+			},
+			pool: &v1.Pool{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{"vsphere-version": "7"},
+				},
+			},
+			expected: false,
+		},
+		{
+			name: "NotIn expression matches pool without the label",
+			lease: &v1.Lease{
+				Spec: v1.LeaseSpec{
+					PoolSelectorExpressions: []metav1.LabelSelectorRequirement{
+						{Key: "vsphere-version", Operator: metav1.LabelSelectorOpNotIn, Values: []string{"7"}},
+					},
+				},
+			},
+			pool: &v1.Pool{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{"region": "us-east"},
+				},
+			},
+			expected: true,
+		},
+		{
+			name: "Exists and DoesNotExist expressions",
+			lease: &v1.Lease{
+				Spec: v1.LeaseSpec{
+					PoolSelectorExpressions: []metav1.LabelSelectorRequirement{
+						{Key: "gpu", Operator: metav1.LabelSelectorOpExists},
+						{Key: "retiring", Operator: metav1.LabelSelectorOpDoesNotExist},
+					},
+				},
+			},
+			pool: &v1.Pool{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{"gpu": "a100"},
+				},
+			},
+			expected: true,
+		},
+		{
+			name: "expressions are ANDed with poolSelector",
+			lease: &v1.Lease{
+				Spec: v1.LeaseSpec{
+					PoolSelector: map[string]string{"tier": "gpu"},
+					PoolSelectorExpressions: []metav1.LabelSelectorRequirement{
+						{Key: "region", Operator: metav1.LabelSelectorOpIn, Values: []string{"us-east", "us-south"}},
+					},
+				},
+			},
+			pool: &v1.Pool{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{"region": "us-east", "tier": "cpu"},
+				},
+			},
+			expected: false,
+		},
+		{
+			name: "invalid expression matches no pools",
+			lease: &v1.Lease{
+				Spec: v1.LeaseSpec{
+					PoolSelectorExpressions: []metav1.LabelSelectorRequirement{
+						{Key: "region", Operator: metav1.LabelSelectorOpIn},
+					},
+				},
+			},
+			pool: &v1.Pool{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{"region": "us-east"},
+				},
+			},
+			expected: false,
+		},
+		{
+			name: "poolSelector values need not be valid label values",
+			lease: &v1.Lease{
+				Spec: v1.LeaseSpec{
+					PoolSelector: map[string]string{"owner": "team a/b"},
+					PoolSelectorExpressions: []metav1.LabelSelectorRequirement{
+						{Key: "region", Operator: metav1.LabelSelectorOpExists},
+					},
+				},
+			},
+			pool: &v1.Pool{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{"owner": "team a/b", "region": "us-east"},
+				},
+			},
+			expected: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -448,7 +742,6 @@ func TestGetFittingPools(t *testing.T) {
 		name               string
 		lease              *v1.Lease
 		pools              []*v1.Pool
-		excludedVCenters   map[string]bool
 		expectedFittingLen int
 		expectedRejections map[string]string
 	}{
@@ -500,6 +793,54 @@ func TestGetFittingPools(t *testing.T) {
 				"pool2": PoolLabelMismatch,
 			},
 		},
+		{
+			name: "pool selector expressions filter out non-matching pools",
+			lease: &v1.Lease{
+				Spec: v1.LeaseSpec{
+					VCpus:  16,
+					Memory: 32,
+					PoolSelectorExpressions: []metav1.LabelSelectorRequirement{
+						{Key: "vsphere-version", Operator: metav1.LabelSelectorOpNotIn, Values: []string{"7"}},
+					},
+				},
+			},
+			pools: []*v1.Pool{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "pool-v8",
+						Labels: map[string]string{
+							"vsphere-version": "8",
+						},
+					},
+					Spec: v1.PoolSpec{
+						VCpus: 100,
+					},
+					Status: v1.PoolStatus{
+						VCpusAvailable:  50,
+						MemoryAvailable: 100,
+					},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "pool-v7",
+						Labels: map[string]string{
+							"vsphere-version": "7",
+						},
+					},
+					Spec: v1.PoolSpec{
+						VCpus: 100,
+					},
+					Status: v1.PoolStatus{
+						VCpusAvailable:  50,
+						MemoryAvailable: 100,
+					},
+				},
+			},
+			expectedFittingLen: 1,
+			expectedRejections: map[string]string{
+				"pool-v7": PoolLabelMismatch,
+			},
+		},
 		{
 			name: "taint toleration filters pools",
 			lease: &v1.Lease{
@@ -680,17 +1021,13 @@ func TestGetFittingPools(t *testing.T) {
 			},
 		},
 		{
-			name: "excludedVCenters filters pools on capped vcenters",
+			name: "pools on different vcenters all fit",
 			lease: &v1.Lease{
 				Spec: v1.LeaseSpec{
 					VCpus:  16,
 					Memory: 32,
 				},
 			},
-			excludedVCenters: map[string]bool{
-				"vcenter1.example.com": true,
-				"vcenter2.example.com": true,
-			},
 			pools: []*v1.Pool{
 				{
 					ObjectMeta: metav1.ObjectMeta{
@@ -726,120 +1063,67 @@ func TestGetFittingPools(t *testing.T) {
 						MemoryAvailable: 100,
 					},
 				},
+			},
+			expectedFittingLen: 2,
+			expectedRejections: map[string]string{},
+		},
+		{
+			name: "insufficient datastore capacity rejects pool",
+			lease: &v1.Lease{
+				Spec: v1.LeaseSpec{
+					VCpus:   16,
+					Memory:  32,
+					Storage: 720,
+				},
+			},
+			pools: []*v1.Pool{
 				{
-					ObjectMeta: metav1.ObjectMeta{
-						Name: "pool-vc3",
+					ObjectMeta: metav1.ObjectMeta{Name: "pool-full-datastore"},
+					Spec: v1.PoolSpec{
+						VCpus:   100,
+						Memory:  200,
+						Storage: 4000,
+					},
+					Status: v1.PoolStatus{
+						VCpusAvailable:     50,
+						MemoryAvailable:    100,
+						DatastoreAvailable: 500,
 					},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "pool-free-datastore"},
 					Spec: v1.PoolSpec{
-						FailureDomainSpec: v1.FailureDomainSpec{
-							VSpherePlatformFailureDomainSpec: configv1.VSpherePlatformFailureDomainSpec{
-								Server: "vcenter3.example.com",
-							},
-						},
-						VCpus: 100,
+						VCpus:   100,
+						Memory:  200,
+						Storage: 4000,
 					},
 					Status: v1.PoolStatus{
-						VCpusAvailable:  50,
-						MemoryAvailable: 100,
+						VCpusAvailable:     50,
+						MemoryAvailable:    100,
+						DatastoreAvailable: 1000,
 					},
 				},
 			},
-			// pool-vc3 is the only pool on a non-excluded vcenter
 			expectedFittingLen: 1,
 			expectedRejections: map[string]string{
-				"pool-vc1": PoolVCenterLimitReached,
-				"pool-vc2": PoolVCenterLimitReached,
+				"pool-full-datastore": PoolInsufficientStorage,
 			},
 		},
 		{
-			name: "nil excludedVCenters applies no vcenter constraint",
+			name: "pool without storage configured does not restrict storage",
 			lease: &v1.Lease{
 				Spec: v1.LeaseSpec{
-					VCpus:  16,
-					Memory: 32,
-				},
-			},
-			excludedVCenters: nil,
-			pools: []*v1.Pool{
-				{
-					ObjectMeta: metav1.ObjectMeta{
-						Name: "pool-vc1",
-					},
-					Spec: v1.PoolSpec{
-						FailureDomainSpec: v1.FailureDomainSpec{
-							VSpherePlatformFailureDomainSpec: configv1.VSpherePlatformFailureDomainSpec{
-								Server: "vcenter1.example.com",
-							},
-						},
-						VCpus: 100,
-					},
-					Status: v1.PoolStatus{
-						VCpusAvailable:  50,
-						MemoryAvailable: 100,
-					},
-				},
-				{
-					ObjectMeta: metav1.ObjectMeta{
-						Name: "pool-vc2",
-					},
-					Spec: v1.PoolSpec{
-						FailureDomainSpec: v1.FailureDomainSpec{
-							VSpherePlatformFailureDomainSpec: configv1.VSpherePlatformFailureDomainSpec{
-								Server: "vcenter2.example.com",
-							},
-						},
-						VCpus: 100,
-					},
-					Status: v1.PoolStatus{
-						VCpusAvailable:  50,
-						MemoryAvailable: 100,
-					},
+					VCpus:   16,
+					Memory:  32,
+					Storage: 720,
 				},
 			},
-			// Both pools are fitting since no vcenter constraint is applied
-			expectedFittingLen: 2,
-			expectedRejections: map[string]string{},
-		},
-		{
-			name: "pool on non-excluded vcenter passes vcenter check",
-			lease: &v1.Lease{
-				Spec: v1.LeaseSpec{
-					VCpus:  16,
-					Memory: 32,
-				},
-			},
-			excludedVCenters: map[string]bool{
-				"vcenter1.example.com": true,
-			},
 			pools: []*v1.Pool{
 				{
-					ObjectMeta: metav1.ObjectMeta{
-						Name: "pool-excluded",
-					},
-					Spec: v1.PoolSpec{
-						FailureDomainSpec: v1.FailureDomainSpec{
-							VSpherePlatformFailureDomainSpec: configv1.VSpherePlatformFailureDomainSpec{
-								Server: "vcenter1.example.com",
-							},
-						},
-						VCpus: 100,
-					},
-					Status: v1.PoolStatus{
-						VCpusAvailable:  50,
-						MemoryAvailable: 100,
-					},
-				},
-				{
-					ObjectMeta: metav1.ObjectMeta{
-						Name: "pool-allowed",
-					},
+					ObjectMeta: metav1.ObjectMeta{Name: "pool-untracked"},
 					Spec: v1.PoolSpec{
-						FailureDomainSpec: v1.FailureDomainSpec{
-							VSpherePlatformFailureDomainSpec: configv1.VSpherePlatformFailureDomainSpec{
-								Server: "vcenter2.example.com",
-							},
-						},
-						VCpus: 100,
+						VCpus:  100,
+						Memory: 200,
 					},
 					Status: v1.PoolStatus{
 						VCpusAvailable:  50,
@@ -848,89 +1132,12 @@ func TestGetFittingPools(t *testing.T) {
 				},
 			},
 			expectedFittingLen: 1,
-			expectedRejections: map[string]string{
-				"pool-excluded": PoolVCenterLimitReached,
-			},
-		},
-		{
-			name: "vcenter check reports correct reason when pool has NoSchedule",
-			lease: &v1.Lease{
-				Spec: v1.LeaseSpec{
-					VCpus:  16,
-					Memory: 32,
-				},
-			},
-			excludedVCenters: map[string]bool{
-				"vcenter1.example.com": true,
-			},
-			pools: []*v1.Pool{
-				{
-					ObjectMeta: metav1.ObjectMeta{
-						Name: "pool-noschedule",
-					},
-					Spec: v1.PoolSpec{
-						FailureDomainSpec: v1.FailureDomainSpec{
-							VSpherePlatformFailureDomainSpec: configv1.VSpherePlatformFailureDomainSpec{
-								Server: "vcenter1.example.com",
-							},
-						},
-						VCpus:      100,
-						NoSchedule: true, // This should be reported instead of vcenter limit
-					},
-					Status: v1.PoolStatus{
-						VCpusAvailable:  50,
-						MemoryAvailable: 100,
-					},
-				},
-			},
-			expectedFittingLen: 0,
-			expectedRejections: map[string]string{
-				// Should report NoSchedule, not PoolVCenterLimitReached
-				"pool-noschedule": PoolNotSchedulable,
-			},
-		},
-		{
-			name: "vcenter check reports correct reason when pool is excluded",
-			lease: &v1.Lease{
-				Spec: v1.LeaseSpec{
-					VCpus:  16,
-					Memory: 32,
-				},
-			},
-			excludedVCenters: map[string]bool{
-				"vcenter1.example.com": true,
-			},
-			pools: []*v1.Pool{
-				{
-					ObjectMeta: metav1.ObjectMeta{
-						Name: "pool-excluded",
-					},
-					Spec: v1.PoolSpec{
-						FailureDomainSpec: v1.FailureDomainSpec{
-							VSpherePlatformFailureDomainSpec: configv1.VSpherePlatformFailureDomainSpec{
-								Server: "vcenter1.example.com",
-							},
-						},
-						VCpus:   100,
-						Exclude: true, // This should be reported instead of vcenter limit
-					},
-					Status: v1.PoolStatus{
-						VCpusAvailable:  50,
-						MemoryAvailable: 100,
-					},
-				},
-			},
-			expectedFittingLen: 0,
-			expectedRejections: map[string]string{
-				// Should report Exclude, not PoolVCenterLimitReached
-				"pool-excluded": PoolExcluded,
-			},
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			fittingPools, poolResults := GetFittingPools(tt.lease, tt.pools, tt.excludedVCenters)
+			fittingPools, poolResults := GetFittingPools(tt.lease, tt.pools)
 
 			if len(fittingPools) != tt.expectedFittingLen {
 				t.Errorf("GetFittingPools() returned %d fitting pools, expected %d",
@@ -957,719 +1164,296 @@ func TestGetFittingPools(t *testing.T) {
 	}
 }
 
-func TestGetVCentersInUse(t *testing.T) {
+func TestPoolHasStorageFor(t *testing.T) {
 	tests := []struct {
-		name          string
-		assignedPools []*v1.Pool
-		expected      map[string]bool
+		name         string
+		leaseStorage int
+		poolStorage  int
+		available    int
+		expected     bool
 	}{
 		{
-			name:          "empty assigned pools returns empty map",
-			assignedPools: []*v1.Pool{},
-			expected:      map[string]bool{},
+			name:         "lease does not request storage",
+			leaseStorage: 0,
+			poolStorage:  1000,
+			available:    0,
+			expected:     true,
 		},
 		{
-			name: "single pool returns its vcenter server",
-			assignedPools: []*v1.Pool{
-				{
-					Spec: v1.PoolSpec{
-						FailureDomainSpec: v1.FailureDomainSpec{
-							VSpherePlatformFailureDomainSpec: configv1.VSpherePlatformFailureDomainSpec{
-								Server: "vcenter1.example.com",
-							},
-						},
-					},
-				},
-			},
-			expected: map[string]bool{
-				"vcenter1.example.com": true,
-			},
+			name:         "pool does not track storage",
+			leaseStorage: 720,
+			poolStorage:  0,
+			available:    0,
+			expected:     true,
 		},
 		{
-			name: "multiple pools on same vcenter deduplicates",
-			assignedPools: []*v1.Pool{
-				{
-					Spec: v1.PoolSpec{
-						FailureDomainSpec: v1.FailureDomainSpec{
-							VSpherePlatformFailureDomainSpec: configv1.VSpherePlatformFailureDomainSpec{
-								Server: "vcenter1.example.com",
-							},
-						},
-					},
-				},
-				{
-					Spec: v1.PoolSpec{
-						FailureDomainSpec: v1.FailureDomainSpec{
-							VSpherePlatformFailureDomainSpec: configv1.VSpherePlatformFailureDomainSpec{
-								Server: "vcenter1.example.com",
-							},
-						},
-					},
-				},
-			},
-			expected: map[string]bool{
-				"vcenter1.example.com": true,
-			},
+			name:         "enough datastore available",
+			leaseStorage: 720,
+			poolStorage:  1000,
+			available:    720,
+			expected:     true,
 		},
 		{
-			name: "pools on different vcenters returns all servers",
-			assignedPools: []*v1.Pool{
-				{
-					Spec: v1.PoolSpec{
-						FailureDomainSpec: v1.FailureDomainSpec{
-							VSpherePlatformFailureDomainSpec: configv1.VSpherePlatformFailureDomainSpec{
-								Server: "vcenter1.example.com",
-							},
-						},
-					},
-				},
-				{
-					Spec: v1.PoolSpec{
-						FailureDomainSpec: v1.FailureDomainSpec{
-							VSpherePlatformFailureDomainSpec: configv1.VSpherePlatformFailureDomainSpec{
-								Server: "vcenter2.example.com",
-							},
-						},
-					},
-				},
-				{
-					Spec: v1.PoolSpec{
-						FailureDomainSpec: v1.FailureDomainSpec{
-							VSpherePlatformFailureDomainSpec: configv1.VSpherePlatformFailureDomainSpec{
-								Server: "vcenter3.example.com",
-							},
-						},
-					},
-				},
-			},
-			expected: map[string]bool{
-				"vcenter1.example.com": true,
-				"vcenter2.example.com": true,
-				"vcenter3.example.com": true,
-			},
+			name:         "datastore exhausted",
+			leaseStorage: 720,
+			poolStorage:  1000,
+			available:    719,
+			expected:     false,
 		},
 		{
-			name: "pool with empty server is skipped",
-			assignedPools: []*v1.Pool{
-				{
-					Spec: v1.PoolSpec{
-						FailureDomainSpec: v1.FailureDomainSpec{
-							VSpherePlatformFailureDomainSpec: configv1.VSpherePlatformFailureDomainSpec{
-								Server: "",
-							},
-						},
-					},
-				},
-				{
-					Spec: v1.PoolSpec{
-						FailureDomainSpec: v1.FailureDomainSpec{
-							VSpherePlatformFailureDomainSpec: configv1.VSpherePlatformFailureDomainSpec{
-								Server: "vcenter1.example.com",
-							},
-						},
-					},
-				},
-			},
-			expected: map[string]bool{
-				"vcenter1.example.com": true,
-			},
+			name:         "datastore overcommitted",
+			leaseStorage: 10,
+			poolStorage:  1000,
+			available:    -200,
+			expected:     false,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := GetVCentersInUse(tt.assignedPools)
-
-			if len(result) != len(tt.expected) {
-				t.Errorf("GetVCentersInUse() returned %d vcenters, expected %d: got %v, want %v",
-					len(result), len(tt.expected), result, tt.expected)
+			lease := &v1.Lease{Spec: v1.LeaseSpec{Storage: tt.leaseStorage}}
+			pool := &v1.Pool{
+				Spec:   v1.PoolSpec{Storage: tt.poolStorage},
+				Status: v1.PoolStatus{DatastoreAvailable: tt.available},
 			}
-
-			for vcenter, expectedVal := range tt.expected {
-				if result[vcenter] != expectedVal {
-					t.Errorf("GetVCentersInUse() missing expected vcenter %q", vcenter)
-				}
+			if result := PoolHasStorageFor(lease, pool); result != tt.expected {
+				t.Errorf("PoolHasStorageFor() = %v, expected %v", result, tt.expected)
 			}
 		})
 	}
 }
 
-// TestGetFittingPoolsWithVCenterCap tests pool selection with vCenter constraints
-// when VCenters=1 and multiple pools are needed
-func TestGetFittingPoolsWithVCenterCap(t *testing.T) {
+func TestPoolCapacity(t *testing.T) {
+	ratio := func(value string) *resource.Quantity {
+		q := resource.MustParse(value)
+		return &q
+	}
+
 	tests := []struct {
-		name                  string
-		lease                 *v1.Lease
-		pools                 []*v1.Pool
-		excludedVCenters      map[string]bool
-		expectedFittingCount  int
-		expectedExcludedCount int
-		expectVCenter         string // Expected vCenter for fitting pools
+		name            string
+		overCommit      v1.PoolOverCommit
+		overCommitRatio string
+		vcpus           int
+		memory          int
+		storage         int
+		expectErr       bool
 	}{
-		{
-			name: "excludes vcenter with insufficient pools when VCenters=1",
-			lease: &v1.Lease{
-				ObjectMeta: metav1.ObjectMeta{
-					Name:      "test-lease",
-					Namespace: "default",
-				},
-				Spec: v1.LeaseSpec{
-					VCpus:    16,
-					Memory:   32,
-					Pools:    3,
-					VCenters: 1,
-				},
-			},
-			pools: []*v1.Pool{
-				// vcenter1 has only 1 pool - should be excluded
-				{
-					ObjectMeta: metav1.ObjectMeta{Name: "vcenter1-pool1"},
-					Spec: v1.PoolSpec{
-						FailureDomainSpec: v1.FailureDomainSpec{
-							VSpherePlatformFailureDomainSpec: configv1.VSpherePlatformFailureDomainSpec{
-								Server: "vcenter1.example.com",
-							},
-						},
-						VCpus:  100,
-						Memory: 1000,
-					},
-					Status: v1.PoolStatus{
-						VCpusAvailable:  100,
-						MemoryAvailable: 1000,
-					},
-				},
-				// vcenter2 has 3 pools - should be available
-				{
-					ObjectMeta: metav1.ObjectMeta{Name: "vcenter2-pool1"},
-					Spec: v1.PoolSpec{
-						FailureDomainSpec: v1.FailureDomainSpec{
-							VSpherePlatformFailureDomainSpec: configv1.VSpherePlatformFailureDomainSpec{
-								Server: "vcenter2.example.com",
-							},
-						},
-						VCpus:  100,
-						Memory: 1000,
-					},
-					Status: v1.PoolStatus{
-						VCpusAvailable:  100,
-						MemoryAvailable: 1000,
-					},
-				},
-				{
-					ObjectMeta: metav1.ObjectMeta{Name: "vcenter2-pool2"},
-					Spec: v1.PoolSpec{
-						FailureDomainSpec: v1.FailureDomainSpec{
-							VSpherePlatformFailureDomainSpec: configv1.VSpherePlatformFailureDomainSpec{
-								Server: "vcenter2.example.com",
-							},
-						},
-						VCpus:  100,
-						Memory: 1000,
-					},
-					Status: v1.PoolStatus{
-						VCpusAvailable:  100,
-						MemoryAvailable: 1000,
-					},
-				},
-				{
-					ObjectMeta: metav1.ObjectMeta{Name: "vcenter2-pool3"},
-					Spec: v1.PoolSpec{
-						FailureDomainSpec: v1.FailureDomainSpec{
-							VSpherePlatformFailureDomainSpec: configv1.VSpherePlatformFailureDomainSpec{
-								Server: "vcenter2.example.com",
-							},
-						},
-						VCpus:  100,
-						Memory: 1000,
-					},
-					Status: v1.PoolStatus{
-						VCpusAvailable:  100,
-						MemoryAvailable: 1000,
-					},
-				},
-			},
-			excludedVCenters: map[string]bool{
-				"vcenter1.example.com": true,
-			},
-			expectedFittingCount:  3,
-			expectedExcludedCount: 1,
-			expectVCenter:         "vcenter2.example.com",
-		},
-		{
-			name: "allows vcenter with sufficient pools when VCenters=1",
-			lease: &v1.Lease{
-				ObjectMeta: metav1.ObjectMeta{
-					Name:      "test-lease",
-					Namespace: "default",
-				},
-				Spec: v1.LeaseSpec{
-					VCpus:    16,
-					Memory:   32,
-					Pools:    2,
-					VCenters: 1,
-				},
-			},
-			pools: []*v1.Pool{
-				{
-					ObjectMeta: metav1.ObjectMeta{Name: "vcenter1-pool1"},
-					Spec: v1.PoolSpec{
-						FailureDomainSpec: v1.FailureDomainSpec{
-							VSpherePlatformFailureDomainSpec: configv1.VSpherePlatformFailureDomainSpec{
-								Server: "vcenter1.example.com",
-							},
-						},
-						VCpus:  100,
-						Memory: 1000,
-					},
-					Status: v1.PoolStatus{
-						VCpusAvailable:  100,
-						MemoryAvailable: 1000,
-					},
-				},
-				{
-					ObjectMeta: metav1.ObjectMeta{Name: "vcenter1-pool2"},
-					Spec: v1.PoolSpec{
-						FailureDomainSpec: v1.FailureDomainSpec{
-							VSpherePlatformFailureDomainSpec: configv1.VSpherePlatformFailureDomainSpec{
-								Server: "vcenter1.example.com",
-							},
-						},
-						VCpus:  100,
-						Memory: 1000,
-					},
-					Status: v1.PoolStatus{
-						VCpusAvailable:  100,
-						MemoryAvailable: 1000,
-					},
-				},
-			},
-			excludedVCenters:      nil, // No exclusions
-			expectedFittingCount:  2,
-			expectedExcludedCount: 0,
-			expectVCenter:         "vcenter1.example.com",
-		},
-		{
-			name: "correctly counts only suitable pools (excludes NoSchedule)",
-			lease: &v1.Lease{
-				ObjectMeta: metav1.ObjectMeta{
-					Name:      "test-lease",
-					Namespace: "default",
-				},
-				Spec: v1.LeaseSpec{
-					VCpus:    16,
-					Memory:   32,
-					Pools:    2,
-					VCenters: 1,
-				},
-			},
-			pools: []*v1.Pool{
-				// vcenter1 has 2 pools but one is NoSchedule
-				{
-					ObjectMeta: metav1.ObjectMeta{Name: "vcenter1-pool1"},
-					Spec: v1.PoolSpec{
-						FailureDomainSpec: v1.FailureDomainSpec{
-							VSpherePlatformFailureDomainSpec: configv1.VSpherePlatformFailureDomainSpec{
-								Server: "vcenter1.example.com",
-							},
-						},
-						VCpus:  100,
-						Memory: 1000,
-					},
-					Status: v1.PoolStatus{
-						VCpusAvailable:  100,
-						MemoryAvailable: 1000,
-					},
-				},
-				{
-					ObjectMeta: metav1.ObjectMeta{Name: "vcenter1-pool2"},
-					Spec: v1.PoolSpec{
-						FailureDomainSpec: v1.FailureDomainSpec{
-							VSpherePlatformFailureDomainSpec: configv1.VSpherePlatformFailureDomainSpec{
-								Server: "vcenter1.example.com",
-							},
-						},
-						VCpus:      100,
-						Memory:     1000,
-						NoSchedule: true, // This pool should be filtered out
-					},
-					Status: v1.PoolStatus{
-						VCpusAvailable:  100,
-						MemoryAvailable: 1000,
-					},
-				},
-				// vcenter2 has 2 schedulable pools
-				{
-					ObjectMeta: metav1.ObjectMeta{Name: "vcenter2-pool1"},
-					Spec: v1.PoolSpec{
-						FailureDomainSpec: v1.FailureDomainSpec{
-							VSpherePlatformFailureDomainSpec: configv1.VSpherePlatformFailureDomainSpec{
-								Server: "vcenter2.example.com",
-							},
-						},
-						VCpus:  100,
-						Memory: 1000,
-					},
-					Status: v1.PoolStatus{
-						VCpusAvailable:  100,
-						MemoryAvailable: 1000,
-					},
-				},
-				{
-					ObjectMeta: metav1.ObjectMeta{Name: "vcenter2-pool2"},
-					Spec: v1.PoolSpec{
-						FailureDomainSpec: v1.FailureDomainSpec{
-							VSpherePlatformFailureDomainSpec: configv1.VSpherePlatformFailureDomainSpec{
-								Server: "vcenter2.example.com",
-							},
-						},
-						VCpus:  100,
-						Memory: 1000,
-					},
-					Status: v1.PoolStatus{
-						VCpusAvailable:  100,
-						MemoryAvailable: 1000,
-					},
-				},
-			},
-			excludedVCenters: map[string]bool{
-				"vcenter1.example.com": true, // Should be excluded (only 1 suitable pool)
-			},
-			expectedFittingCount:  2,
-			expectedExcludedCount: 2, // vcenter1-pool2 (NoSchedule) + vcenter1-pool1 (vcenter excluded)
-			expectVCenter:         "vcenter2.example.com",
-		},
+		{name: "no ratios", vcpus: 100, memory: 400, storage: 1000},
+		{name: "per resource ratios", overCommit: v1.PoolOverCommit{CPU: ratio("2.5"), Memory: ratio("1.2"), Storage: ratio("1.5")}, vcpus: 250, memory: 480, storage: 1500},
+		{name: "deprecated ratio applies to vcpus", overCommitRatio: "2.0", vcpus: 200, memory: 400, storage: 1000},
+		{name: "cpu ratio takes precedence over deprecated ratio", overCommit: v1.PoolOverCommit{CPU: ratio("3")}, overCommitRatio: "2.0", vcpus: 300, memory: 400, storage: 1000},
+		{name: "invalid deprecated ratio counts as 1", overCommitRatio: "lots", vcpus: 100, memory: 400, storage: 1000, expectErr: true},
+		{name: "zero ratio counts as 1", overCommit: v1.PoolOverCommit{Memory: ratio("0")}, vcpus: 100, memory: 400, storage: 1000, expectErr: true},
 	}
 
 	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			fittingPools, results := GetFittingPools(tt.lease, tt.pools, tt.excludedVCenters)
-
-			if len(fittingPools) != tt.expectedFittingCount {
-				t.Errorf("Expected %d fitting pools, got %d", tt.expectedFittingCount, len(fittingPools))
-			}
-
-			excludedCount := 0
-			for _, result := range results {
-				if result.MatchResults == PoolVCenterLimitReached || result.MatchResults == PoolNotSchedulable {
-					excludedCount++
-				}
-			}
-
-			if excludedCount != tt.expectedExcludedCount {
-				t.Errorf("Expected %d excluded pools, got %d", tt.expectedExcludedCount, excludedCount)
-			}
-
-			// Verify all fitting pools are from the expected vCenter
-			if tt.expectVCenter != "" {
-				for _, pool := range fittingPools {
-					if pool.Spec.Server != tt.expectVCenter {
-						t.Errorf("Expected pool %s to be from vCenter %s, got %s",
-							pool.Name, tt.expectVCenter, pool.Spec.Server)
-					}
-				}
-			}
-		})
-	}
-}
-
-// TestGetPoolWithStrategyMultiVCenterConstraint tests the full pool selection flow
-// with vCenter constraints
-func TestGetPoolWithStrategyMultiVCenterConstraint(t *testing.T) {
-	lease := &v1.Lease{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      "test-lease",
-			Namespace: "default",
-		},
-		Spec: v1.LeaseSpec{
-			VCpus:    16,
-			Memory:   32,
-			Pools:    3,
-			VCenters: 1,
-		},
-	}
-
-	pools := []*v1.Pool{
-		// vcenter1 has only 1 pool - insufficient
-		{
-			TypeMeta: metav1.TypeMeta{
-				APIVersion: "vspherecapacitymanager.splat.io/v1",
-				Kind:       "Pool",
-			},
-			ObjectMeta: metav1.ObjectMeta{
-				Name: "vcenter1-pool1",
-				UID:  "uid-vcenter1-pool1",
-			},
-			Spec: v1.PoolSpec{
-				FailureDomainSpec: v1.FailureDomainSpec{
-					VSpherePlatformFailureDomainSpec: configv1.VSpherePlatformFailureDomainSpec{
-						Server: "vcenter1.example.com",
-					},
-				},
-				VCpus:  100,
-				Memory: 1000,
-			},
-			Status: v1.PoolStatus{
-				VCpusAvailable:  100,
-				MemoryAvailable: 1000,
-			},
-		},
-		// vcenter2 has 3 pools - sufficient
-		{
-			TypeMeta: metav1.TypeMeta{
-				APIVersion: "vspherecapacitymanager.splat.io/v1",
-				Kind:       "Pool",
-			},
-			ObjectMeta: metav1.ObjectMeta{
-				Name: "vcenter2-pool1",
-				UID:  "uid-vcenter2-pool1",
-			},
-			Spec: v1.PoolSpec{
-				FailureDomainSpec: v1.FailureDomainSpec{
-					VSpherePlatformFailureDomainSpec: configv1.VSpherePlatformFailureDomainSpec{
-						Server: "vcenter2.example.com",
-					},
-				},
-				VCpus:  100,
-				Memory: 1000,
-			},
-			Status: v1.PoolStatus{
-				VCpusAvailable:  100,
-				MemoryAvailable: 1000,
-			},
-		},
-		{
-			TypeMeta: metav1.TypeMeta{
-				APIVersion: "vspherecapacitymanager.splat.io/v1",
-				Kind:       "Pool",
-			},
-			ObjectMeta: metav1.ObjectMeta{
-				Name: "vcenter2-pool2",
-				UID:  "uid-vcenter2-pool2",
-			},
-			Spec: v1.PoolSpec{
-				FailureDomainSpec: v1.FailureDomainSpec{
-					VSpherePlatformFailureDomainSpec: configv1.VSpherePlatformFailureDomainSpec{
-						Server: "vcenter2.example.com",
-					},
-				},
-				VCpus:  100,
-				Memory: 1000,
-			},
-			Status: v1.PoolStatus{
-				VCpusAvailable:  100,
-				MemoryAvailable: 1000,
-			},
-		},
-		{
-			TypeMeta: metav1.TypeMeta{
-				APIVersion: "vspherecapacitymanager.splat.io/v1",
-				Kind:       "Pool",
-			},
-			ObjectMeta: metav1.ObjectMeta{
-				Name: "vcenter2-pool3",
-				UID:  "uid-vcenter2-pool3",
-			},
-			Spec: v1.PoolSpec{
-				FailureDomainSpec: v1.FailureDomainSpec{
-					VSpherePlatformFailureDomainSpec: configv1.VSpherePlatformFailureDomainSpec{
-						Server: "vcenter2.example.com",
-					},
-				},
-				VCpus:  100,
-				Memory: 1000,
-			},
-			Status: v1.PoolStatus{
-				VCpusAvailable:  100,
-				MemoryAvailable: 1000,
-			},
-		},
-	}
+		t.Run(tt.name, func(t *testing.T) {
+			pool := &v1.Pool{Spec: v1.PoolSpec{
+				VCpus:           100,
+				Memory:          400,
+				Storage:         1000,
+				OverCommit:      tt.overCommit,
+				OverCommitRatio: tt.overCommitRatio,
+			}}
 
-	// Exclude vcenter1 because it doesn't have enough pools
-	excludedVCenters := map[string]bool{
-		"vcenter1.example.com": true,
+			if _, _, _, err := PoolOverCommitRatios(pool); (err != nil) != tt.expectErr {
+				t.Errorf("expected error %v, got %v", tt.expectErr, err)
+			}
+			vcpus, memory, storage := PoolCapacity(pool)
+			if vcpus != tt.vcpus || memory != tt.memory || storage != tt.storage {
+				t.Errorf("expected capacity %d/%d/%d, got %d/%d/%d", tt.vcpus, tt.memory, tt.storage, vcpus, memory, storage)
+			}
+		})
 	}
+}
+
+func TestMaxAchievablePoolsCapacity(t *testing.T) {
+	memoryRatio := resource.MustParse("1.2")
+	small := testPool("small", "vcenter1.example.com")
+	small.Spec.VCpus, small.Spec.Memory = 16, 64
+	ballooned := testPool("ballooned", "vcenter1.example.com")
+	ballooned.Spec.VCpus, ballooned.Spec.Memory = 16, 64
+	ballooned.Spec.OverCommit.Memory = &memoryRatio
 
-	pool, err := GetPoolWithStrategy(lease, pools, v1.RESOURCE_ALLOCATION_STRATEGY_UNDERUTILIZED, excludedVCenters)
-	if err != nil {
-		t.Fatalf("GetPoolWithStrategy failed: %v", err)
+	lease := &v1.Lease{Spec: v1.LeaseSpec{VCpus: 8, Memory: 72, Pools: 1}}
+	if got := MaxAchievablePools(lease, []*v1.Pool{small, ballooned}); got != 1 {
+		t.Errorf("expected only the pool with overcommitted memory to fit, got %d achievable pools", got)
 	}
+	if ok, _ := IsLeaseSatisfiable(lease, []*v1.Pool{small}); ok {
+		t.Errorf("expected a lease larger than the pool capacity to be unsatisfiable")
+	}
+}
 
-	if pool.Spec.Server != "vcenter2.example.com" {
-		t.Errorf("Expected pool from vcenter2.example.com, got %s", pool.Spec.Server)
+func TestGetFittingPoolsExtendedResources(t *testing.T) {
+	withHosts := testPool("with-hosts", "vcenter1.example.com")
+	withHosts.Spec.VCpus, withHosts.Spec.Memory = 100, 100
+	withHosts.Spec.ExtendedResources = map[string]int{"vsphere.io/hosts": 4}
+	withHosts.Status = v1.PoolStatus{VCpusAvailable: 100, MemoryAvailable: 100, ExtendedResourcesAvailable: map[string]int{"vsphere.io/hosts": 1}}
+
+	withoutHosts := testPool("without-hosts", "vcenter1.example.com")
+	withoutHosts.Spec.VCpus, withoutHosts.Spec.Memory = 100, 100
+	withoutHosts.Status = v1.PoolStatus{VCpusAvailable: 100, MemoryAvailable: 100}
+
+	allPools := []*v1.Pool{withHosts, withoutHosts}
+
+	lease := &v1.Lease{Spec: v1.LeaseSpec{VCpus: 8, Memory: 16, ExtendedResources: map[string]int{"vsphere.io/hosts": 1}}}
+	fitting, results := GetFittingPools(lease, allPools)
+	if len(fitting) != 1 || fitting[0].Name != "with-hosts" {
+		t.Fatalf("expected only the pool offering hosts to fit, got %v", fitting)
+	}
+	if len(results) != 1 || results[0].Reason != ReasonPoolInsufficientExtended || results[0].Resource != "vsphere.io/hosts" || results[0].Required != 1 || results[0].Available != 0 {
+		t.Errorf("expected %s on vsphere.io/hosts for the other pool, got %+v", ReasonPoolInsufficientExtended, results[0])
 	}
 
-	// Verify the pool was added to lease's OwnerReferences
-	if len(lease.OwnerReferences) != 1 {
-		t.Errorf("Expected 1 owner reference, got %d", len(lease.OwnerReferences))
+	lease.Spec.ExtendedResources["vsphere.io/hosts"] = 2
+	if fitting, _ := GetFittingPools(lease, allPools); len(fitting) != 0 {
+		t.Errorf("expected no pool to fit once the hosts are in use, got %v", fitting)
+	}
+	if got := MaxAchievablePools(lease, allPools); got != 1 {
+		t.Errorf("expected the pool offering hosts to remain achievable, got %d", got)
 	}
 
-	if lease.OwnerReferences[0].Name != pool.Name {
-		t.Errorf("Expected owner reference to pool %s, got %s", pool.Name, lease.OwnerReferences[0].Name)
+	lease.Spec.ExtendedResources["vsphere.io/hosts"] = 5
+	if ok, _ := IsLeaseSatisfiable(lease, allPools); ok {
+		t.Errorf("expected a lease requesting more hosts than any pool offers to be unsatisfiable")
 	}
 }
 
-// TestGetPoolWithStrategyVCenters2Pools3 tests the VCenters=2, Pools=3 scenario
-func TestGetPoolWithStrategyVCenters2Pools3(t *testing.T) {
-	lease := &v1.Lease{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      "test-lease",
-			Namespace: "default",
-		},
-		Spec: v1.LeaseSpec{
-			VCpus:    16,
-			Memory:   32,
-			Pools:    3,
-			VCenters: 2,
+func TestGetVCentersInUse(t *testing.T) {
+	tests := []struct {
+		name          string
+		assignedPools []*v1.Pool
+		expected      map[string]bool
+	}{
+		{
+			name:          "empty assigned pools returns empty map",
+			assignedPools: []*v1.Pool{},
+			expected:      map[string]bool{},
 		},
-	}
-
-	pools := []*v1.Pool{
-		// vcenter1 has only 1 pool - should be excluded (needs ceil(3/2)=2)
 		{
-			TypeMeta: metav1.TypeMeta{
-				APIVersion: "vspherecapacitymanager.splat.io/v1",
-				Kind:       "Pool",
-			},
-			ObjectMeta: metav1.ObjectMeta{
-				Name: "vcenter1-pool1",
-				UID:  "uid-vcenter1-pool1",
-			},
-			Spec: v1.PoolSpec{
-				FailureDomainSpec: v1.FailureDomainSpec{
-					VSpherePlatformFailureDomainSpec: configv1.VSpherePlatformFailureDomainSpec{
-						Server: "vcenter1.example.com",
+			name: "single pool returns its vcenter server",
+			assignedPools: []*v1.Pool{
+				{
+					Spec: v1.PoolSpec{
+						FailureDomainSpec: v1.FailureDomainSpec{
+							VSpherePlatformFailureDomainSpec: configv1.VSpherePlatformFailureDomainSpec{
+								Server: "vcenter1.example.com",
+							},
+						},
 					},
 				},
-				VCpus:  100,
-				Memory: 1000,
 			},
-			Status: v1.PoolStatus{
-				VCpusAvailable:  100,
-				MemoryAvailable: 1000,
+			expected: map[string]bool{
+				"vcenter1.example.com": true,
 			},
 		},
-		// vcenter2 has 2 pools - sufficient
 		{
-			TypeMeta: metav1.TypeMeta{
-				APIVersion: "vspherecapacitymanager.splat.io/v1",
-				Kind:       "Pool",
-			},
-			ObjectMeta: metav1.ObjectMeta{
-				Name: "vcenter2-pool1",
-				UID:  "uid-vcenter2-pool1",
-			},
-			Spec: v1.PoolSpec{
-				FailureDomainSpec: v1.FailureDomainSpec{
-					VSpherePlatformFailureDomainSpec: configv1.VSpherePlatformFailureDomainSpec{
-						Server: "vcenter2.example.com",
+			name: "multiple pools on same vcenter deduplicates",
+			assignedPools: []*v1.Pool{
+				{
+					Spec: v1.PoolSpec{
+						FailureDomainSpec: v1.FailureDomainSpec{
+							VSpherePlatformFailureDomainSpec: configv1.VSpherePlatformFailureDomainSpec{
+								Server: "vcenter1.example.com",
+							},
+						},
 					},
 				},
-				VCpus:  100,
-				Memory: 1000,
-			},
-			Status: v1.PoolStatus{
-				VCpusAvailable:  100,
-				MemoryAvailable: 1000,
-			},
-		},
-		{
-			TypeMeta: metav1.TypeMeta{
-				APIVersion: "vspherecapacitymanager.splat.io/v1",
-				Kind:       "Pool",
-			},
-			ObjectMeta: metav1.ObjectMeta{
-				Name: "vcenter2-pool2",
-				UID:  "uid-vcenter2-pool2",
-			},
-			Spec: v1.PoolSpec{
-				FailureDomainSpec: v1.FailureDomainSpec{
-					VSpherePlatformFailureDomainSpec: configv1.VSpherePlatformFailureDomainSpec{
-						Server: "vcenter2.example.com",
+				{
+					Spec: v1.PoolSpec{
+						FailureDomainSpec: v1.FailureDomainSpec{
+							VSpherePlatformFailureDomainSpec: configv1.VSpherePlatformFailureDomainSpec{
+								Server: "vcenter1.example.com",
+							},
+						},
 					},
 				},
-				VCpus:  100,
-				Memory: 1000,
 			},
-			Status: v1.PoolStatus{
-				VCpusAvailable:  100,
-				MemoryAvailable: 1000,
+			expected: map[string]bool{
+				"vcenter1.example.com": true,
 			},
 		},
-		// vcenter3 has 2 pools - sufficient
 		{
-			TypeMeta: metav1.TypeMeta{
-				APIVersion: "vspherecapacitymanager.splat.io/v1",
-				Kind:       "Pool",
-			},
-			ObjectMeta: metav1.ObjectMeta{
-				Name: "vcenter3-pool1",
-				UID:  "uid-vcenter3-pool1",
-			},
-			Spec: v1.PoolSpec{
-				FailureDomainSpec: v1.FailureDomainSpec{
-					VSpherePlatformFailureDomainSpec: configv1.VSpherePlatformFailureDomainSpec{
-						Server: "vcenter3.example.com",
+			name: "pools on different vcenters returns all servers",
+			assignedPools: []*v1.Pool{
+				{
+					Spec: v1.PoolSpec{
+						FailureDomainSpec: v1.FailureDomainSpec{
+							VSpherePlatformFailureDomainSpec: configv1.VSpherePlatformFailureDomainSpec{
+								Server: "vcenter1.example.com",
+							},
+						},
+					},
+				},
+				{
+					Spec: v1.PoolSpec{
+						FailureDomainSpec: v1.FailureDomainSpec{
+							VSpherePlatformFailureDomainSpec: configv1.VSpherePlatformFailureDomainSpec{
+								Server: "vcenter2.example.com",
+							},
+						},
+					},
+				},
+				{
+					Spec: v1.PoolSpec{
+						FailureDomainSpec: v1.FailureDomainSpec{
+							VSpherePlatformFailureDomainSpec: configv1.VSpherePlatformFailureDomainSpec{
+								Server: "vcenter3.example.com",
+							},
+						},
 					},
 				},
-				VCpus:  100,
-				Memory: 1000,
 			},
-			Status: v1.PoolStatus{
-				VCpusAvailable:  100,
-				MemoryAvailable: 1000,
+			expected: map[string]bool{
+				"vcenter1.example.com": true,
+				"vcenter2.example.com": true,
+				"vcenter3.example.com": true,
 			},
 		},
 		{
-			TypeMeta: metav1.TypeMeta{
-				APIVersion: "vspherecapacitymanager.splat.io/v1",
-				Kind:       "Pool",
-			},
-			ObjectMeta: metav1.ObjectMeta{
-				Name: "vcenter3-pool2",
-				UID:  "uid-vcenter3-pool2",
-			},
-			Spec: v1.PoolSpec{
-				FailureDomainSpec: v1.FailureDomainSpec{
-					VSpherePlatformFailureDomainSpec: configv1.VSpherePlatformFailureDomainSpec{
-						Server: "vcenter3.example.com",
+			name: "pool with empty server is skipped",
+			assignedPools: []*v1.Pool{
+				{
+					Spec: v1.PoolSpec{
+						FailureDomainSpec: v1.FailureDomainSpec{
+							VSpherePlatformFailureDomainSpec: configv1.VSpherePlatformFailureDomainSpec{
+								Server: "",
+							},
+						},
+					},
+				},
+				{
+					Spec: v1.PoolSpec{
+						FailureDomainSpec: v1.FailureDomainSpec{
+							VSpherePlatformFailureDomainSpec: configv1.VSpherePlatformFailureDomainSpec{
+								Server: "vcenter1.example.com",
+							},
+						},
 					},
 				},
-				VCpus:  100,
-				Memory: 1000,
 			},
-			Status: v1.PoolStatus{
-				VCpusAvailable:  100,
-				MemoryAvailable: 1000,
+			expected: map[string]bool{
+				"vcenter1.example.com": true,
 			},
 		},
 	}
 
-	// Exclude vcenter1 because it doesn't have enough pools (needs ceil(3/2)=2, has 1)
-	excludedVCenters := map[string]bool{
-		"vcenter1.example.com": true,
-	}
-
-	pool, err := GetPoolWithStrategy(lease, pools, v1.RESOURCE_ALLOCATION_STRATEGY_UNDERUTILIZED, excludedVCenters)
-	if err != nil {
-		t.Fatalf("GetPoolWithStrategy failed: %v", err)
-	}
-
-	// Pool should be from vcenter2 or vcenter3, not vcenter1
-	if pool.Spec.Server == "vcenter1.example.com" {
-		t.Errorf("Should not have selected pool from vcenter1 (excluded), got %s", pool.Name)
-	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := GetVCentersInUse(tt.assignedPools)
 
-	if pool.Spec.Server != "vcenter2.example.com" && pool.Spec.Server != "vcenter3.example.com" {
-		t.Errorf("Expected pool from vcenter2 or vcenter3, got %s from %s", pool.Name, pool.Spec.Server)
-	}
+			if len(result) != len(tt.expected) {
+				t.Errorf("GetVCentersInUse() returned %d vcenters, expected %d: got %v, want %v",
+					len(result), len(tt.expected), result, tt.expected)
+			}
 
-	// Verify the pool was added to lease's OwnerReferences
-	if len(lease.OwnerReferences) != 1 {
-		t.Errorf("Expected 1 owner reference, got %d", len(lease.OwnerReferences))
+			for vcenter, expectedVal := range tt.expected {
+				if result[vcenter] != expectedVal {
+					t.Errorf("GetVCentersInUse() missing expected vcenter %q", vcenter)
+				}
+			}
+		})
 	}
-
-	t.Logf("Successfully selected pool %s from vCenter %s", pool.Name, pool.Spec.Server)
 }
 
 // testPool builds a minimal pool on the given vcenter server, with optional name/labels/taints.
@@ -1777,6 +1561,50 @@ func TestMaxAchievablePools(t *testing.T) {
 			},
 			expected: 1,
 		},
+		{
+			name: "PreferNoSchedule taints do not narrow structural match",
+			lease: &v1.Lease{
+				Spec: v1.LeaseSpec{Pools: 2},
+			},
+			pools: []*v1.Pool{
+				func() *v1.Pool {
+					p := testPool("vc1-pool1", "vcenter1.example.com")
+					p.Spec.Taints = []v1.Taint{{Key: "retiring", Value: "true", Effect: v1.TaintEffectPreferNoSchedule}}
+					return p
+				}(),
+				testPool("vc1-pool2", "vcenter1.example.com"),
+			},
+			expected: 2,
+		},
+		{
+			name: "poolSelectorExpressions narrow structural match",
+			lease: &v1.Lease{
+				Spec: v1.LeaseSpec{
+					Pools: 3,
+					PoolSelectorExpressions: []metav1.LabelSelectorRequirement{
+						{Key: "region", Operator: metav1.LabelSelectorOpIn, Values: []string{"us-east", "us-south"}},
+					},
+				},
+			},
+			pools: []*v1.Pool{
+				func() *v1.Pool {
+					p := testPool("vc1-pool1", "vcenter1.example.com")
+					p.Labels = map[string]string{"region": "us-east"}
+					return p
+				}(),
+				func() *v1.Pool {
+					p := testPool("vc1-pool2", "vcenter1.example.com")
+					p.Labels = map[string]string{"region": "us-south"}
+					return p
+				}(),
+				func() *v1.Pool {
+					p := testPool("vc1-pool3", "vcenter1.example.com")
+					p.Labels = map[string]string{"region": "us-west"}
+					return p
+				}(),
+			},
+			expected: 2,
+		},
 	}
 
 	for _, tt := range tests {
@@ -1830,6 +1658,99 @@ func TestIsLeaseSatisfiable(t *testing.T) {
 			},
 			expected: true,
 		},
+		{
+			name: "invalid poolSelectorExpressions can never be satisfied",
+			lease: &v1.Lease{
+				Spec: v1.LeaseSpec{
+					PoolSelectorExpressions: []metav1.LabelSelectorRequirement{
+						{Key: "region", Operator: "Sometimes", Values: []string{"us-east"}},
+					},
+				},
+			},
+			pools: []*v1.Pool{
+				testPool("vc1-pool1", "vcenter1.example.com"),
+			},
+			expected: false,
+		},
+		{
+			name: "invalid leaseAntiAffinity selector can never be satisfied",
+			lease: &v1.Lease{
+				Spec: v1.LeaseSpec{
+					LeaseAntiAffinity: []v1.LeaseAffinityTerm{
+						{
+							LabelSelector: metav1.LabelSelector{
+								MatchExpressions: []metav1.LabelSelectorRequirement{
+									{Key: "boskos-lease-id", Operator: metav1.LabelSelectorOpIn},
+								},
+							},
+							TopologyKey: v1.LeaseTopologyKeyServer,
+						},
+					},
+				},
+			},
+			pools: []*v1.Pool{
+				testPool("vc1-pool1", "vcenter1.example.com"),
+			},
+			expected: false,
+		},
+		{
+			name: "topology spread over vcenters the inventory has",
+			lease: &v1.Lease{
+				Spec: v1.LeaseSpec{
+					Pools:                     2,
+					TopologySpreadConstraints: []v1.TopologySpreadConstraint{{TopologyKey: "server", MinDomains: 2}},
+				},
+			},
+			pools: []*v1.Pool{
+				testPool("vc1-pool1", "vcenter1.example.com"),
+				testPool("vc2-pool1", "vcenter2.example.com"),
+			},
+			expected: true,
+		},
+		{
+			name: "topology spread needs more vcenters than the inventory has",
+			lease: &v1.Lease{
+				Spec: v1.LeaseSpec{
+					Pools:                     2,
+					TopologySpreadConstraints: []v1.TopologySpreadConstraint{{TopologyKey: "server", MinDomains: 2}},
+				},
+			},
+			pools: []*v1.Pool{
+				testPool("vc1-pool1", "vcenter1.example.com"),
+				testPool("vc1-pool2", "vcenter1.example.com"),
+			},
+			expected: false,
+		},
+		{
+			name: "topology spread needs more domains than pools",
+			lease: &v1.Lease{
+				Spec: v1.LeaseSpec{
+					Pools:                     2,
+					TopologySpreadConstraints: []v1.TopologySpreadConstraint{{TopologyKey: "server", MinDomains: 3}},
+				},
+			},
+			pools: []*v1.Pool{
+				testPool("vc1-pool1", "vcenter1.example.com"),
+				testPool("vc2-pool1", "vcenter2.example.com"),
+				testPool("vc3-pool1", "vcenter3.example.com"),
+			},
+			expected: false,
+		},
+		{
+			name: "topology spread needs more vcenters than the vcenters cap",
+			lease: &v1.Lease{
+				Spec: v1.LeaseSpec{
+					Pools:                     2,
+					VCenters:                  1,
+					TopologySpreadConstraints: []v1.TopologySpreadConstraint{{TopologyKey: "server", MinDomains: 2}},
+				},
+			},
+			pools: []*v1.Pool{
+				testPool("vc1-pool1", "vcenter1.example.com"),
+				testPool("vc2-pool1", "vcenter2.example.com"),
+			},
+			expected: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -1847,3 +1768,45 @@ func TestIsLeaseSatisfiable(t *testing.T) {
 		})
 	}
 }
+
+func TestNewUnschedulableReasons(t *testing.T) {
+	var results []*PoolFittingInfo
+	for i := 0; i < v1.MaxUnschedulablePools+2; i++ {
+		results = append(results, &PoolFittingInfo{
+			Pool:         testPool(fmt.Sprintf("memory-%02d", i), "vc1"),
+			MatchResults: PoolInsufficientMemory,
+			Reason:       ReasonPoolInsufficientMemory,
+			Resource:     ResourceMemory,
+			Required:     96,
+			Available:    64,
+		})
+	}
+	results = append(results,
+		&PoolFittingInfo{Pool: testPool("a-tainted", "vc1"), MatchResults: PoolTaintNotTolerated, Reason: ReasonPoolTaintNotTolerated},
+		&PoolFittingInfo{Pool: testPool("a-plugin", "vc1"), MatchResults: "rejected by plugin"},
+	)
+
+	reasons := NewUnschedulableReasons(results, metav1.Now())
+
+	expectedSummary := []v1.UnschedulableReasonCount{
+		{Reason: ReasonPoolInsufficientMemory, Count: v1.MaxUnschedulablePools + 2},
+		{Reason: ReasonPoolTaintNotTolerated, Count: 1},
+		{Reason: "Unknown", Count: 1},
+	}
+	if !reflect.DeepEqual(reasons.Summary, expectedSummary) {
+		t.Errorf("Summary = %v, expected %v", reasons.Summary, expectedSummary)
+	}
+	if len(reasons.Pools) != v1.MaxUnschedulablePools {
+		t.Errorf("expected %d pools, got %d", v1.MaxUnschedulablePools, len(reasons.Pools))
+	}
+	if reasons.OmittedPools != 4 {
+		t.Errorf("expected 4 omitted pools, got %d", reasons.OmittedPools)
+	}
+	if reasons.Pools[0].Pool != "a-plugin" || reasons.Pools[1].Pool != "a-tainted" {
+		t.Errorf("expected pools to be ordered by name, got %s, %s", reasons.Pools[0].Pool, reasons.Pools[1].Pool)
+	}
+	memory := reasons.Pools[2]
+	if memory.Resource != ResourceMemory || memory.Required != 96 || memory.Available != 64 {
+		t.Errorf("expected measured memory values, got %+v", memory)
+	}
+}
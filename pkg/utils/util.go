@@ -31,7 +31,17 @@ func init() {
 		export dns_server="{{.Nameserver}}"
 		export vlanid="{{.VlanId}}"
 		export phydc="{{.IDatacenter}}"
-		export primaryrouterhostname="{{.PrimaryRouterHostname}}"`
+		export primaryrouterhostname="{{.PrimaryRouterHostname}}"{{if .APIVIP}}
+		export api_vip="{{.APIVIP}}"{{end}}{{if .IngressVIP}}
+		export ingress_vip="{{.IngressVIP}}"{{end}}{{if .BootstrapIP}}
+		export bootstrap_ip="{{.BootstrapIP}}"{{end}}{{if .NodeIPs}}
+		export node_ips="{{.NodeIPs}}"{{end}}{{if .IPAddresses}}
+		export ip_addresses="{{.IPAddresses}}"{{end}}{{if .IPFamilies}}
+		export ip_families="{{.IPFamilies}}"{{end}}{{if .GatewayIPv6}}
+		export gateway_ipv6="{{.GatewayIPv6}}"
+		export ipv6_prefix="{{.IPv6Prefix}}"
+		export cidr_ipv6="{{.CidrIPv6}}"
+		export start_ipv6_address="{{.StartIPv6Address}}"{{end}}`
 
 	parsedTemplate, err = template.New("source").Parse(sourceTemplate)
 	if err != nil {
@@ -87,17 +97,32 @@ func DoesLeaseHaveNetworks(lease *v1.Lease) bool {
 	return requiredNetworks == 0
 }
 
-func GenerateEnvVars(lease *v1.Lease, pool *v1.Pool, network *v1.Network) error {
-	var portgroup string
-	for _, portgroup = range pool.Spec.Topology.Networks {
+// poolPortGroup returns the port group of the network as named by the topology of the pool. Networks
+// bound to the pool by networkRefs may not be listed in the topology, their port group is used as is.
+func poolPortGroup(pool *v1.Pool, network *v1.Network) string {
+	for _, portgroup := range pool.Spec.Topology.Networks {
 		if strings.Contains(portgroup, network.Spec.PortGroupName) {
-			break
+			tokens := strings.Split(portgroup, "/")
+			if len(tokens) >= 3 {
+				return tokens[len(tokens)-1]
+			}
+			return portgroup
 		}
 	}
-	tokens := strings.Split(portgroup, "/")
-	if len(tokens) >= 3 {
-		portgroup = tokens[len(tokens)-1]
+	return network.Spec.PortGroupName
+}
+
+// networkGateway returns the IPv4 gateway of the network, or its IPv6 gateway when an IPv6-only
+// network has no IPv4 gateway.
+func networkGateway(network *v1.Network) string {
+	if network.Spec.Gateway != nil {
+		return *network.Spec.Gateway
 	}
+	return network.Spec.GatewayIPv6
+}
+
+func GenerateEnvVars(lease *v1.Lease, pool *v1.Pool, network *v1.Network) error {
+	portgroup := poolPortGroup(pool, network)
 	inputs := struct {
 		Server                string
 		ComputeCluster        string
@@ -110,6 +135,16 @@ func GenerateEnvVars(lease *v1.Lease, pool *v1.Pool, network *v1.Network) error
 		Nameserver            string
 		IDatacenter           string
 		PrimaryRouterHostname string
+		APIVIP                string
+		IngressVIP            string
+		BootstrapIP           string
+		NodeIPs               string
+		IPAddresses           string
+		IPFamilies            string
+		GatewayIPv6           string
+		IPv6Prefix            string
+		CidrIPv6              int
+		StartIPv6Address      string
 	}{
 		Server:                pool.Spec.Server,
 		ComputeCluster:        pool.Spec.Topology.ComputeCluster,
@@ -117,8 +152,8 @@ func GenerateEnvVars(lease *v1.Lease, pool *v1.Pool, network *v1.Network) error
 		VDatacenter:           pool.Spec.Topology.Datacenter,
 		Datastore:             pool.Spec.Topology.Datastore,
 		PortGroup:             portgroup,
-		Gateway:               *network.Spec.Gateway,
-		Nameserver:            *network.Spec.Gateway, // Default to Gateway for legacy usage.  We'll update below if nameservers set.
+		Gateway:               networkGateway(network),
+		Nameserver:            networkGateway(network), // Default to Gateway for legacy usage.  We'll update below if nameservers set.
 		VlanId:                network.Spec.VlanId,
 		IDatacenter:           pool.Spec.IBMPoolSpec.Datacenter,
 		PrimaryRouterHostname: network.Spec.PrimaryRouterHostname,
@@ -129,6 +164,31 @@ func GenerateEnvVars(lease *v1.Lease, pool *v1.Pool, network *v1.Network) error
 		inputs.Nameserver = network.Spec.Nameservers[0]
 	}
 
+	// Export the IPv6 fields of the network when the lease asks for IPv6.
+	var families []string
+	for _, family := range lease.Spec.IPFamilies {
+		families = append(families, string(family))
+		if family == v1.IPFamilyIPv6 {
+			inputs.GatewayIPv6 = network.Spec.GatewayIPv6
+			inputs.IPv6Prefix = network.Spec.IpV6prefix
+			inputs.CidrIPv6 = network.Spec.CidrIPv6
+			inputs.StartIPv6Address = network.Spec.StartIPv6Address
+		}
+	}
+	inputs.IPFamilies = strings.Join(families, " ")
+
+	// Export the addresses allocated to the lease on this network, if any.
+	for _, allocation := range lease.Status.IPAllocations {
+		if allocation.Network == network.Name {
+			inputs.APIVIP = allocation.APIVIP
+			inputs.IngressVIP = allocation.IngressVIP
+			inputs.BootstrapIP = allocation.Bootstrap
+			inputs.NodeIPs = strings.Join(allocation.Nodes, " ")
+			inputs.IPAddresses = strings.Join(allocation.Addresses, " ")
+			break
+		}
+	}
+
 	outBytes := new(bytes.Buffer)
 	err := parsedTemplate.Execute(outBytes, inputs)
 	if err != nil {
@@ -151,16 +211,7 @@ func GenerateEnvVars(lease *v1.Lease, pool *v1.Pool, network *v1.Network) error
 // GenerateEnvVarsForServer generates environment variables for a specific pool and network,
 // returning the string without modifying the lease status
 func GenerateEnvVarsForServer(pool *v1.Pool, network *v1.Network) (string, error) {
-	var portgroup string
-	for _, portgroup = range pool.Spec.Topology.Networks {
-		if strings.Contains(portgroup, network.Spec.PortGroupName) {
-			break
-		}
-	}
-	tokens := strings.Split(portgroup, "/")
-	if len(tokens) >= 3 {
-		portgroup = tokens[len(tokens)-1]
-	}
+	portgroup := poolPortGroup(pool, network)
 	inputs := struct {
 		Server                string
 		ComputeCluster        string
@@ -173,6 +224,16 @@ func GenerateEnvVarsForServer(pool *v1.Pool, network *v1.Network) (string, error
 		Nameserver            string
 		IDatacenter           string
 		PrimaryRouterHostname string
+		APIVIP                string
+		IngressVIP            string
+		BootstrapIP           string
+		NodeIPs               string
+		IPAddresses           string
+		IPFamilies            string
+		GatewayIPv6           string
+		IPv6Prefix            string
+		CidrIPv6              int
+		StartIPv6Address      string
 	}{
 		Server:                pool.Spec.Server,
 		ComputeCluster:        pool.Spec.Topology.ComputeCluster,
@@ -180,8 +241,8 @@ func GenerateEnvVarsForServer(pool *v1.Pool, network *v1.Network) (string, error
 		VDatacenter:           pool.Spec.Topology.Datacenter,
 		Datastore:             pool.Spec.Topology.Datastore,
 		PortGroup:             portgroup,
-		Gateway:               *network.Spec.Gateway,
-		Nameserver:            *network.Spec.Gateway,
+		Gateway:               networkGateway(network),
+		Nameserver:            networkGateway(network),
 		VlanId:                network.Spec.VlanId,
 		IDatacenter:           pool.Spec.IBMPoolSpec.Datacenter,
 		PrimaryRouterHostname: network.Spec.PrimaryRouterHostname,